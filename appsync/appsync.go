@@ -0,0 +1,157 @@
+// Package appsync provides conveniences for authorizing requests to AWS
+// AppSync GraphQL APIs with the API key and the Amazon Cognito user pool or
+// OpenID Connect authorization modes.
+package appsync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Endpoint returns the URL of the GraphQL API with the given ID in region,
+// e.g. "eu-west-1".
+func Endpoint(apiID, region string) string {
+	return "https://" + apiID + ".appsync-api." + region + ".amazonaws.com/graphql"
+}
+
+// WithAPIKey makes the client authorize requests with key, using the API key
+// authorization mode.
+func WithAPIKey(key string) graphqlclient.Option {
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("X-Api-Key", key)
+	})
+}
+
+// WithToken makes the client authorize requests with the JWT token issued by
+// a Cognito user pool or OpenID Connect provider. Use a TokenTransport for
+// tokens that expire.
+func WithToken(token string) graphqlclient.Option {
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("Authorization", token)
+	})
+}
+
+// TokenFunc fetches a JWT token issued by a Cognito user pool or OpenID
+// Connect provider, e.g. by refreshing the session of a user.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// TokenTransport is an http.RoundTripper authorizing requests with tokens
+// fetched by a TokenFunc. A token is reused until RefreshBefore before the
+// expiry time in its exp claim, or, for tokens without one, until the API
+// responds with 401 Unauthorized.
+type TokenTransport struct {
+	// RefreshBefore is how long before a token expires a new token is
+	// fetched. Defaults to one minute.
+	RefreshBefore time.Duration
+
+	transport http.RoundTripper
+	fetch     TokenFunc
+
+	// now is replaced in tests.
+	now func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewTokenTransport returns a TokenTransport authorizing requests with tokens
+// fetched by fetch, sending them using transport, or http.DefaultTransport if
+// nil.
+func NewTokenTransport(transport http.RoundTripper, fetch TokenFunc) *TokenTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &TokenTransport{
+		transport: transport,
+		fetch:     fetch,
+		now:       time.Now,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", token)
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		t.mu.Lock()
+		if t.token == token {
+			t.token = ""
+		}
+		t.mu.Unlock()
+	}
+
+	return resp, nil
+}
+
+// Token returns the current token, fetching a new one if needed.
+func (t *TokenTransport) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	refreshBefore := t.RefreshBefore
+	if refreshBefore == 0 {
+		refreshBefore = time.Minute
+	}
+
+	if t.token != "" && (t.expiry.IsZero() || t.now().Add(refreshBefore).Before(t.expiry)) {
+		return t.token, nil
+	}
+
+	token, err := t.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error fetching token: %v", err)
+	}
+
+	t.token = token
+	t.expiry = tokenExpiry(token)
+
+	return token, nil
+}
+
+// tokenExpiry returns the time in the exp claim of the JWT token, or the zero
+// time if it has none.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
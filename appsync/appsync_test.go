@@ -0,0 +1,153 @@
+package appsync
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestWithAPIKey(t *testing.T) {
+	var gotKey string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotKey = r.Header.Get("X-Api-Key")
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := graphqlclient.NewClient(ts.URL, WithAPIKey("foo-key"))
+
+	if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := gotKey, "foo-key"; got != want {
+		t.Errorf("X-Api-Key = %q, want %q", got, want)
+	}
+}
+
+func jwt(claims string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+func TestTokenTransport(t *testing.T) {
+	var (
+		tokens   []string
+		unauth   bool
+		now      = time.Unix(1600000000, 0)
+		fetches  int
+		nextExp  = now.Add(time.Hour).Unix()
+		gotAuths []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotAuths = append(gotAuths, r.Header.Get("Authorization"))
+
+			if unauth {
+				unauth = false
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"message":"expired"}]}`))
+				return
+			}
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	tr := NewTokenTransport(nil, func(ctx context.Context) (string, error) {
+		fetches++
+		token := jwt(fmt.Sprintf(`{"exp":%d,"n":%d}`, nextExp, fetches))
+		tokens = append(tokens, token)
+		return token, nil
+	})
+	tr.now = func() time.Time { return now }
+
+	c := graphqlclient.NewClient(ts.URL, graphqlclient.WithHTTPClient(&http.Client{Transport: tr}))
+
+	query := func() error {
+		return c.Query(context.Background(), "foo-query", nil, nil)
+	}
+
+	for n := 0; n < 2; n++ {
+		if err := query(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := fetches, 1; got != want {
+		t.Errorf("fetches = %d, want %d", got, want)
+	}
+
+	// Within a minute of expiry, a new token is fetched.
+	now = now.Add(59*time.Minute + time.Second)
+	nextExp = now.Add(time.Hour).Unix()
+
+	if err := query(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := fetches, 2; got != want {
+		t.Errorf("fetches = %d, want %d", got, want)
+	}
+
+	// A rejected token is not reused.
+	unauth = true
+
+	if err := query(); err == nil {
+		t.Fatal("err = nil, want error")
+	}
+
+	if err := query(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := fetches, 3; got != want {
+		t.Errorf("fetches = %d, want %d", got, want)
+	}
+
+	want := strings.Join([]string{tokens[0], tokens[0], tokens[1], tokens[1], tokens[2]}, ",")
+
+	if got := strings.Join(gotAuths, ","); got != want {
+		t.Errorf("Authorization headers = %q, want %q", got, want)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		tr := NewTokenTransport(nil, func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("no session")
+		})
+
+		c := graphqlclient.NewClient(ts.URL, graphqlclient.WithHTTPClient(&http.Client{Transport: tr}))
+
+		err := c.Query(context.Background(), "foo-query", nil, nil)
+
+		if err == nil || !strings.Contains(err.Error(), "error fetching token: no session") {
+			t.Errorf("err = %v, want error fetching token", err)
+		}
+	})
+}
+
+func TestTokenExpiry(t *testing.T) {
+	for _, tc := range []struct {
+		token string
+		want  time.Time
+	}{
+		{jwt(`{"exp":1600000000}`), time.Unix(1600000000, 0)},
+		{jwt(`{"sub":"foo"}`), time.Time{}},
+		{"opaque-token", time.Time{}},
+	} {
+		if got := tokenExpiry(tc.token); !got.Equal(tc.want) {
+			t.Errorf("tokenExpiry(%q) = %v, want %v", tc.token, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package graphqlclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// WithAutomaticPersistedQueries makes the client send queries as automatic
+// persisted queries, as implemented by Apollo Server and most gateways, to cut
+// the size of requests with large queries. Queries are first sent as the
+// SHA-256 hash of their text, in the "persistedQuery" extension, without the
+// text itself. If the server doesn't know the hash, it responds with a
+// PersistedQueryNotFound error and the query is sent again with its text,
+// which registers it for later requests.
+//
+// Servers responding with a PersistedQueryNotSupported error are sent plain
+// queries from then on. Trusted documents sent by ID are never persisted.
+// Combined with WithGETQueries, queries sent as their hash are small enough
+// to be cached by CDNs.
+func WithAutomaticPersistedQueries() Option {
+	return func(c *Client) {
+		c.apq = &persistedQueries{}
+	}
+}
+
+// persistedQueries is the state of automatic persisted queries of a client.
+type persistedQueries struct {
+	// unsupported is set once the server has responded that it doesn't
+	// support persisted queries.
+	unsupported int32
+}
+
+// persistMode is how an operation is sent as an automatic persisted query.
+type persistMode int
+
+const (
+	// notPersisted sends the query text only.
+	notPersisted persistMode = iota
+
+	// persistHash sends the hash of the query without its text.
+	persistHash
+
+	// persistQuery sends the hash of the query with its text, registering
+	// it.
+	persistQuery
+)
+
+// queryPersisted sends op with variables as an automatic persisted query,
+// unless the server doesn't support them, decoding the data payload of the
+// response into data.
+func (c *Client) queryPersisted(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	if atomic.LoadInt32(&c.apq.unsupported) != 0 {
+		return c.send(ctx, op, variables, data, reqOpts)
+	}
+
+	op.persist = persistHash
+
+	err := c.send(ctx, op, variables, data, reqOpts)
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) {
+		if err == nil {
+			atomic.AddInt64(&c.stats.apqHits, 1)
+		}
+		return err
+	}
+
+	switch persistedQueryError(errResp.Errors) {
+	case "PersistedQueryNotFound":
+		op.persist = persistQuery
+	case "PersistedQueryNotSupported":
+		atomic.StoreInt32(&c.apq.unsupported, 1)
+		op.persist = notPersisted
+	default:
+		atomic.AddInt64(&c.stats.apqHits, 1)
+		return err
+	}
+
+	atomic.AddInt64(&c.stats.retries, 1)
+
+	return c.send(ctx, op, variables, data, reqOpts)
+}
+
+// persistedQueryError returns the persisted query error in errs, if any:
+// PersistedQueryNotFound or PersistedQueryNotSupported.
+func persistedQueryError(errs []Error) string {
+	for _, e := range errs {
+		code, _ := e.Extensions["code"].(string)
+
+		switch {
+		case e.Message == "PersistedQueryNotFound", code == "PERSISTED_QUERY_NOT_FOUND":
+			return "PersistedQueryNotFound"
+		case e.Message == "PersistedQueryNotSupported", code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return "PersistedQueryNotSupported"
+		}
+	}
+
+	return ""
+}
+
+// persistedQueryExtension returns the "persistedQuery" extension of query.
+func persistedQueryExtension(query string) map[string]interface{} {
+	sum := sha256.Sum256([]byte(query))
+
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": hex.EncodeToString(sum[:]),
+		},
+	}
+}
@@ -0,0 +1,167 @@
+package graphqlclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithAutomaticPersistedQueries(t *testing.T) {
+	newServer := func(supported bool, requests *[]string) *httptest.Server {
+		persisted := map[string]string{}
+
+		return httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query      string `json:"query"`
+					Extensions struct {
+						PersistedQuery *struct {
+							Version    int    `json:"version"`
+							SHA256Hash string `json:"sha256Hash"`
+						} `json:"persistedQuery"`
+					} `json:"extensions"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+
+				pq := body.Extensions.PersistedQuery
+
+				switch {
+				case pq == nil:
+					*requests = append(*requests, "query")
+				case body.Query == "":
+					*requests = append(*requests, "hash")
+				default:
+					*requests = append(*requests, "hash+query")
+				}
+
+				if pq != nil {
+					if !supported {
+						w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotSupported"}]}`))
+						return
+					}
+
+					if body.Query != "" {
+						sum := sha256.Sum256([]byte(body.Query))
+						if got, want := pq.SHA256Hash, hex.EncodeToString(sum[:]); got != want {
+							t.Errorf("sha256Hash = %q, want %q", got, want)
+						}
+						persisted[pq.SHA256Hash] = body.Query
+					}
+
+					if _, ok := persisted[pq.SHA256Hash]; !ok {
+						w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+						return
+					}
+				}
+
+				w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			},
+		))
+	}
+
+	t.Run("Persisted", func(t *testing.T) {
+		var requests []string
+
+		ts := newServer(true, &requests)
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithAutomaticPersistedQueries())
+
+		for n := 0; n < 2; n++ {
+			var data struct {
+				Foo string `json:"foo"`
+			}
+
+			if err := c.Query(context.Background(), "{ foo }", nil, &data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := data.Foo, "bar"; got != want {
+				t.Errorf("data.Foo = %q, want %q", got, want)
+			}
+		}
+
+		if got, want := strings.Join(requests, ","), "hash,hash+query,hash"; got != want {
+			t.Errorf("requests = %q, want %q", got, want)
+		}
+
+		stats := c.Stats()
+
+		if got, want := stats.Retries, int64(1); got != want {
+			t.Errorf("Retries = %d, want %d", got, want)
+		}
+
+		if got, want := stats.PersistedQueryHits, int64(1); got != want {
+			t.Errorf("PersistedQueryHits = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Prepared", func(t *testing.T) {
+		var requests []string
+
+		ts := newServer(true, &requests)
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithAutomaticPersistedQueries())
+
+		op, err := c.Prepare("query Foo {\n  foo\n}")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for n := 0; n < 2; n++ {
+			if err := op.Query(context.Background(), nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if got, want := strings.Join(requests, ","), "hash,hash+query,hash"; got != want {
+			t.Errorf("requests = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		var requests []string
+
+		ts := newServer(false, &requests)
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithAutomaticPersistedQueries())
+
+		for n := 0; n < 2; n++ {
+			if err := c.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if got, want := strings.Join(requests, ","), "hash,query,query"; got != want {
+			t.Errorf("requests = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("OmitVariables", func(t *testing.T) {
+		p := &payload{
+			op:            operation{query: "{ foo }", persist: persistHash},
+			omitVariables: true,
+		}
+
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		if err := p.writePrefix(buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sum := sha256.Sum256([]byte("{ foo }"))
+
+		want := `{"extensions":{"persistedQuery":{"sha256Hash":"` + hex.EncodeToString(sum[:]) + `","version":1}}`
+		if got := buf.String(); got != want {
+			t.Errorf("prefix = %s, want %s", got, want)
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithQueryBatching makes the client coalesce queries sent with Query within
+// window of each other into batches of up to maxSize operations, sent as
+// single requests as by QueryBatch, for servers supporting batching. Each
+// call waits for up to window for others to join its batch, and returns the
+// result of its own operation, as Query would.
+//
+// Only queries sent without request options passed to Query are batched, and
+// only with queries sent to the same endpoint with the same headers derived
+// from their context, see WithContextRequestOptions, so that requests made on
+// behalf of different users are never merged. Mutations, queries sent as
+// automatic persisted queries, and queries of clients sending them as GET
+// requests, see WithGETQueries, are sent as usual. A maxSize below 1 means no
+// limit.
+//
+// A batch is sent until the last of its calls has returned, with the latest
+// of their deadlines, and the response metadata of each call, see
+// QueryWithMetadata, is that of the batch, with the extensions of its own
+// result.
+func WithQueryBatching(window time.Duration, maxSize int) Option {
+	return func(c *Client) {
+		c.batcher = &queryBatcher{
+			window:  window,
+			maxSize: maxSize,
+			pending: make(map[string]*queryBatch),
+		}
+	}
+}
+
+// queryBatcher coalesces queries into batches.
+type queryBatcher struct {
+	window  time.Duration
+	maxSize int
+
+	mu sync.Mutex
+
+	// pending are the batches waiting to be sent, by their batch key.
+	pending map[string]*queryBatch
+}
+
+// queryBatch is a batch of queries.
+type queryBatch struct {
+	// ctx is the context of the first query of the batch, without its
+	// cancelation, canceled by cancel once no call is waiting for the
+	// batch, and url and tag are those of all its queries.
+	ctx    context.Context
+	cancel context.CancelFunc
+	url    string
+	tag    string
+	calls  []*batchCall
+	timer  *time.Timer
+
+	// waiting is the number of calls waiting for the batch, and deadline
+	// the latest of their deadlines, unless unbounded is set by a call
+	// without one.
+	waiting   int
+	deadline  time.Time
+	unbounded bool
+}
+
+// batchCall is a call to Query batched.
+type batchCall struct {
+	payload *payload
+	done    chan struct{}
+	result  BatchResult
+	meta    ResponseMetadata
+	err     error
+}
+
+// batchable reports whether op can be sent in a batch.
+func (c *Client) batchable(op operation, reqOpts []func(*http.Request)) bool {
+	return c.batcher != nil && len(reqOpts) == 0 && c.apq == nil && !c.getQueries && !c.isMutation(op.query)
+}
+
+// queryBatched sends op with variables in a batch, decoding the data payload
+// of its result into data.
+func (c *Client) queryBatched(ctx context.Context, op operation, variables map[string]interface{}, data interface{}) error {
+	if err := c.lifecycle.begin(); err != nil {
+		return err
+	}
+	defer c.lifecycle.end()
+
+	url, err := c.requestURL(ctx, op.query)
+	if err != nil {
+		return err
+	}
+
+	tag := c.operationTag(ctx)
+
+	call := &batchCall{
+		payload: c.newPayload(ctx, op, variables, tag),
+		done:    make(chan struct{}),
+	}
+
+	key := c.batchKey(ctx, url, tag)
+	batch := c.batcher.add(c, ctx, key, url, tag, call)
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		c.batcher.leave(key, batch)
+		return ctx.Err()
+	}
+
+	if meta := responseMetadata(ctx); meta != nil {
+		meta.StatusCode = call.meta.StatusCode
+		meta.Header = call.meta.Header
+		meta.Extensions = call.result.Extensions
+	}
+
+	if call.err != nil {
+		return call.err
+	}
+
+	if len(call.result.Errors) > 0 {
+		// Undecoded data payloads are kept even with errors.
+		if raw, ok := data.(*json.RawMessage); ok {
+			*raw = call.result.Data.data
+		}
+
+		return &ErrorResponse{
+			StatusCode: call.meta.StatusCode,
+			Errors:     call.result.Errors,
+			hasData:    call.result.Data.data != nil,
+		}
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	if err := call.result.Decode(data); err != nil {
+		return fmt.Errorf("error decoding data payload: %v", err)
+	}
+
+	return nil
+}
+
+// batchKey returns the key of the batches of requests made with ctx, tagged
+// with tag, to url: the URL and the headers set from ctx.
+func (c *Client) batchKey(ctx context.Context, url, tag string) string {
+	var key bytes.Buffer
+	key.WriteString(url + "\n")
+
+	if len(c.ctxReqOpts) == 0 && (tag == "" || c.tagHeader == "") {
+		return key.String()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return key.String()
+	}
+	req = req.WithContext(ctx)
+
+	if tag != "" && c.tagHeader != "" {
+		req.Header.Set(c.tagHeader, tag)
+	}
+
+	c.applyContextRequestOptions(req)
+
+	req.Header.Write(&key)
+
+	return key.String()
+}
+
+// add adds call, made with ctx, to the pending batch with key, starting one
+// if there is none, sends the batch once it is full, and returns it.
+func (b *queryBatcher) add(c *Client, ctx context.Context, key, url, tag string, call *batchCall) *queryBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch := b.pending[key]
+	if batch == nil {
+		batch = &queryBatch{
+			url: url,
+			tag: tag,
+		}
+		batch.ctx, batch.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		batch.timer = time.AfterFunc(b.window, func() {
+			b.flush(c, key, batch)
+		})
+
+		b.pending[key] = batch
+	}
+
+	batch.calls = append(batch.calls, call)
+	batch.waiting++
+
+	if deadline, ok := ctx.Deadline(); !ok {
+		batch.unbounded = true
+	} else if deadline.After(batch.deadline) {
+		batch.deadline = deadline
+	}
+
+	if b.maxSize > 0 && len(batch.calls) >= b.maxSize {
+		batch.timer.Stop()
+		delete(b.pending, key)
+
+		go b.send(c, batch)
+	}
+
+	return batch
+}
+
+// leave records that a call has stopped waiting for batch, the batch with
+// key, and cancels it if no call is waiting for it anymore: pending, it is
+// then never sent.
+func (b *queryBatcher) leave(key string, batch *queryBatch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if batch.waiting--; batch.waiting > 0 {
+		return
+	}
+
+	if b.pending[key] == batch {
+		batch.timer.Stop()
+		delete(b.pending, key)
+	}
+
+	batch.cancel()
+}
+
+// flush sends batch, the pending batch with key unless it has been sent.
+func (b *queryBatcher) flush(c *Client, key string, batch *queryBatch) {
+	b.mu.Lock()
+	if b.pending[key] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, key)
+	b.mu.Unlock()
+
+	b.send(c, batch)
+}
+
+// send sends batch and hands its results to its calls.
+func (b *queryBatcher) send(c *Client, batch *queryBatch) {
+	defer batch.cancel()
+
+	payloads := make([]*payload, len(batch.calls))
+	for i, call := range batch.calls {
+		payloads[i] = call.payload
+	}
+
+	ctx := batch.ctx
+
+	if !batch.unbounded {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, batch.deadline)
+		defer cancel()
+	}
+
+	meta := &ResponseMetadata{}
+
+	results, err := c.sendBatch(contextWithResponseMetadata(ctx, meta), batch.url, payloads, batch.tag, nil)
+
+	for i, call := range batch.calls {
+		call.meta = *meta
+
+		if err != nil {
+			call.err = err
+		} else {
+			call.result = results[i]
+		}
+
+		close(call.done)
+	}
+}
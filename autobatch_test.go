@@ -0,0 +1,251 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithQueryBatching(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []int
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var ops []struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+				// Not a batch.
+				w.Write([]byte(`{"data":{"n":-1}}`))
+				return
+			}
+
+			mu.Lock()
+			requests = append(requests, len(ops))
+			mu.Unlock()
+
+			results := make([]map[string]interface{}, len(ops))
+			for i, op := range ops {
+				if op.Variables["n"] == "fail" {
+					results[i] = map[string]interface{}{"errors": []map[string]interface{}{{"message": "failed"}}}
+					continue
+				}
+				results[i] = map[string]interface{}{"data": map[string]interface{}{"n": op.Variables["n"]}}
+			}
+
+			w.Header().Set("X-Batch", fmt.Sprint(len(ops)))
+			json.NewEncoder(w).Encode(results)
+		},
+	))
+	defer ts.Close()
+
+	query := func(c *Client, ctx context.Context, n interface{}, reqOpts ...func(*http.Request)) (interface{}, error) {
+		var data struct {
+			N interface{} `json:"n"`
+		}
+
+		err := c.Query(ctx, "query Q($n: Int) { n(n: $n) }", map[string]interface{}{"n": n}, &data, reqOpts...)
+
+		return data.N, err
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		requests = nil
+
+		c := NewClient(ts.URL, WithQueryBatching(50*time.Millisecond, 0))
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				got, err := query(c, context.Background(), i)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				if got, want := fmt.Sprint(got), fmt.Sprint(i); got != want {
+					t.Errorf("n = %s, want %s", got, want)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		if got, want := fmt.Sprint(requests), "[5]"; got != want {
+			t.Errorf("requests = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		requests = nil
+
+		c := NewClient(ts.URL, WithQueryBatching(time.Hour, 2))
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				if _, err := query(c, context.Background(), i); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		if got, want := fmt.Sprint(requests), "[2 2]"; got != want {
+			t.Errorf("requests = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		c := NewClient(ts.URL, WithQueryBatching(time.Millisecond, 0))
+
+		_, err := query(c, context.Background(), "fail")
+
+		if got, want := fmt.Sprint(err), "200 OK: failed"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("RequestOptions", func(t *testing.T) {
+		c := NewClient(ts.URL, WithQueryBatching(time.Hour, 0))
+
+		got, err := query(c, context.Background(), 1, func(*http.Request) {})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := fmt.Sprint(got), "-1"; got != want {
+			t.Errorf("n = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		c := NewClient(ts.URL, WithQueryBatching(time.Millisecond, 0))
+
+		meta, err := c.QueryWithMetadata(context.Background(), "query Q($n: Int) { n(n: $n) }", map[string]interface{}{"n": 1}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := meta.StatusCode, http.StatusOK; got != want {
+			t.Errorf("StatusCode = %d, want %d", got, want)
+		}
+
+		if got, want := meta.Header.Get("X-Batch"), "1"; got != want {
+			t.Errorf("X-Batch = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("GETQueries", func(t *testing.T) {
+		c := NewClient(ts.URL, WithQueryBatching(time.Hour, 0), WithGETQueries())
+
+		got, err := query(c, context.Background(), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := fmt.Sprint(got), "-1"; got != want {
+			t.Errorf("n = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("ContextHeaders", func(t *testing.T) {
+		requests = nil
+
+		type userKey struct{}
+
+		c := NewClient(ts.URL,
+			WithQueryBatching(50*time.Millisecond, 0),
+			WithContextRequestOptions(func(ctx context.Context) []func(*http.Request) {
+				user, _ := ctx.Value(userKey{}).(string)
+				return []func(*http.Request){func(req *http.Request) {
+					req.Header.Set("X-User", user)
+				}}
+			}),
+		)
+
+		var wg sync.WaitGroup
+
+		for _, user := range []string{"a", "b", "a"} {
+			wg.Add(1)
+			go func(user string) {
+				defer wg.Done()
+
+				if _, err := query(c, context.WithValue(context.Background(), userKey{}, user), 1); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}(user)
+		}
+
+		wg.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if got, want := len(requests), 2; got != want {
+			t.Errorf("len(requests) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		c := NewClient(ts.URL, WithQueryBatching(time.Hour, 0))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := query(c, ctx, 1)
+
+		if got, want := err, context.DeadlineExceeded; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("BatchCanceled", func(t *testing.T) {
+		canceled := make(chan struct{})
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				// The connection is only watched once the body is read.
+				ioutil.ReadAll(r.Body)
+
+				<-r.Context().Done()
+				close(canceled)
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithQueryBatching(time.Millisecond, 0))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := query(c, ctx, 1); err != context.DeadlineExceeded {
+			t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Errorf("batch not canceled")
+		}
+	})
+}
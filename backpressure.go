@@ -0,0 +1,203 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// OverflowPolicy is what a subscription does with events received while its
+// event buffer is full, see WithSubscriptionBuffer.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading events until the handler catches up.
+	// With the WebSocketTransport, this holds up the other subscriptions
+	// on the same connection.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest drops the oldest event in the buffer to make room.
+	OverflowDropOldest
+
+	// OverflowDropNewest drops the event received.
+	OverflowDropNewest
+)
+
+// WithSubscriptionBuffer makes Subscribe buffer up to size events, at least
+// one, for handlers slower than the events, and apply policy to events
+// received while the buffer is full. Events buffered when the server
+// completes a subscription are still handled before Subscribe returns.
+// Without it, subscriptions stop reading events while the handler runs.
+func WithSubscriptionBuffer(size int, policy OverflowPolicy) Option {
+	if size < 1 {
+		size = 1
+	}
+
+	return func(c *Client) {
+		c.eventBuffer = &eventBuffer{size: size, policy: policy}
+	}
+}
+
+// eventBuffer is the event buffer configuration of a client.
+type eventBuffer struct {
+	size   int
+	policy OverflowPolicy
+}
+
+// subscriptionEvent is an event of a subscription.
+type subscriptionEvent struct {
+	data json.RawMessage
+	errs []Error
+}
+
+// subscribe sends a subscription using send, buffering its events for
+// handler, which is called in another goroutine.
+func (b *eventBuffer) subscribe(ctx context.Context, send func(context.Context, SubscriptionHandler) error, handler SubscriptionHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	q := &eventQueue{
+		eventBuffer: b,
+		monitor:     subscriptionMonitor(ctx),
+		ready:       make(chan struct{}, 1),
+		space:       make(chan struct{}, 1),
+	}
+
+	var (
+		handlerErr error
+		done       = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+
+		for {
+			e, ok := q.pop(ctx)
+			if !ok {
+				return
+			}
+
+			if err := handler(e.data, e.errs); err != nil {
+				handlerErr = err
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err := send(ctx, func(data json.RawMessage, errs []Error) error {
+		return q.push(ctx, subscriptionEvent{data, errs})
+	})
+
+	if err != nil {
+		// Buffered events are dropped.
+		cancel()
+	}
+
+	q.close()
+	<-done
+
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	return err
+}
+
+// eventQueue is the buffer of events of a subscription.
+type eventQueue struct {
+	*eventBuffer
+
+	// monitor, if set, counts the events dropped.
+	monitor *SubscriptionMonitor
+
+	mu     sync.Mutex
+	events []subscriptionEvent
+	closed bool
+
+	// ready and space signal that events were pushed or the queue closed,
+	// and that events were popped.
+	ready chan struct{}
+	space chan struct{}
+}
+
+// push adds e to the queue, applying the overflow policy if it is full.
+func (q *eventQueue) push(ctx context.Context, e subscriptionEvent) error {
+	for {
+		q.mu.Lock()
+
+		switch {
+		case len(q.events) < q.size:
+			q.events = append(q.events, e)
+		case q.policy == OverflowDropOldest:
+			q.events = append(q.events[1:], e)
+			q.monitor.dropped()
+		case q.policy == OverflowDropNewest:
+			q.monitor.dropped()
+		default:
+			q.mu.Unlock()
+
+			select {
+			case <-q.space:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		q.mu.Unlock()
+		signal(q.ready)
+
+		return nil
+	}
+}
+
+// pop removes the oldest event from the queue, waiting for one unless the
+// queue is closed. It returns false once the queue is closed and empty, or
+// ctx is done.
+func (q *eventQueue) pop(ctx context.Context) (subscriptionEvent, bool) {
+	for {
+		q.mu.Lock()
+
+		if len(q.events) > 0 {
+			e := q.events[0]
+			q.events = q.events[1:]
+			q.mu.Unlock()
+
+			signal(q.space)
+
+			return e, true
+		}
+
+		closed := q.closed
+		q.mu.Unlock()
+
+		if closed {
+			return subscriptionEvent{}, false
+		}
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return subscriptionEvent{}, false
+		}
+	}
+}
+
+// close closes the queue after the last event has been pushed.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	signal(q.ready)
+}
+
+// signal sends on c, a channel with a buffer of one, unless a signal is
+// already pending.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
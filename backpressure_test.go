@@ -0,0 +1,134 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// subscriptionTransportFunc is a SubscriptionTransport calling itself.
+type subscriptionTransportFunc func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error
+
+func (f subscriptionTransportFunc) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	return f(ctx, sub, handler)
+}
+
+func TestWithSubscriptionBuffer(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy OverflowPolicy
+		want   string
+	}{
+		{"Block", OverflowBlock, "1,2,3,4,5"},
+		{"DropOldest", OverflowDropOldest, "1,4,5"},
+		{"DropNewest", OverflowDropNewest, "1,2,3"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				started = make(chan struct{})
+				gate    = make(chan struct{})
+			)
+
+			// Blocking transports wait for the handler, which must not
+			// wait for them in turn.
+			if tc.policy == OverflowBlock {
+				close(gate)
+			}
+
+			// Events 2 to 5 are received while the handler is busy
+			// with event 1.
+			transport := subscriptionTransportFunc(func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+				for i := 1; i <= 5; i++ {
+					if err := handler(json.RawMessage(fmt.Sprint(i)), nil); err != nil {
+						return err
+					}
+
+					if i == 1 {
+						<-started
+					}
+				}
+
+				if tc.policy != OverflowBlock {
+					close(gate)
+				}
+
+				return nil
+			})
+
+			c := NewClient("http://example.com/graphql",
+				WithSubscriptionTransport(transport),
+				WithSubscriptionBuffer(2, tc.policy),
+			)
+
+			var events []string
+
+			err := c.Subscribe(context.Background(), "subscription { foo }", nil,
+				func(data json.RawMessage, errs []Error) error {
+					if len(events) == 0 {
+						close(started)
+						<-gate
+					}
+
+					events = append(events, string(data))
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(events, ","), tc.want; got != want {
+				t.Errorf("events = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("HandlerError", func(t *testing.T) {
+		errStop := errors.New("stop")
+
+		transport := subscriptionTransportFunc(func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+			for {
+				if err := handler(json.RawMessage("1"), nil); err != nil {
+					return err
+				}
+			}
+		})
+
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(transport),
+			WithSubscriptionBuffer(2, OverflowBlock),
+		)
+
+		err := c.Subscribe(context.Background(), "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error { return errStop },
+		)
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("TransportError", func(t *testing.T) {
+		errLost := errors.New("connection lost")
+
+		transport := subscriptionTransportFunc(func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+			return errLost
+		})
+
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(transport),
+			WithSubscriptionBuffer(2, OverflowBlock),
+		)
+
+		err := c.Subscribe(context.Background(), "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := err, errLost; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Operation is an operation sent in a batch by QueryBatch.
+type Operation struct {
+	Query     string
+	Variables map[string]interface{}
+
+	// OperationName, if not empty, selects the operation to execute in
+	// documents containing more than one.
+	OperationName string
+
+	// Extensions are sent in the "extensions" key of the operation's
+	// request object, in addition to those of the client and context, see
+	// ContextWithExtensions, taking precedence over them.
+	Extensions map[string]interface{}
+}
+
+// BatchResult is the result of an operation sent in a batch.
+type BatchResult struct {
+	// Data is the data payload of the result, nil if there is none.
+	Data Result
+
+	// Errors are the errors of the operation.
+	Errors []Error
+
+	// Extensions is the undecoded "extensions" field of the result, nil if
+	// there is none.
+	Extensions json.RawMessage
+}
+
+// Decode decodes the data payload of the result into v.
+func (r *BatchResult) Decode(v interface{}) error {
+	if r.Data.data == nil {
+		return errNoData
+	}
+	return json.Unmarshal(r.Data.data, v)
+}
+
+// QueryBatch sends ops to the server in a single request, as an array of
+// request objects, for servers and gateways supporting batching, e.g. Apollo
+// Server and Hasura, and returns the results of the operations in the same
+// order. The errors of each operation are returned in its result; an error is
+// only returned if the request as a whole failed, e.g. as an *ErrorResponse
+// if the server responded with a non-2xx status code or a single response
+// object with errors instead of an array.
+//
+// The request objects are encoded as by Query, but batches are always sent as
+// POST requests, and their operations are never sent as automatic persisted
+// queries. A batch is sent to the write endpoint if any of its operations is,
+// see WithWriteEndpoint. The response is read as by Query, with the decode
+// limits and transport stats of the client, except that response codecs are
+// not used: batches only accept JSON responses.
+func (c *Client) QueryBatch(ctx context.Context, ops []Operation, reqOpts ...func(*http.Request)) ([]BatchResult, error) {
+	if err := c.lifecycle.begin(); err != nil {
+		return nil, err
+	}
+	defer c.lifecycle.end()
+
+	queries := make([]string, len(ops))
+	for i, op := range ops {
+		queries[i] = op.Query
+	}
+
+	url, err := c.requestURL(ctx, queries...)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := c.operationTag(ctx)
+
+	payloads := make([]*payload, len(ops))
+
+	for i, o := range ops {
+		op := operation{query: o.Query}
+		if c.documents != nil {
+			op = c.documents.operation(o.Query)
+		}
+
+		payloads[i] = c.newPayload(ctx, op, o.Variables, tag)
+
+		if o.OperationName != "" {
+			payloads[i].operationName = o.OperationName
+		}
+
+		if len(o.Extensions) > 0 {
+			payloads[i].extensions = mergeExtensions(payloads[i].extensions, o.Extensions)
+		}
+	}
+
+	return c.sendBatch(ctx, url, payloads, tag, reqOpts)
+}
+
+// sendBatch sends a request for payloads, tagged with tag, to url, with the
+// client's request options and reqOpts applied, and returns the results of
+// the operations. The response is handled as by Query, see exchange.
+func (c *Client) sendBatch(ctx context.Context, url string, payloads []*payload, tag string, reqOpts []func(*http.Request)) (results []BatchResult, err error) {
+	req, release, err := c.prepareBatchRequest(ctx, url, payloads, tag, reqOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	err = c.exchange(ctx, req, nil, false, func(resp *response) error {
+		if resp.codec != nil {
+			// Codecs decode single response objects, and are not
+			// advertised for batches.
+			head, _ := ioutil.ReadAll(io.LimitReader(resp.body, maxErrorBodySize))
+
+			return &UnexpectedContentTypeError{
+				StatusCode:  resp.StatusCode,
+				ContentType: resp.Header.Get("Content-Type"),
+				Body:        head,
+			}
+		}
+
+		results, err = c.decodeBatchResponse(resp, len(payloads))
+		return err
+	})
+
+	return results, err
+}
+
+// prepareBatchRequest returns the request sending payloads, tagged with tag,
+// to url, with the client's request options and reqOpts applied. release
+// must be called once the response has been handled.
+func (c *Client) prepareBatchRequest(ctx context.Context, url string, payloads []*payload, tag string, reqOpts []func(*http.Request)) (*http.Request, func(), error) {
+	buf := getBuffer()
+	buf.WriteByte('[')
+
+	for i, p := range payloads {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := p.write(buf); err != nil {
+			putBuffer(buf)
+			return nil, nil, fmt.Errorf("operation %d: %v", i, err)
+		}
+	}
+
+	buf.WriteByte(']')
+
+	req, release, err := newBufferedRequest(ctx, url, buf, c.gzipThresholdOrNone())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Set ahead of the request options, without the media types of
+	// codecs, see sendBatch.
+	if c.jsonAccept != "" {
+		req.Header.Set("Accept", c.jsonAccept)
+	}
+
+	c.applyRequestOptions(req, tag, reqOpts)
+
+	return req, release, nil
+}
+
+// decodeBatchResponse decodes resp, the response to a batch of n operations.
+func (c *Client) decodeBatchResponse(resp *response, n int) ([]BatchResult, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var (
+		raw        json.RawMessage
+		statusCode = resp.StatusCode
+	)
+
+	err := json.NewDecoder(io.TeeReader(resp.body, &headWriter{buf: buf, max: maxErrorBodySize})).Decode(&raw)
+
+	var limitErr *DecodeLimitError
+	if errors.As(err, &limitErr) {
+		return nil, limitErr
+	}
+
+	if err == nil && len(raw) > 0 && raw[0] == '{' {
+		// The request as a whole failed.
+		var res BatchResult
+		if err := c.decodeBatchResult(raw, &res); err != nil {
+			return nil, fmt.Errorf("error decoding response: %v", err)
+		}
+
+		if resp.meta != nil {
+			resp.meta.Extensions = res.Extensions
+		}
+
+		return nil, &ErrorResponse{
+			StatusCode: statusCode,
+			Errors:     res.Errors,
+			Body:       errorBody(buf),
+			hasData:    res.Data.data != nil,
+		}
+	}
+
+	if statusCode/100 != 2 {
+		return nil, &ErrorResponse{
+			StatusCode: statusCode,
+			Body:       errorBody(buf),
+		}
+	}
+
+	if err == io.EOF && resp.empty() {
+		return nil, &EmptyResponseError{StatusCode: statusCode}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	if len(items) != n {
+		return nil, fmt.Errorf("error decoding response: %d results for %d operations", len(items), n)
+	}
+
+	results := make([]BatchResult, n)
+
+	for i, item := range items {
+		if err := c.decodeBatchResult(item, &results[i]); err != nil {
+			return nil, fmt.Errorf("error decoding response: result %d: %v", i, err)
+		}
+	}
+
+	return results, nil
+}
+
+// decodeBatchResult decodes the response object raw into res, keeping its
+// data payload even if there are errors.
+func (c *Client) decodeBatchResult(raw json.RawMessage, res *BatchResult) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+
+	for key, v := range fields {
+		switch {
+		case strings.EqualFold(key, c.envelope.data):
+			res.Data.data = v
+		case strings.EqualFold(key, c.envelope.errors) && c.lenient:
+			errs, err := decodeLenientErrors(v)
+			if err != nil {
+				return err
+			}
+			res.Errors = errs
+		case strings.EqualFold(key, c.envelope.errors):
+			if err := json.Unmarshal(v, &res.Errors); err != nil {
+				return err
+			}
+		case key == "extensions":
+			res.Extensions = v
+		}
+	}
+
+	return nil
+}
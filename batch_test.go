@@ -0,0 +1,124 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQueryBatch(t *testing.T) {
+	var gotBody, gotAccept string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+			gotAccept = r.Header.Get("Accept")
+
+			switch {
+			case strings.Contains(gotBody, "invalid"):
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":[{"message":"batching not supported"}]}`))
+			case strings.Contains(gotBody, "short"):
+				w.Write([]byte(`[{"data":{}}]`))
+			default:
+				w.Write([]byte(`[{"data":{"user":{"name":"Alice"}}},{"data":{"viewer":null},"errors":[{"message":"forbidden","path":["viewer"]}]}]`))
+			}
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+
+	t.Run("Batch", func(t *testing.T) {
+		results, err := c.QueryBatch(context.Background(), []Operation{
+			{Query: "query GetUser($id: ID!) { user(id: $id) { name } }", Variables: map[string]interface{}{"id": "1"}},
+			{Query: "{ viewer { id } }", Extensions: map[string]interface{}{"trace": true}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := `[{"query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}},{"extensions":{"trace":true},"query":"{ viewer { id } }","variables":null}]`
+		if got := gotBody; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+
+		if got, want := len(results), 2; got != want {
+			t.Fatalf("len(results) = %d, want %d", got, want)
+		}
+
+		var name string
+		if err := results[0].Data.Get("user.name", &name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := name, "Alice"; got != want {
+			t.Errorf("name = %q, want %q", got, want)
+		}
+
+		if got, want := len(results[0].Errors), 0; got != want {
+			t.Errorf("len(results[0].Errors) = %d, want %d", got, want)
+		}
+
+		var data struct {
+			Viewer *struct{} `json:"viewer"`
+		}
+		if err := results[1].Decode(&data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if data.Viewer != nil {
+			t.Errorf("data.Viewer = %v, want nil", data.Viewer)
+		}
+
+		if got, want := fmt.Sprint(results[1].Errors[0].Message, results[1].Errors[0].Path), "forbidden[viewer]"; got != want {
+			t.Errorf("results[1].Errors[0] = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ErrorResponse", func(t *testing.T) {
+		_, err := c.QueryBatch(context.Background(), []Operation{{Query: "invalid"}})
+
+		if got, want := fmt.Sprint(err), "400 Bad Request: batching not supported"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("DecodeLimits", func(t *testing.T) {
+		_, err := c.With(WithDecodeLimits(DecodeLimits{MaxDepth: 3})).QueryBatch(context.Background(), []Operation{{Query: "{ user { name } }"}})
+
+		var limitErr *DecodeLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("err = %v, want *DecodeLimitError", err)
+		}
+	})
+
+	t.Run("Codecs", func(t *testing.T) {
+		_, err := c.With(WithResponseCodecs(gobCodec{})).QueryBatch(context.Background(), []Operation{{Query: "{ user { name } }"}, {Query: "{ viewer { id } }"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotAccept, DefaultAccept; got != want {
+			t.Errorf("Accept = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("MissingResults", func(t *testing.T) {
+		_, err := c.QueryBatch(context.Background(), []Operation{{Query: "short"}, {Query: "short"}})
+
+		if got, want := fmt.Sprint(err), "error decoding response: 1 results for 2 operations"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
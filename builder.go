@@ -0,0 +1,91 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestBuilder builds a query to send with Do, as an alternative to passing
+// everything to Query:
+//
+//	err := c.NewRequest(query).
+//		Var("id", id).
+//		OperationName("GetUser").
+//		Header("X-Foo", "bar").
+//		Do(ctx, &data)
+//
+// A RequestBuilder is not safe for concurrent use, but it can be sent more
+// than once.
+type RequestBuilder struct {
+	c             *Client
+	query         string
+	variables     map[string]interface{}
+	operationName string
+	extensions    map[string]interface{}
+	reqOpts       []func(*http.Request)
+}
+
+// NewRequest returns a RequestBuilder for query.
+func (c *Client) NewRequest(query string) *RequestBuilder {
+	return &RequestBuilder{c: c, query: query}
+}
+
+// Var sets the variable name to value.
+func (b *RequestBuilder) Var(name string, value interface{}) *RequestBuilder {
+	if b.variables == nil {
+		b.variables = make(map[string]interface{})
+	}
+	b.variables[name] = value
+	return b
+}
+
+// Vars sets the variables in variables.
+func (b *RequestBuilder) Vars(variables map[string]interface{}) *RequestBuilder {
+	for name, value := range variables {
+		b.Var(name, value)
+	}
+	return b
+}
+
+// OperationName sets the operation name sent, see ContextWithOperationName.
+func (b *RequestBuilder) OperationName(name string) *RequestBuilder {
+	b.operationName = name
+	return b
+}
+
+// Extension sets the extension key to value, see ContextWithExtensions.
+func (b *RequestBuilder) Extension(key string, value interface{}) *RequestBuilder {
+	if b.extensions == nil {
+		b.extensions = make(map[string]interface{})
+	}
+	b.extensions[key] = value
+	return b
+}
+
+// Header sets the header key to value.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	return b.Option(func(req *http.Request) {
+		req.Header.Set(key, value)
+	})
+}
+
+// Option adds reqOpt to the options applied to the request, as passed to
+// Query.
+func (b *RequestBuilder) Option(reqOpt func(*http.Request)) *RequestBuilder {
+	b.reqOpts = append(b.reqOpts, reqOpt)
+	return b
+}
+
+// Do sends the query as Query does, decoding the data payload of the response
+// into data.
+func (b *RequestBuilder) Do(ctx context.Context, data interface{}) error {
+	if b.operationName != "" {
+		ctx = ContextWithOperationName(ctx, b.operationName)
+	}
+
+	if len(b.extensions) > 0 {
+		ctx = ContextWithExtensions(ctx, b.extensions)
+	}
+
+	return b.c.Query(ctx, b.query, b.variables, data, b.reqOpts...)
+}
@@ -0,0 +1,63 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	var (
+		gotHeader string
+		gotBody   string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotHeader = r.Header.Get("X-Foo")
+			gotBody = string(b)
+
+			w.Write([]byte(`{"data":{"user":{"name":"Alice"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+
+	var data struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	err := c.NewRequest("query GetUser($id: ID!) { user(id: $id) { name } }").
+		Var("id", "1").
+		Vars(map[string]interface{}{"locale": "sv"}).
+		OperationName("GetUser").
+		Extension("trace", true).
+		Header("X-Foo", "bar").
+		Do(context.Background(), &data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data.User.Name, "Alice"; got != want {
+		t.Errorf("data.User.Name = %q, want %q", got, want)
+	}
+
+	if got, want := gotHeader, "bar"; got != want {
+		t.Errorf("X-Foo = %q, want %q", got, want)
+	}
+
+	want := `{"extensions":{"trace":true},"operationName":"GetUser","query":"query GetUser($id: ID!) { user(id: $id) { name } }","variables":{"id":"1","locale":"sv"}}`
+	if got := gotBody; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
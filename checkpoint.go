@@ -0,0 +1,88 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Checkpoint is the position of a Paginator after a page has been processed.
+type Checkpoint struct {
+	// Page is the number of pages processed.
+	Page int `json:"page"`
+
+	// Items is the number of items on the pages processed.
+	Items int `json:"items"`
+
+	// Variables are the variables to fetch the next page with, including
+	// its cursor or offset.
+	Variables map[string]interface{} `json:"variables"`
+
+	// Done is true if the last page has been processed.
+	Done bool `json:"done"`
+}
+
+// CheckpointStore persists the checkpoints of a Paginator.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the last checkpoint saved, or nil if there is
+	// none.
+	LoadCheckpoint(ctx context.Context) (*Checkpoint, error)
+
+	// SaveCheckpoint saves cp, replacing the last checkpoint saved.
+	SaveCheckpoint(ctx context.Context, cp *Checkpoint) error
+}
+
+// FileCheckpointStore is a CheckpointStore keeping the checkpoint as JSON in
+// a file. The file is replaced atomically on every save, so a crash while
+// saving leaves the previous checkpoint in place.
+type FileCheckpointStore struct {
+	Path string
+}
+
+// LoadCheckpoint implements CheckpointStore. It returns nil if the file does
+// not exist.
+func (s FileCheckpointStore) LoadCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", s.Path, err)
+	}
+
+	return &cp, nil
+}
+
+// SaveCheckpoint implements CheckpointStore.
+func (s FileCheckpointStore) SaveCheckpoint(ctx context.Context, cp *Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), s.Path)
+}
@@ -0,0 +1,181 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPaginator_Checkpoints(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		pagination   func() Pagination
+		prefetch     int
+		wantRequests string
+	}{
+		{"Cursor", func() Pagination { return Cursor("user.friends") }, 0, "1,2"},
+		{"Offset", func() Pagination { return Offset("user.friends.nodes", 1, nil) }, 0, "1,2,3"},
+		{"Prefetch", func() Pagination { return Cursor("user.friends") }, 2, "1,2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					var body struct {
+						Variables struct {
+							After  string `json:"after"`
+							Offset int    `json:"offset"`
+						} `json:"variables"`
+					}
+
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+
+					n := body.Variables.Offset
+					if body.Variables.After != "" {
+						fmt.Sscan(body.Variables.After, &n)
+					}
+
+					mu.Lock()
+					requests = append(requests, fmt.Sprint(n))
+					mu.Unlock()
+
+					nodes := fmt.Sprintf(`"node-%d"`, n)
+					if n > 2 {
+						nodes = ""
+					}
+
+					fmt.Fprintf(w, `{"data":{"user":{"friends":{"nodes":[%s],"pageInfo":{"hasNextPage":%t,"endCursor":"%d"}}}}}`,
+						nodes, n < 2, n+1)
+				},
+			))
+			defer ts.Close()
+
+			store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+			errCrash := errors.New("crash")
+
+			run := func(crashAt string) ([]string, error) {
+				p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, tc.pagination())
+				p.Prefetch = tc.prefetch
+				p.Checkpoints = store
+
+				var nodes []string
+
+				err := p.ForEachNode(context.Background(), func(node json.RawMessage) error {
+					var s string
+					json.Unmarshal(node, &s)
+
+					if s == crashAt {
+						return errCrash
+					}
+
+					nodes = append(nodes, fmt.Sprintf("%d:%s", p.PageNumber(), s))
+					return nil
+				})
+
+				return nodes, err
+			}
+
+			nodes, err := run("node-1")
+			if err != errCrash {
+				t.Fatalf("err = %v, want %v", err, errCrash)
+			}
+
+			if got, want := strings.Join(nodes, ","), "1:node-0"; got != want {
+				t.Errorf("nodes = %q, want %q", got, want)
+			}
+
+			// Pages prefetched before the crash may still be fetched.
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			requests = nil
+			mu.Unlock()
+
+			nodes, err = run("")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(nodes, ","), "2:node-1,3:node-2"; got != want {
+				t.Errorf("resumed nodes = %q, want %q", got, want)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got, want := strings.Join(requests, ","), tc.wantRequests; got != want {
+				t.Errorf("resumed requests = %q, want %q", got, want)
+			}
+
+			requests = nil
+			mu.Unlock()
+
+			nodes, err = run("")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mu.Lock()
+
+			if got, want := len(nodes)+len(requests), 0; got != want {
+				t.Errorf("nodes and requests after done = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	cp, err := store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cp != nil {
+		t.Errorf("cp = %+v, want nil", cp)
+	}
+
+	for _, after := range []string{"a", "b"} {
+		err := store.SaveCheckpoint(context.Background(), &Checkpoint{
+			Page:      1,
+			Variables: map[string]interface{}{"after": after},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	cp, err = store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := fmt.Sprint(cp.Variables), "map[after:b]"; got != want {
+		t.Errorf("cp.Variables = %q, want %q", got, want)
+	}
+
+	files, err := ioutil.ReadDir(filepath.Dir(store.Path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := len(files), 1; got != want {
+		t.Errorf("len(files) = %d, want %d", got, want)
+	}
+}
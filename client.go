@@ -3,109 +3,1232 @@
 package graphqlclient
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Querier is the interface implemented by Client. Code that depends on a
+// Querier rather than a *Client can be tested without an HTTP server, e.g.
+// using graphqltest.FakeClient.
+type Querier interface {
+	Query(ctx context.Context, query string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error
+}
+
+var _ Querier = (*Client)(nil)
+
 // Client is a generic GraphQL client
 type Client struct {
-	url        string
-	httpClient *http.Client
-	reqOpts    []func(*http.Request)
+	url            string
+	httpClient     *http.Client
+	reqOpts        []func(*http.Request)
+	streamRequests bool
+
+	// userAgent, if not empty, is sent in the User-Agent header.
+	userAgent string
+
+	// contentType is sent in the Content-Type header, and accept, if not
+	// empty, in the Accept header.
+	contentType string
+	accept      string
+
+	// jsonAccept is accept without the media types of response codecs,
+	// sent with batches.
+	jsonAccept string
+
+	// extensions are sent in the "extensions" key of all requests.
+	extensions map[string]interface{}
+
+	// drainPolicy is how much of the rest of response bodies is read
+	// before closing them.
+	drainPolicy DrainPolicy
+
+	// warningHandler, if set, is called with the warnings of responses.
+	warningHandler func(context.Context, []Warning)
+
+	// stats counts the requests sent.
+	stats *clientStats
+
+	// tag, if not empty, tags all requests, in a comment before the query
+	// or in tagHeader if set.
+	tag       string
+	tagHeader string
+
+	// omitEmptyVariables and omitNullVariables leave out the "variables"
+	// key if there are none and the variables that are null.
+	omitEmptyVariables bool
+	omitNullVariables  bool
+
+	// ctxReqOpts derive options from the context of each request.
+	ctxReqOpts []RequestOptionsFunc
+
+	// gzipRequests enables compressing request bodies of at least
+	// gzipThreshold bytes.
+	gzipRequests  bool
+	gzipThreshold int
+
+	// spillResponses enables writing response bodies larger than
+	// spillThreshold bytes to temporary files in spillDir.
+	spillResponses bool
+	spillDir       string
+	spillThreshold int64
+
+	// decodeLimits, if set, limits the responses decoded.
+	decodeLimits *DecodeLimits
+
+	// getQueries enables sending queries as GET requests, and
+	// graphqlBodies sending queries as application/graphql bodies.
+	getQueries    bool
+	graphqlBodies bool
+
+	// apq, if set, enables automatic persisted queries.
+	apq *persistedQueries
+
+	// batcher, if set, coalesces queries into batches.
+	batcher *queryBatcher
+
+	// transport, if set, sends queries instead of httpClient, and
+	// transportWrappers wrap it, or the HTTP transport of the client, into
+	// wrappedTransport, which sends queries if set.
+	transport         Transport
+	transportWrappers []func(Transport) Transport
+	wrappedTransport  Transport
+
+	// timeoutHeader, if not empty, is the header the time remaining until
+	// the deadline of requests is sent in.
+	timeoutHeader string
+
+	// timeout, if positive, bounds the time queries take.
+	timeout time.Duration
+
+	// noPOSTRedirects disables following redirects of POST requests.
+	noPOSTRedirects bool
+
+	// codecs decode responses in formats other than JSON.
+	codecs []ResponseCodec
+
+	// decompressors decompress responses by Content-Encoding, advertised
+	// in the order of acceptEncoding.
+	decompressors  map[string]Decompressor
+	acceptEncoding []string
+
+	// lenient enables tolerating malformed "errors" fields.
+	lenient bool
+
+	// envelope holds the names of the fields of response objects.
+	envelope envelope
+
+	// transportOpts tune the transport built by NewClient.
+	transportOpts []func(*http.Transport)
+
+	// subscriptionTransport sends subscriptions, falling back to
+	// subscriptionFallback, if set, when it fails to.
+	subscriptionTransport SubscriptionTransport
+	subscriptionFallback  SubscriptionTransport
+
+	// connParams, if set, returns the connection_init payload of
+	// subscription connections.
+	connParams ConnectionParamsFunc
+
+	// reconnect, if set, reconnects lost subscriptions.
+	reconnect *ReconnectPolicy
+
+	// eventBuffer, if set, buffers subscription events for handlers.
+	eventBuffer *eventBuffer
+
+	// documents, if set, holds trusted documents sent by ID.
+	documents *TrustedDocuments
+
+	// resolver, if set, discovers the endpoints requests are sent to
+	// instead of url.
+	resolver *endpointResolver
+
+	// writeURL, if set, is the URL mutations are sent to, as routed by
+	// routes.
+	writeURL string
+	routes   *operationRoutes
+
+	// transportStats, if set, tracks the connections of the transport.
+	transportStats *transportStats
+
+	// progress, if set, is called with the progress of requests every
+	// progressInterval.
+	progress         func(RequestProgress)
+	progressInterval time.Duration
+
+	// lifecycle tracks the operations in flight until the client is
+	// closed.
+	lifecycle *clientLifecycle
+
+	// prepared records the queries prepared with the client and the
+	// clients derived from it.
+	prepared *preparedQueries
 }
 
 // New returns a new client. The optional reqOpts will be applied to all
-// requests.
+// requests. It is equivalent to NewClient with WithHTTPClient and
+// WithRequestOptions, which, with the other options, is preferred.
 func New(url string, httpClient *http.Client, reqOpts ...func(*http.Request)) *Client {
-	return &Client{
-		httpClient: httpClient,
-		url:        url,
-		reqOpts:    reqOpts,
-	}
+	return NewClient(url, WithHTTPClient(httpClient), WithRequestOptions(reqOpts...))
 }
 
 // Query sends the given query and variables to the server. If the "errors"
 // array in the response object contains any items, these will be unmarshaled
 // and returned as an error. If there are no errors, the value of the "data"
 // field of the response object with be unmarshaled into the "data" argument.
+// The data payload is decoded straight into "data" as the response is read;
+// if the server sends the "errors" array after it, "data" may have been
+// populated when the errors are returned.
 // reqOpts can be used to inspect or modify the request before it gets sent.
 // These reqOpts are run after any reqOpts passed to func New.
+//
+// The request body is encoded canonically, so that the same query and
+// variables always produce the same bytes: the keys of all objects, including
+// those encoded from structs, are sorted, numbers are formatted the way
+// encoding/json formats them and there is no insignificant whitespace. Clients
+// created with WithStreamingRequests instead stream the body as it is encoded.
+// Clients created with WithGzipRequests compress the body.
 func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
-	body, err := json.Marshal(
-		map[string]interface{}{
-			"query":     query,
-			"variables": variables,
-		},
+	op := operation{query: query}
+	if c.documents != nil {
+		op = c.documents.operation(query)
+	}
+
+	return c.query(ctx, op, variables, data, reqOpts)
+}
+
+// Query sends query and variables to the server with c as Client.Query does,
+// and returns the data payload of the response decoded into a T, so that
+// callers don't declare and pass a destination themselves. reqOpts are run
+// as with Client.Query; to query with a different configuration, pass a
+// client derived once with Client.With.
+//
+//	user, err := graphqlclient.Query[struct {
+//		User User `json:"user"`
+//	}](ctx, c, query, map[string]interface{}{"id": id})
+func Query[T any](ctx context.Context, c *Client, query string, variables map[string]interface{}, reqOpts ...func(*http.Request)) (T, error) {
+	var data T
+	err := c.Query(ctx, query, variables, &data, reqOpts...)
+
+	return data, err
+}
+
+// operation is the query sent in a request. prefix, if set, is the request
+// object encoded up to the value of "variables", as encoded ahead of time by
+// Prepare. id, if not empty, is the ID of the trusted document prefix refers
+// to instead of holding the query. persist is how the query is sent as an
+// automatic persisted query.
+type operation struct {
+	query   string
+	prefix  []byte
+	id      string
+	persist persistMode
+}
+
+// query sends op with variables, decoding the data payload of the response
+// into data.
+func (c *Client) query(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	return withOperationName(c.dispatch(ctx, op, variables, data, reqOpts), operationName(ctx))
+}
+
+// dispatch sends op with variables as configured, decoding the data payload
+// of the response into data.
+func (c *Client) dispatch(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	if c.wrappedTransport != nil {
+		return c.queryTransport(ctx, op, variables, data, reqOpts)
+	}
+
+	return c.dispatchHTTP(ctx, op, variables, data, reqOpts)
+}
+
+// dispatchHTTP sends op with variables over HTTP as configured, decoding the
+// data payload of the response into data.
+func (c *Client) dispatchHTTP(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	switch {
+	case c.batchable(op, reqOpts):
+		return unknownDocument(op.id, c.queryBatched(ctx, op, variables, data))
+	case op.id != "":
+		return unknownDocument(op.id, c.send(ctx, op, variables, data, reqOpts))
+	case c.apq != nil:
+		return c.queryPersisted(ctx, op, variables, data, reqOpts)
+	}
+
+	return c.send(ctx, op, variables, data, reqOpts)
+}
+
+// send sends a request for op with variables, decoding the data payload of
+// the response into data.
+func (c *Client) send(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	if err := c.lifecycle.begin(); err != nil {
+		return err
+	}
+	defer c.lifecycle.end()
+
+	req, release, encErr, err := c.prepareRequest(ctx, op, variables, reqOpts, c.streamRequests)
+	if err != nil {
+		return err
+	}
+
+	if release != nil {
+		defer release()
+	}
+
+	return c.exchange(ctx, req, encErr, false, func(resp *response) error {
+		if resp.codec != nil {
+			return c.decodeWithCodec(resp.codec, resp.StatusCode, resp.body, data)
+		}
+
+		respBodyBuf := getBuffer()
+		defer putBuffer(respBodyBuf)
+
+		// Only the head of the body is kept, to be reported in an
+		// ErrorResponse.
+		respBody := io.TeeReader(resp.body, &headWriter{buf: respBodyBuf, max: maxErrorBodySize})
+
+		var extensions *json.RawMessage
+		if resp.meta != nil {
+			extensions = &resp.meta.Extensions
+		}
+
+		errs, dataErr, err := decodeResponse(respBody, data, resp.StatusCode/100 == 2, c.envelope, c.lenient, extensions)
+
+		var limitErr *DecodeLimitError
+		if errors.As(err, &limitErr) || errors.As(dataErr, &limitErr) {
+			return limitErr
+		}
+
+		if err != nil {
+			if resp.StatusCode/100 != 2 {
+				return &ErrorResponse{
+					StatusCode: resp.StatusCode,
+					Body:       errorBody(respBodyBuf),
+				}
+			}
+			if err == io.EOF && resp.empty() {
+				return &EmptyResponseError{StatusCode: resp.StatusCode}
+			}
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+
+		if resp.StatusCode/100 != 2 || len(errs) > 0 {
+			return &ErrorResponse{
+				StatusCode: resp.StatusCode,
+				Errors:     errs,
+				Body:       errorBody(respBodyBuf),
+				hasData:    dataErr != errNoData,
+			}
+		}
+
+		if dataErr != nil {
+			return fmt.Errorf("error decoding data payload: %v", dataErr)
+		}
+
+		return nil
+	})
+}
+
+// response is a response handled by exchange.
+type response struct {
+	*http.Response
+
+	// body is the response body, decompressed, and, unless it is decoded
+	// by codec, checked, spilled and limited as configured.
+	body  io.Reader
+	codec ResponseCodec
+
+	// boundary is the boundary of the parts of a multipart/mixed response
+	// delivered incrementally, whose body is read as is.
+	boundary string
+
+	// meta, if not nil, receives the metadata of the response.
+	meta *ResponseMetadata
+
+	// bytesRead counts the bytes of the body read.
+	bytesRead *int64
+}
+
+// empty reports whether the response body is empty, once it has been read.
+func (r *response) empty() bool {
+	return atomic.LoadInt64(r.bytesRead) == 0
+}
+
+// exchange sends req, made with ctx, and hands its response to decode, with
+// everything but decoding done as configured: connections are traced for
+// transport stats, progress is reported, stats and response metadata are
+// recorded, warnings are handled, the body is decompressed, redirects are
+// returned as a *RedirectError, and responses not decoded by a codec have
+// their Content-Type checked, and are spilled and limited. encErr, if not
+// nil, receives the error encoding a streamed request body. If incremental is
+// true, successful multipart/mixed responses are handed to decode as they are
+// read, with their boundary, for decode to limit their parts.
+func (c *Client) exchange(ctx context.Context, req *http.Request, encErr <-chan error, incremental bool, decode func(*response) error) (err error) {
+	if c.transportStats != nil {
+		var done func()
+		ctx, done = c.transportStats.trace(ctx)
+		defer done()
+
+		req = req.WithContext(ctx)
+	}
+
+	// bytesRead counts the bytes of the response body read, for stats and
+	// progress reports.
+	bytesRead := new(int64)
+
+	if c.progress != nil {
+		var (
+			uploaded = new(int64)
+			total    = req.ContentLength
+		)
+
+		if req.Body != nil {
+			if total == 0 {
+				total = -1
+			}
+			req.Body = &countingReadCloser{ReadCloser: req.Body, n: uploaded}
+		}
+
+		progress := startProgress(c.progressInterval, c.progress, uploaded, total, bytesRead)
+		defer progress.done()
+	}
+
+	var (
+		start     = time.Now()
+		bytesSent = req.ContentLength
+		gotResp   bool
 	)
+
+	if bytesSent <= 0 && req.Body != nil {
+		bytesSent = 0
+		req.Body = &countingReadCloser{ReadCloser: req.Body, n: &bytesSent}
+	}
+
+	defer func() {
+		c.stats.record(time.Since(start), atomic.LoadInt64(&bytesSent), atomic.LoadInt64(bytesRead), gotResp, err)
+	}()
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		select {
+		case err := <-encErr:
+			if err != nil {
+				return fmt.Errorf("error encoding variables: %v", err)
+			}
+		default:
+		}
+		return fmt.Errorf("error performing request: %v", err)
+	}
+	defer func() {
+		c.drainPolicy.drain(httpResp.Body)
+		httpResp.Body.Close()
+	}()
+
+	gotResp = true
+
+	if c.warningHandler != nil {
+		if warnings := ParseWarnings(httpResp.Header); len(warnings) > 0 {
+			c.warningHandler(ctx, warnings)
+		}
+	}
+
+	resp := &response{
+		Response:  httpResp,
+		meta:      responseMetadata(ctx),
+		bytesRead: bytesRead,
+	}
+
+	if resp.meta != nil {
+		resp.meta.StatusCode = httpResp.StatusCode
+		resp.meta.Header = httpResp.Header
+	}
+
+	body, closeBody, err := c.decompress(httpResp, &countingReader{r: httpResp.Body, n: bytesRead})
 	if err != nil {
-		return fmt.Errorf("error encoding variables: %v", err)
+		return err
+	}
+	defer closeBody()
+
+	if httpResp.StatusCode/100 == 3 {
+		return &RedirectError{
+			StatusCode: httpResp.StatusCode,
+			Location:   httpResp.Header.Get("Location"),
+		}
+	}
+
+	if resp.codec = c.responseCodec(httpResp); resp.codec != nil {
+		resp.body = body
+		return decode(resp)
+	}
+
+	if incremental && httpResp.StatusCode/100 == 2 {
+		if mediaType, params, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type")); mediaType == "multipart/mixed" {
+			resp.boundary = params["boundary"]
+			resp.body = body
+			return decode(resp)
+		}
+	}
+
+	if httpResp.StatusCode/100 == 2 {
+		if err := checkContentType(httpResp, body); err != nil {
+			return err
+		}
+	}
+
+	if c.spillResponses {
+		spilled, cleanup, err := spillBody(body, c.spillDir, c.spillThreshold)
+		if err != nil {
+			return fmt.Errorf("error reading response: %v", err)
+		}
+		defer cleanup()
+
+		body = spilled
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if c.decodeLimits != nil {
+		body = &limitReader{r: body, limits: *c.decodeLimits}
+	}
+
+	resp.body = body
+
+	return decode(resp)
+}
+
+// BuildRequest returns the request Query would send for query and variables,
+// with all request options applied, without sending it, e.g. to sign it or
+// send it with another transport. Its body is always buffered, also for
+// clients created with WithStreamingRequests, so that it can be read more
+// than once using GetBody. Queries sent as GET requests, see WithGETQueries,
+// have no body.
+func (c *Client) BuildRequest(ctx context.Context, query string, variables map[string]interface{}, reqOpts ...func(*http.Request)) (*http.Request, error) {
+	op := operation{query: query}
+	if c.documents != nil {
+		op = c.documents.operation(query)
+	}
+
+	req, release, _, err := c.prepareRequest(ctx, op, variables, reqOpts, false)
 	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+		return nil, err
 	}
 
-	req = req.WithContext(ctx)
+	if release == nil {
+		// The body, if any, is not in a pooled buffer.
+		return req, nil
+	}
+	defer release()
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	// Copy the body out of the pooled buffer, which is released.
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %v", err)
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	return req, nil
+}
+
+// prepareRequest returns the request sending op with variables, with the
+// client's request options and reqOpts applied. If stream is true, its body
+// is streamed, and the error encoding it is sent on encErr; otherwise
+// release, if not nil, must be called once the response has been handled.
+func (c *Client) prepareRequest(ctx context.Context, op operation, variables map[string]interface{}, reqOpts []func(*http.Request), stream bool) (req *http.Request, release func(), encErr <-chan error, err error) {
+	url, err := c.requestURL(ctx, op.query)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tag := c.operationTag(ctx)
+	p := c.newPayload(ctx, op, variables, tag)
+
+	if c.getQueries && !c.isMutation(op.query) {
+		if req, err = newGETRequest(ctx, url, p); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	switch {
+	case req != nil:
+	case c.graphqlBodies:
+		req, err = newGraphQLRequest(ctx, url, p)
+	case stream:
+		req, encErr, err = newStreamingRequest(ctx, url, p, c.gzipRequests)
+	default:
+		req, release, err = newRequest(ctx, url, p, c.gzipThresholdOrNone())
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c.applyRequestOptions(req, tag, reqOpts)
+
+	return req, release, encErr, nil
+}
+
+// requestURL returns the URL a request made with ctx sending queries is sent
+// to.
+func (c *Client) requestURL(ctx context.Context, queries ...string) (string, error) {
+	if c.writeURL != "" {
+		for _, query := range queries {
+			if c.isWrite(ctx, query) {
+				return c.writeURL, nil
+			}
+		}
+	}
+
+	if c.resolver != nil {
+		return c.resolver.endpoint(ctx)
+	}
+
+	return c.url, nil
+}
+
+// newPayload returns the request object sending op with variables in a
+// request made with ctx and tagged with tag.
+func (c *Client) newPayload(ctx context.Context, op operation, variables map[string]interface{}, tag string) *payload {
+	if c.omitNullVariables {
+		variables = withoutNullVariables(variables)
+	}
+
+	p := &payload{
+		op:            op,
+		variables:     variables,
+		omitVariables: c.omitEmptyVariables && len(variables) == 0,
+		extensions:    c.requestExtensions(ctx),
+		operationName: operationName(ctx),
+	}
+
+	if tag != "" && c.tagHeader == "" {
+		p.comment = tag
+	}
+
+	return p
+}
+
+// gzipThresholdOrNone returns the size of request bodies from which they are
+// compressed, or -1 if they are not.
+func (c *Client) gzipThresholdOrNone() int {
+	if c.gzipRequests {
+		return c.gzipThreshold
+	}
+	return -1
+}
+
+// applyRequestOptions sets the headers of req, tagged with tag, and applies
+// the client's request options and reqOpts to it.
+func (c *Client) applyRequestOptions(req *http.Request, tag string, reqOpts []func(*http.Request)) {
+	if req.Method == http.MethodPost && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", c.contentType)
+	}
+
+	if c.accept != "" && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", c.accept)
+	}
+
+	c.setAcceptEncoding(req)
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if tag != "" && c.tagHeader != "" {
+		req.Header.Set(c.tagHeader, tag)
+	}
+
+	c.setTimeoutHeader(req)
 
 	for _, o := range c.reqOpts {
 		o(req)
 	}
 
+	c.applyContextRequestOptions(req)
+
 	for _, o := range reqOpts {
 		o(req)
 	}
+}
+
+// payload is the request object sent in a request body.
+type payload struct {
+	op        operation
+	variables map[string]interface{}
+
+	// omitVariables leaves the "variables" key out.
+	omitVariables bool
+
+	// extensions, if not empty, are sent in the "extensions" key.
+	extensions map[string]interface{}
+
+	// comment, if not empty, is written as a comment before the query,
+	// unless it is sent by ID.
+	comment string
+
+	// operationName, if not empty, is sent in the "operationName" key.
+	operationName string
+}
+
+// writePrefix writes the request object up to the value of "variables" to
+// buf, or up to its end if p.omitVariables is true.
+func (p *payload) writePrefix(buf *bytes.Buffer) error {
+	start := buf.Len()
+
+	query := p.op.query
+	if p.comment != "" && p.op.id == "" {
+		query = "# " + p.comment + "\n" + query
+	}
+
+	switch {
+	case p.op.persist == persistHash:
+		buf.WriteString("{" + variablesKey[1:])
+	case p.comment != "" && p.op.id == "":
+		writeRequestPrefix(buf, query)
+	case p.op.prefix != nil:
+		buf.Write(p.op.prefix)
+	default:
+		writeRequestPrefix(buf, query)
+	}
+
+	if p.operationName != "" {
+		writeOperationName(buf, start, p.operationName)
+	}
+
+	extensions := p.extensions
+	if p.op.persist != notPersisted {
+		extensions = mergeExtensions(extensions, persistedQueryExtension(query))
+	}
+
+	if len(extensions) > 0 {
+		// Keep the keys sorted, with "extensions" first.
+		rest := getBuffer()
+		defer putBuffer(rest)
+
+		rest.Write(buf.Bytes()[start+1:])
+		buf.Truncate(start + 1)
+
+		buf.WriteString(`"extensions":`)
+		if err := writeCanonicalJSON(buf, extensions); err != nil {
+			return fmt.Errorf("error encoding extensions: %v", err)
+		}
+		buf.WriteByte(',')
+		buf.Write(rest.Bytes())
+	}
+
+	if p.omitVariables {
+		buf.Truncate(buf.Len() - len(variablesKey))
+	}
+
+	return nil
+}
+
+// write writes the request object encoded canonically to buf.
+func (p *payload) write(buf *bytes.Buffer) error {
+	if err := p.writePrefix(buf); err != nil {
+		return err
+	}
+
+	if !p.omitVariables {
+		if err := writeCanonicalJSON(buf, p.variables); err != nil {
+			return fmt.Errorf("error encoding variables: %v", err)
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// newRequest returns a request with p encoded canonically into a pooled
+// buffer as its body. If gzipThreshold is not negative, bodies of at least
+// gzipThreshold bytes are compressed with gzip. release must be called once
+// the response has been handled.
+func newRequest(ctx context.Context, url string, p *payload, gzipThreshold int) (*http.Request, func(), error) {
+	buf := getBuffer()
+
+	if err := p.write(buf); err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+
+	return newBufferedRequest(ctx, url, buf, gzipThreshold)
+}
+
+// newBufferedRequest returns a request with the pooled buffer buf as its
+// body, compressed as by newRequest. release must be called once the response
+// has been handled.
+func newBufferedRequest(ctx context.Context, url string, buf *bytes.Buffer, gzipThreshold int) (*http.Request, func(), error) {
+	var contentEncoding string
+
+	if gzipThreshold >= 0 && buf.Len() >= gzipThreshold {
+		zbuf := getBuffer()
+
+		if err := writeGzip(zbuf, buf.Bytes()); err != nil {
+			putBuffer(zbuf)
+			putBuffer(buf)
+			return nil, nil, fmt.Errorf("error compressing request: %v", err)
+		}
+
+		putBuffer(buf)
+		buf = zbuf
+		contentEncoding = "gzip"
+	}
 
-	resp, err := c.httpClient.Do(req)
+	body := newRequestBody(buf)
+
+	req, err := http.NewRequest(http.MethodPost, url, body)
 	if err != nil {
-		return fmt.Errorf("error performing request: %v", err)
+		body.Close()
+		body.release()
+		return nil, nil, fmt.Errorf("error creating request: %v", err)
 	}
-	defer func() {
-		io.CopyN(ioutil.Discard, resp.Body, 64)
-		resp.Body.Close()
+
+	req.ContentLength = int64(buf.Len())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	return req.WithContext(ctx), body.release, nil
+}
+
+// newStreamingRequest returns a request whose body is encoded by a goroutine
+// as the transport reads it, compressed with gzip if compress is true. The
+// error encoding the body, if any, is sent on the returned channel before the
+// body reports it to the transport.
+func newStreamingRequest(ctx context.Context, url string, p *payload, compress bool) (*http.Request, <-chan error, error) {
+	pr, pw := io.Pipe()
+
+	req, err := http.NewRequest(http.MethodPost, url, pr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	encErr := make(chan error, 1)
+
+	go func() {
+		var (
+			dst io.Writer = pw
+			zw  *gzip.Writer
+		)
+
+		if compress {
+			zw = getGzipWriter(pw)
+			defer putGzipWriter(zw)
+			dst = zw
+		}
+
+		w := bufio.NewWriterSize(dst, 32<<10)
+
+		err := writeRequestBody(w, p)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err == nil && zw != nil {
+			err = zw.Close()
+		}
+
+		encErr <- err
+		pw.CloseWithError(err)
 	}()
 
-	var response struct {
-		Data   json.RawMessage `json:"data"`
-		Errors []Error         `json:"errors"`
+	return req.WithContext(ctx), encErr, nil
+}
+
+// writeRequestPrefix writes the request object for query up to the value of
+// "variables" to buf. The keys of the request object are written in sorted
+// order, so that the request body is canonical if the variables are.
+func writeRequestPrefix(buf *bytes.Buffer, query string) {
+	buf.WriteString(`{"query":`)
+
+	// Encoding a string can't fail.
+	json.NewEncoder(buf).Encode(query)
+
+	// Drop the newline added by Encode.
+	buf.Truncate(buf.Len() - 1)
+
+	buf.WriteString(variablesKey)
+}
+
+// variablesKey ends the request object up to the value of "variables".
+const variablesKey = `,"variables":`
+
+// writeRequestBody writes the request object to w, encoding the variables
+// with a json.Encoder.
+func writeRequestBody(w io.Writer, p *payload) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := p.writePrefix(buf); err != nil {
+		return err
 	}
 
-	var respBody io.Reader = resp.Body
-	var respBodyBuf bytes.Buffer
-	respBody = io.TeeReader(respBody, &respBodyBuf)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
 
-	if err := json.NewDecoder(respBody).Decode(&response); err != nil {
-		if resp.StatusCode/100 != 2 {
-			return &ErrorResponse{
-				StatusCode: resp.StatusCode,
-				Body:       respBodyBuf.Next(2048),
+	if !p.omitVariables {
+		if err := json.NewEncoder(w).Encode(p.variables); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "}")
+
+	return err
+}
+
+// spillBody reads r to its end and returns a reader of what was read. Up to
+// threshold bytes are held in a pooled buffer; larger bodies are written to a
+// temporary file in dir, which is removed by cleanup.
+func spillBody(r io.Reader, dir string, threshold int64) (body io.Reader, cleanup func(), err error) {
+	buf := getBuffer()
+
+	if _, err := io.CopyN(buf, r, threshold+1); err == io.EOF {
+		return bytes.NewReader(buf.Bytes()), func() { putBuffer(buf) }, nil
+	} else if err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, "graphqlclient-response-")
+	if err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	_, err = buf.WriteTo(f)
+	putBuffer(buf)
+
+	if err == nil {
+		_, err = io.Copy(f, r)
+	}
+
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return f, cleanup, nil
+}
+
+// errNoData is the error decoding the data payload of a response without a
+// "data" field.
+var errNoData = errors.New("no data in response")
+
+// envelope holds the names of the fields of response objects holding the
+// data payload and the errors, if not "data" and "errors".
+type envelope struct {
+	data   string
+	errors string
+}
+
+// defaultEnvelope is the envelope of responses following the GraphQL
+// specification.
+var defaultEnvelope = envelope{data: "data", errors: "errors"}
+
+// decodeResponse decodes the response object read from r in a single pass.
+// If decodeData is true, the data field of env is decoded directly into data,
+// unless the errors field precedes it and is not empty and data is not a
+// *json.RawMessage. A data payload that doesn't match data is reported as
+// dataErr, as is errNoData if there is no data field; err is only set if the
+// response object itself can't be decoded. If lenient is true, malformed
+// errors fields are decoded by decodeLenientErrors. If extensions is not nil,
+// the "extensions" field is kept in it.
+func decodeResponse(r io.Reader, data interface{}, decodeData bool, env envelope, lenient bool, extensions *json.RawMessage) (errs []Error, dataErr error, err error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("response is %v, not an object", tok)
+	}
+
+	dataErr = errNoData
+
+	// Undecoded data payloads are kept even with errors.
+	_, raw := data.(*json.RawMessage)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, _ := tok.(string)
+
+		switch {
+		case strings.EqualFold(key, env.errors) && lenient:
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, nil, err
+			}
+
+			if errs, err = decodeLenientErrors(raw); err != nil {
+				return nil, nil, err
+			}
+		case strings.EqualFold(key, env.errors):
+			if err := dec.Decode(&errs); err != nil {
+				return nil, nil, err
+			}
+		case strings.EqualFold(key, env.data) && decodeData && (len(errs) == 0 || raw):
+			dataErr = dec.Decode(&data)
+
+			// Errors other than syntax errors and read errors leave
+			// the decoder at the next field.
+			var syntaxErr *json.SyntaxError
+			if errors.As(dataErr, &syntaxErr) || errors.Is(dataErr, io.ErrUnexpectedEOF) {
+				return nil, nil, dataErr
 			}
+		case strings.EqualFold(key, env.data):
+			// Present, but not decoded.
+			dataErr = nil
+
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, nil, err
+			}
+		case key == "extensions" && extensions != nil:
+			if err := dec.Decode(extensions); err != nil {
+				return nil, nil, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	return errs, dataErr, nil
+}
+
+// decodeLenientErrors decodes an "errors" field that may not be an array of
+// error objects: null items are dropped, strings are taken as messages, and
+// a single error object or string is taken as an array of one item.
+func decodeLenientErrors(raw json.RawMessage) ([]Error, error) {
+	var items []json.RawMessage
+
+	switch raw = bytes.TrimSpace(raw); {
+	case string(raw) == "null":
+		return nil, nil
+	case len(raw) > 0 && raw[0] == '[':
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("error decoding response: %v", err)
+	default:
+		items = []json.RawMessage{raw}
 	}
 
-	if resp.StatusCode/100 != 2 || len(response.Errors) > 0 {
-		return &ErrorResponse{
-			StatusCode: resp.StatusCode,
-			Errors:     response.Errors,
-			Body:       respBodyBuf.Next(2048),
+	var errs []Error
+
+	for _, item := range items {
+		var e Error
+
+		switch item = bytes.TrimSpace(item); {
+		case string(item) == "null":
+			continue
+		case len(item) > 0 && item[0] == '"':
+			if err := json.Unmarshal(item, &e.Message); err != nil {
+				return nil, err
+			}
+		default:
+			if err := json.Unmarshal(item, &e); err != nil {
+				return nil, err
+			}
 		}
+
+		errs = append(errs, e)
+	}
+
+	return errs, nil
+}
+
+// writeCanonicalJSON writes v encoded as JSON with sorted object keys to
+// buf. Values are first encoded with encoding/json and then decoded into
+// generic maps, which encoding/json always encodes in key order. Numbers are
+// decoded as json.Number, so their original formatting is kept as is.
+func writeCanonicalJSON(buf *bytes.Buffer, v interface{}) error {
+	tmp := getBuffer()
+	defer putBuffer(tmp)
+
+	if err := json.NewEncoder(tmp).Encode(v); err != nil {
+		return err
 	}
 
-	if err := json.Unmarshal(response.Data, &data); err != nil {
-		return fmt.Errorf("error decoding data payload: %v", err)
+	dec := json.NewDecoder(tmp)
+	dec.UseNumber()
+
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
 	}
 
+	if err := json.NewEncoder(buf).Encode(generic); err != nil {
+		return err
+	}
+
+	// Drop the newline added by Encode.
+	buf.Truncate(buf.Len() - 1)
+
 	return nil
 }
 
+// maxPooledBufferSize is the capacity above which buffers are not returned
+// to bufferPool, so that a few large requests or responses don't keep their
+// memory alive.
+const maxPooledBufferSize = 1 << 20
+
+// bufferPool holds the buffers requests are encoded into and responses are
+// captured into for error reporting.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// gzipWriterPool holds the writers request bodies are compressed with, since
+// a gzip.Writer allocates several hundred kilobytes of compression state.
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	zw.Reset(w)
+	return zw
+}
+
+func putGzipWriter(zw *gzip.Writer) {
+	// Drop the reference to the destination writer.
+	zw.Reset(nil)
+	gzipWriterPool.Put(zw)
+}
+
+// writeGzip writes p compressed with gzip to buf.
+func writeGzip(buf *bytes.Buffer, p []byte) error {
+	zw := getGzipWriter(buf)
+	defer putGzipWriter(zw)
+
+	if _, err := zw.Write(p); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// maxErrorBodySize is the number of bytes of the response body kept in an
+// ErrorResponse.
+const maxErrorBodySize = 2048
+
+// headWriter writes the first max bytes written to it to buf and discards the
+// rest.
+type headWriter struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.max - w.buf.Len(); room > 0 {
+		if len(p) > room {
+			w.buf.Write(p[:room])
+		} else {
+			w.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// errorBody returns a copy of the head of the response body captured in buf,
+// to be kept in an ErrorResponse after buf has been returned to the pool.
+func errorBody(buf *bytes.Buffer) []byte {
+	return append([]byte(nil), buf.Bytes()...)
+}
+
+// requestBody is a request body read from a pooled buffer. The transport may
+// close the body after Query has returned, so the buffer is returned to the
+// pool only once both the transport has closed the body and Query has
+// released it.
+type requestBody struct {
+	*bytes.Reader
+	buf   *bytes.Buffer
+	refs  int32
+	close sync.Once
+}
+
+func newRequestBody(buf *bytes.Buffer) *requestBody {
+	return &requestBody{
+		Reader: bytes.NewReader(buf.Bytes()),
+		buf:    buf,
+		refs:   2,
+	}
+}
+
+func (b *requestBody) Close() error {
+	b.close.Do(b.release)
+	return nil
+}
+
+func (b *requestBody) release() {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		putBuffer(b.buf)
+	}
+}
+
 // ErrorResponse wraps the HTTP status code returned from the server and the
 // value of the response object's "errors" array. If the response body is not
 // JSON, up to the first 2048 bytes of it will be stored in the Body field.
@@ -113,6 +1236,34 @@ type ErrorResponse struct {
 	StatusCode int
 	Body       []byte
 	Errors     []Error
+
+	// OperationName is the operation name sent in the request, if any,
+	// see ContextWithOperationName.
+	OperationName string
+
+	// hasData is true if the response object has a data field.
+	hasData bool
+}
+
+// IsRequestError reports whether the request failed as a whole, before it
+// was executed, e.g. because its query is invalid, as opposed to failing with
+// field errors during execution: the response has errors but no data field,
+// as specified by the GraphQL specification. Servers responding with
+// application/graphql-response+json respond to request errors with a 4xx or
+// 5xx status code, and to field errors with 200 and the partial data.
+func (e *ErrorResponse) IsRequestError() bool {
+	return len(e.Errors) > 0 && !e.hasData
+}
+
+// EmptyResponseError is returned for successful responses without a body,
+// e.g. 204 No Content, instead of the error decoding their response object.
+type EmptyResponseError struct {
+	StatusCode int
+}
+
+// Error returns a string representation of the error.
+func (e *EmptyResponseError) Error() string {
+	return fmt.Sprintf("empty response: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
 }
 
 // Error represents one item in the response object's "errors" array. Its
@@ -129,11 +1280,30 @@ type Error struct {
 
 // Error returns a string representation of the error.
 func (e *ErrorResponse) Error() string {
-	var errMsg string
+	// Built without fmt, in a single allocation, since callers commonly
+	// log every error.
+	var code [20]byte
+	status := strconv.AppendInt(code[:0], int64(e.StatusCode), 10)
+	statusText := http.StatusText(e.StatusCode)
+
+	var sb strings.Builder
+
 	if len(e.Errors) > 0 {
-		errMsg = e.Errors[0].Message
+		sb.Grow(len(status) + len(statusText) + len(e.Errors[0].Message) + 3)
 	} else {
-		errMsg = string(e.Body)
+		sb.Grow(len(status) + len(statusText) + len(e.Body) + 3)
 	}
-	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), errMsg)
+
+	sb.Write(status)
+	sb.WriteByte(' ')
+	sb.WriteString(statusText)
+	sb.WriteString(": ")
+
+	if len(e.Errors) > 0 {
+		sb.WriteString(e.Errors[0].Message)
+	} else {
+		sb.Write(e.Body)
+	}
+
+	return sb.String()
 }
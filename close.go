@@ -0,0 +1,130 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by operations started after Close is called,
+// and by subscriptions ended by it.
+var ErrClientClosed = errors.New("client closed")
+
+// Close shuts down the client gracefully: it stops accepting new operations,
+// ends active subscriptions, stopping them on their connections before
+// closing these, and waits for in-flight queries to finish or ctx to be
+// done, in which case it returns ctx.Err() and leaves the queries running.
+// Idle connections of the client's HTTP client are then closed.
+func (c *Client) Close(ctx context.Context) error {
+	defer c.httpClient.CloseIdleConnections()
+
+	select {
+	case <-c.lifecycle.close():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// clientLifecycle tracks the operations in flight on a client until it is
+// closed.
+type clientLifecycle struct {
+	mu      sync.Mutex
+	closed  bool
+	queries int
+	subs    map[int]context.CancelCauseFunc
+	nextSub int
+
+	// drained is closed once the client is closed and no operations are in
+	// flight.
+	drained chan struct{}
+}
+
+// begin registers a query, unless the client is closed.
+func (l *clientLifecycle) begin() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClientClosed
+	}
+
+	l.queries++
+
+	return nil
+}
+
+// end unregisters a query.
+func (l *clientLifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queries--
+	l.checkDrainedLocked()
+}
+
+// beginSubscription registers a subscription, unless the client is closed,
+// and returns its context, canceled with ErrClientClosed as cause when the
+// client is closed, and a function unregistering it.
+func (l *clientLifecycle) beginSubscription(ctx context.Context) (context.Context, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, nil, ErrClientClosed
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	if l.subs == nil {
+		l.subs = map[int]context.CancelCauseFunc{}
+	}
+
+	id := l.nextSub
+	l.nextSub++
+	l.subs[id] = cancel
+
+	return ctx, func() {
+		cancel(nil)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.subs, id)
+		l.checkDrainedLocked()
+	}, nil
+}
+
+// close closes the client, ending its subscriptions, and returns a channel
+// closed once no operations are in flight.
+func (l *clientLifecycle) close() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		l.drained = make(chan struct{})
+
+		for _, cancel := range l.subs {
+			cancel(ErrClientClosed)
+		}
+
+		l.checkDrainedLocked()
+	}
+
+	return l.drained
+}
+
+// checkDrainedLocked closes drained if the client is closed and no operations
+// are in flight. l.mu must be held.
+func (l *clientLifecycle) checkDrainedLocked() {
+	if !l.closed || l.queries > 0 || len(l.subs) > 0 {
+		return
+	}
+
+	select {
+	case <-l.drained:
+	default:
+		close(l.drained)
+	}
+}
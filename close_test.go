@@ -0,0 +1,97 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Close(t *testing.T) {
+	t.Run("Queries", func(t *testing.T) {
+		var (
+			received = make(chan struct{})
+			release  = make(chan struct{})
+		)
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				close(received)
+				<-release
+				w.Write([]byte(`{"data":{}}`))
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL)
+
+		errc := make(chan error, 1)
+		go func() {
+			var data interface{}
+			errc <- c.Query(context.Background(), "foo-query", nil, &data)
+		}()
+
+		<-received
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if got, want := c.Close(ctx), context.DeadlineExceeded; got != want {
+			t.Errorf("c.Close(ctx) = %v, want %v", got, want)
+		}
+
+		var data interface{}
+		if got, want := c.Query(context.Background(), "foo-query", nil, &data), ErrClientClosed; got != want {
+			t.Errorf("c.Query after Close = %v, want %v", got, want)
+		}
+
+		close(release)
+
+		if err := c.Close(context.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := <-errc; err != nil {
+			t.Errorf("in-flight query: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Subscriptions", func(t *testing.T) {
+		subscribed := make(chan struct{})
+
+		transport := subscriptionTransportFunc(func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+			close(subscribed)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		c := NewClient("http://example.com/graphql", WithSubscriptionTransport(transport))
+
+		errc := make(chan error, 1)
+		go func() {
+			errc <- c.Subscribe(context.Background(), "subscription { foo }", nil,
+				func(json.RawMessage, []Error) error { return nil },
+			)
+		}()
+
+		<-subscribed
+
+		if err := c.Close(context.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if got, want := <-errc, ErrClientClosed; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+
+		err := c.Subscribe(context.Background(), "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := err, ErrClientClosed; got != want {
+			t.Errorf("Subscribe after Close = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package graphqlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseCodec decodes response objects sent in a wire format other than
+// JSON, e.g. MessagePack or CBOR, for servers that can send responses in that
+// format. Requests are still sent as JSON.
+type ResponseCodec interface {
+	// MediaType is the media type of responses the codec decodes, e.g.
+	// application/msgpack, as sent in the Accept header of requests and
+	// the Content-Type header of responses.
+	MediaType() string
+
+	// DecodeResponse decodes the response object read from r, decoding
+	// its data payload into data, as Query does with JSON, and returning
+	// its errors. A nil data means the data payload is not decoded.
+	DecodeResponse(r io.Reader, data interface{}) ([]Error, error)
+}
+
+// WithResponseCodecs makes the client accept responses decoded by codecs, in
+// order of preference, ahead of the media types of its Accept header, see
+// WithAccept, and decode responses using the codec of their Content-Type.
+// Responses of other media types are decoded as JSON. The decode limits of
+// WithDecodeLimits only apply to JSON responses.
+func WithResponseCodecs(codecs ...ResponseCodec) Option {
+	return func(c *Client) {
+		c.codecs = append(c.codecs, codecs...)
+	}
+}
+
+// codecAccept returns the Accept header of a client preferring the media
+// types of codecs to accept.
+func codecAccept(codecs []ResponseCodec, accept string) string {
+	types := make([]string, 0, len(codecs)+1)
+	for _, codec := range codecs {
+		types = append(types, codec.MediaType())
+	}
+
+	if accept != "" {
+		types = append(types, accept)
+	}
+
+	return strings.Join(types, ", ")
+}
+
+// responseCodec returns the codec of the Content-Type of resp, or nil if the
+// client has none.
+func (c *Client) responseCodec(resp *http.Response) ResponseCodec {
+	if len(c.codecs) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+
+	for _, codec := range c.codecs {
+		if strings.EqualFold(codec.MediaType(), mediaType) {
+			return codec
+		}
+	}
+
+	return nil
+}
+
+// decodeWithCodec decodes the response object of a response with statusCode
+// read from body using codec, decoding its data payload into data.
+func (c *Client) decodeWithCodec(codec ResponseCodec, statusCode int, body io.Reader, data interface{}) error {
+	if statusCode/100 != 2 {
+		data = nil
+	}
+
+	// Undecoded data payloads are returned encoded as JSON.
+	raw, _ := data.(*json.RawMessage)
+
+	var v interface{}
+	if raw != nil {
+		data = &v
+	}
+
+	errs, err := codec.DecodeResponse(body, data)
+	if err == nil && raw != nil {
+		*raw, err = json.Marshal(v)
+	}
+
+	if err != nil {
+		if statusCode/100 != 2 {
+			return &ErrorResponse{StatusCode: statusCode}
+		}
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+
+	if statusCode/100 != 2 || len(errs) > 0 {
+		// Codecs don't report whether the response has a data payload;
+		// successful ones are assumed to have.
+		return &ErrorResponse{
+			StatusCode: statusCode,
+			Errors:     errs,
+			hasData:    statusCode/100 == 2,
+		}
+	}
+
+	return nil
+}
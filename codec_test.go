@@ -0,0 +1,124 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// gobCodec decodes responses encoded with encoding/gob, holding the data
+// payload as JSON.
+type gobCodec struct{}
+
+type gobResponse struct {
+	Data   []byte
+	Errors []string
+}
+
+func (gobCodec) MediaType() string {
+	return "application/x-gob"
+}
+
+func (gobCodec) DecodeResponse(r io.Reader, data interface{}) ([]Error, error) {
+	var resp gobResponse
+	if err := gob.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	var errs []Error
+	for _, msg := range resp.Errors {
+		errs = append(errs, Error{Message: msg})
+	}
+
+	if data != nil && len(errs) == 0 {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return errs, nil
+}
+
+func TestWithResponseCodecs(t *testing.T) {
+	var gotAccept string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotAccept = r.Header.Get("Accept")
+
+			switch r.URL.Query().Get("format") {
+			case "gob":
+				w.Header().Set("Content-Type", "application/x-gob")
+				gob.NewEncoder(w).Encode(gobResponse{Data: []byte(`{"foo":"gob"}`)})
+			case "gob-error":
+				w.Header().Set("Content-Type", "application/x-gob")
+				gob.NewEncoder(w).Encode(gobResponse{Errors: []string{"failed"}})
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"data":{"foo":"json"}}`))
+			}
+		},
+	))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		format  string
+		want    string
+		wantErr string
+	}{
+		{"gob", "gob", ""},
+		{"json", "json", ""},
+		{"gob-error", "", "200 OK: failed"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			c := NewClient(ts.URL+"?format="+tc.format, WithResponseCodecs(gobCodec{}))
+
+			var data struct {
+				Foo string `json:"foo"`
+			}
+
+			err := c.Query(context.Background(), "{ foo }", nil, &data)
+
+			switch {
+			case tc.wantErr != "":
+				if got, want := fmt.Sprint(err), tc.wantErr; got != want {
+					t.Errorf("err = %q, want %q", got, want)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := data.Foo, tc.want; got != want {
+				t.Errorf("data.Foo = %q, want %q", got, want)
+			}
+
+			if got, want := gotAccept, "application/x-gob, "+DefaultAccept; got != want {
+				t.Errorf("Accept = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("TransportWrapper", func(t *testing.T) {
+		c := NewClient(ts.URL+"?format=gob",
+			WithResponseCodecs(gobCodec{}),
+			WithTransportWrapper(func(next Transport) Transport { return next }),
+		)
+
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := c.Query(context.Background(), "{ foo }", nil, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "gob"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+	})
+}
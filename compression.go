@@ -0,0 +1,85 @@
+package graphqlclient
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Decompressor returns a reader decompressing r, e.g. wrapping a brotli or
+// zstd decoder:
+//
+//	graphqlclient.WithResponseCompression("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return io.NopCloser(brotli.NewReader(r)), nil
+//	})
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// WithResponseCompression makes the client advertise encoding, e.g. br or
+// zstd, in the Accept-Encoding header of requests, and decompress responses
+// with that Content-Encoding using fn. net/http only decompresses gzip
+// responses, and only if no Accept-Encoding header is set, so clients
+// created with the option advertise and decompress gzip too. Encodings are
+// advertised in the order they are added, ahead of gzip.
+func WithResponseCompression(encoding string, fn Decompressor) Option {
+	return func(c *Client) {
+		if c.decompressors == nil {
+			c.decompressors = make(map[string]Decompressor)
+		}
+
+		encoding = strings.ToLower(encoding)
+
+		if _, ok := c.decompressors[encoding]; !ok {
+			c.acceptEncoding = append(c.acceptEncoding, encoding)
+		}
+
+		c.decompressors[encoding] = fn
+	}
+}
+
+// setAcceptEncoding sets the Accept-Encoding header of req, if the client has
+// decompressors.
+func (c *Client) setAcceptEncoding(req *http.Request) {
+	if len(c.decompressors) == 0 {
+		return
+	}
+
+	encodings := c.acceptEncoding
+	if _, ok := c.decompressors["gzip"]; !ok {
+		encodings = append(encodings[:len(encodings):len(encodings)], "gzip")
+	}
+
+	req.Header.Set("Accept-Encoding", strings.Join(encodings, ", "))
+}
+
+// decompress returns body, the body of resp, decompressed according to the
+// Content-Encoding of resp, and a function closing the decompressor.
+func (c *Client) decompress(resp *http.Response, body io.Reader) (io.Reader, func(), error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	if len(c.decompressors) == 0 || encoding == "" || encoding == "identity" {
+		return body, func() {}, nil
+	}
+
+	fn, ok := c.decompressors[encoding]
+	if !ok && encoding == "gzip" {
+		fn, ok = gunzip, true
+	}
+
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	rc, err := fn(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decompressing response: %v", err)
+	}
+
+	return rc, func() { rc.Close() }, nil
+}
+
+// gunzip is the Decompressor of gzip.
+func gunzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
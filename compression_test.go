@@ -0,0 +1,85 @@
+package graphqlclient
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithResponseCompression(t *testing.T) {
+	var gotAcceptEncoding string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+
+			encoding := r.URL.Query().Get("encoding")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", encoding)
+
+			var zw io.WriteCloser
+
+			switch encoding {
+			case "deflate":
+				zw, _ = flate.NewWriter(w, flate.BestSpeed)
+			case "gzip":
+				zw = gzip.NewWriter(w)
+			default:
+				w.Write([]byte(`{"data":{"foo":"bar"}}`))
+				return
+			}
+
+			zw.Write([]byte(`{"data":{"foo":"bar"}}`))
+			zw.Close()
+		},
+	))
+	defer ts.Close()
+
+	deflate := func(r io.Reader) (io.ReadCloser, error) {
+		return flate.NewReader(r), nil
+	}
+
+	for _, tc := range []struct {
+		encoding string
+		wantErr  string
+	}{
+		{"deflate", ""},
+		{"gzip", ""},
+		{"identity", ""},
+		{"br", `unsupported content encoding "br"`},
+	} {
+		t.Run(tc.encoding, func(t *testing.T) {
+			c := NewClient(ts.URL+"?encoding="+tc.encoding, WithResponseCompression("deflate", deflate))
+
+			var data struct {
+				Foo string `json:"foo"`
+			}
+
+			err := c.Query(context.Background(), "{ foo }", nil, &data)
+
+			if tc.wantErr != "" {
+				if got, want := fmt.Sprint(err), tc.wantErr; got != want {
+					t.Errorf("err = %q, want %q", got, want)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := data.Foo, "bar"; got != want {
+				t.Errorf("data.Foo = %q, want %q", got, want)
+			}
+
+			if got, want := gotAcceptEncoding, "deflate, gzip"; got != want {
+				t.Errorf("Accept-Encoding = %q, want %q", got, want)
+			}
+		})
+	}
+}
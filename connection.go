@@ -0,0 +1,88 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Nodes is a slice of the nodes of a Relay connection. It decodes a
+// connection selecting either edges { node { ... } } or nodes { ... }
+// directly into a slice of the node type, so that response types don't need
+// wrapper types for the connection and its edges:
+//
+//	var data struct {
+//		User struct {
+//			Friends graphqlclient.Nodes[Friend] `json:"friends"`
+//		} `json:"user"`
+//	}
+//
+// A JSON array is decoded as a plain slice.
+type Nodes[T any] []T
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Nodes[T]) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+
+	if len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, (*[]T)(n))
+	}
+
+	var conn Connection[T]
+	if err := json.Unmarshal(b, &conn); err != nil {
+		return err
+	}
+
+	*n = conn.Nodes
+
+	return nil
+}
+
+// Connection is a Relay connection, decoded with its nodes flattened as for
+// Nodes.
+type Connection[T any] struct {
+	Nodes      []T
+	PageInfo   PageInfo
+	TotalCount *int
+}
+
+// PageInfo is the pageInfo of a Relay connection.
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Connection[T]) UnmarshalJSON(b []byte) error {
+	if string(bytes.TrimSpace(b)) == "null" {
+		return nil
+	}
+
+	var conn struct {
+		Edges []struct {
+			Node T `json:"node"`
+		} `json:"edges"`
+		Nodes      []T      `json:"nodes"`
+		PageInfo   PageInfo `json:"pageInfo"`
+		TotalCount *int     `json:"totalCount"`
+	}
+
+	if err := json.Unmarshal(b, &conn); err != nil {
+		return err
+	}
+
+	c.Nodes = conn.Nodes
+
+	if c.Nodes == nil && conn.Edges != nil {
+		c.Nodes = make([]T, len(conn.Edges))
+		for i, e := range conn.Edges {
+			c.Nodes[i] = e.Node
+		}
+	}
+
+	c.PageInfo = conn.PageInfo
+	c.TotalCount = conn.TotalCount
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package graphqlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestNodes(t *testing.T) {
+	type friend struct {
+		Name string `json:"name"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		json string
+		want string
+	}{
+		{"Edges", `{"edges":[{"node":{"name":"a"}},{"node":{"name":"b"}}]}`, "[{a} {b}]"},
+		{"Nodes", `{"nodes":[{"name":"a"}],"pageInfo":{"hasNextPage":false}}`, "[{a}]"},
+		{"Array", `[{"name":"a"}]`, "[{a}]"},
+		{"Empty", `{"edges":[]}`, "[]"},
+		{"Null", `null`, "[]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var data struct {
+				Friends Nodes[friend] `json:"friends"`
+			}
+
+			if err := json.Unmarshal([]byte(`{"friends":`+tc.json+`}`), &data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := fmt.Sprint(data.Friends), tc.want; got != want {
+				t.Errorf("data.Friends = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		var nodes Nodes[friend]
+
+		if err := json.Unmarshal([]byte(`{"edges":[{"node":1}]}`), &nodes); err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+}
+
+func TestConnection(t *testing.T) {
+	var conn Connection[int]
+
+	err := json.Unmarshal([]byte(`{"edges":[{"cursor":"c1","node":1},{"cursor":"c2","node":2}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"},"totalCount":10}`), &conn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := fmt.Sprint(conn.Nodes), "[1 2]"; got != want {
+		t.Errorf("conn.Nodes = %s, want %s", got, want)
+	}
+
+	if got, want := conn.PageInfo.HasNextPage, true; got != want {
+		t.Errorf("conn.PageInfo.HasNextPage = %v, want %v", got, want)
+	}
+
+	if got, want := *conn.PageInfo.EndCursor, "c2"; got != want {
+		t.Errorf("conn.PageInfo.EndCursor = %q, want %q", got, want)
+	}
+
+	if got, want := *conn.TotalCount, 10; got != want {
+		t.Errorf("conn.TotalCount = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package graphqlclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// UnexpectedContentTypeError is returned for successful responses whose
+// Content-Type is not one a response object is sent with, e.g. the HTML error
+// page of a misconfigured proxy sent with status 200, instead of the error
+// decoding it as JSON.
+type UnexpectedContentTypeError struct {
+	StatusCode  int
+	ContentType string
+
+	// Body is the head of the response body, up to its first 2048 bytes.
+	Body []byte
+}
+
+// Error returns a string representation of the error.
+func (e *UnexpectedContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected content type %q in response: %d %s", e.ContentType, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// checkContentType returns an *UnexpectedContentTypeError if resp, a 2xx
+// response, has a Content-Type that can't be a response object. JSON media
+// types, including application/graphql-response+json, are expected, but
+// text/plain and application/octet-stream, sent by some servers for JSON, and
+// responses without a Content-Type are tolerated.
+func checkContentType(resp *http.Response, body io.Reader) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		switch {
+		case mediaType == "application/json",
+			strings.HasSuffix(mediaType, "+json"),
+			mediaType == "text/plain",
+			mediaType == "application/octet-stream":
+			return nil
+		}
+	}
+
+	head, _ := ioutil.ReadAll(io.LimitReader(body, maxErrorBodySize))
+
+	return &UnexpectedContentTypeError{
+		StatusCode:  resp.StatusCode,
+		ContentType: contentType,
+		Body:        head,
+	}
+}
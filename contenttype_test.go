@@ -0,0 +1,70 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnexpectedContentTypeError(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{"JSON", "application/json; charset=utf-8", `{"data":{}}`, false},
+		{"GraphQLResponse", "application/graphql-response+json", `{"data":{}}`, false},
+		{"TextPlain", "text/plain", `{"data":{}}`, false},
+		{"None", "", `{"data":{}}`, false},
+		{"HTML", "text/html; charset=utf-8", "<html><body>Please log in</body></html>", true},
+		{"Invalid", "json", `{"data":{}}`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header()["Content-Type"] = []string{tc.contentType}
+					w.Write([]byte(tc.body))
+				},
+			))
+			defer ts.Close()
+
+			err := NewClient(ts.URL).Query(context.Background(), "{ foo }", nil, nil)
+
+			var ctErr *UnexpectedContentTypeError
+
+			if !tc.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.As(err, &ctErr) {
+				t.Fatalf("err = %v, want %T", err, ctErr)
+			}
+
+			if got, want := ctErr.StatusCode, http.StatusOK; got != want {
+				t.Errorf("StatusCode = %d, want %d", got, want)
+			}
+
+			if got, want := ctErr.ContentType, tc.contentType; got != want {
+				t.Errorf("ContentType = %q, want %q", got, want)
+			}
+
+			if got, want := string(ctErr.Body), tc.body; got != want {
+				t.Errorf("Body = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		err := &UnexpectedContentTypeError{StatusCode: http.StatusOK, ContentType: "text/html"}
+
+		if got, want := err.Error(), `unexpected content type "text/html" in response: 200 OK`; got != want {
+			t.Errorf("err.Error() = %q, want %q", got, want)
+		}
+	})
+}
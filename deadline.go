@@ -0,0 +1,46 @@
+package graphqlclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeoutHeader is the header WithTimeoutHeader sends the remaining
+// time of requests in if given an empty name.
+const DefaultTimeoutHeader = "X-Request-Timeout-Ms"
+
+// WithTimeoutHeader makes the client send the time remaining until the
+// deadline of the context of requests, if any, in whole milliseconds, in the
+// header with name, or DefaultTimeoutHeader if empty, so that servers can
+// shed work they can't finish in time. The header is set before the client's
+// request options are applied, which may override it.
+func WithTimeoutHeader(name string) Option {
+	if name == "" {
+		name = DefaultTimeoutHeader
+	}
+
+	return func(c *Client) {
+		c.timeoutHeader = name
+	}
+}
+
+// setTimeoutHeader sets the timeout header of req, if the client sends one,
+// to the time remaining until the deadline of its context.
+func (c *Client) setTimeoutHeader(req *http.Request) {
+	if c.timeoutHeader == "" {
+		return
+	}
+
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+
+	ms := time.Until(deadline).Milliseconds()
+	if ms < 0 {
+		ms = 0
+	}
+
+	req.Header.Set(c.timeoutHeader, strconv.FormatInt(ms, 10))
+}
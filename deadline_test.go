@@ -0,0 +1,57 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutHeader(t *testing.T) {
+	var (
+		gotHeader string
+		hasHeader bool
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotHeader = r.Header.Get(DefaultTimeoutHeader)
+			_, hasHeader = r.Header[DefaultTimeoutHeader]
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithTimeoutHeader(""))
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := c.Query(ctx, "{ foo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ms, err := strconv.Atoi(gotHeader)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ms <= 4000 || ms > 5000 {
+			t.Errorf("%s = %d, want in (4000, 5000]", DefaultTimeoutHeader, ms)
+		}
+	})
+
+	t.Run("NoDeadline", func(t *testing.T) {
+		if err := c.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if hasHeader {
+			t.Errorf("%s = %q, want none", DefaultTimeoutHeader, gotHeader)
+		}
+	})
+}
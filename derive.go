@@ -0,0 +1,92 @@
+package graphqlclient
+
+// With returns a client configured as c with opts applied on top, for the
+// operations that need another timeout, endpoint, headers or decoding
+// settings than the rest:
+//
+//	reports := c.With(
+//		graphqlclient.WithEndpoint("https://reports.example.com/graphql"),
+//		graphqlclient.WithTimeout(time.Minute),
+//		graphqlclient.WithLenientResponses(),
+//	)
+//
+// Options adding to the configuration of c, e.g. WithRequestOptions and
+// WithExtensions, add to it without changing c. The client shares the stats
+// of c, and is closed with it, and vice versa; its queries are batched apart
+// from those of c, see WithQueryBatching. It uses the HTTP client of c unless
+// opts set another one, tuned as that of c, or tune its transport, in which
+// case a copy of it is tuned, with connections of its own. WithRedirects(true)
+// has no effect if c doesn't follow redirects.
+//
+// Deriving a client is cheap, but derived clients are meant to be kept, like
+// c, rather than derived for each query.
+func (c *Client) With(opts ...Option) *Client {
+	d := *c
+
+	// Options append to slices and add to maps, which must not be shared
+	// with c.
+	d.reqOpts = c.reqOpts[:len(c.reqOpts):len(c.reqOpts)]
+	d.ctxReqOpts = c.ctxReqOpts[:len(c.ctxReqOpts):len(c.ctxReqOpts)]
+	d.codecs = c.codecs[:len(c.codecs):len(c.codecs)]
+	d.acceptEncoding = c.acceptEncoding[:len(c.acceptEncoding):len(c.acceptEncoding)]
+	d.transportWrappers = c.transportWrappers[:len(c.transportWrappers):len(c.transportWrappers)]
+	d.transportOpts = nil
+
+	if c.extensions != nil {
+		d.extensions = mergeExtensions(c.extensions, nil)
+	}
+
+	if c.decompressors != nil {
+		d.decompressors = make(map[string]Decompressor, len(c.decompressors))
+		for encoding, fn := range c.decompressors {
+			d.decompressors[encoding] = fn
+		}
+	}
+
+	if c.batcher != nil {
+		d.batcher = &queryBatcher{
+			window:  c.batcher.window,
+			maxSize: c.batcher.maxSize,
+			pending: make(map[string]*queryBatch),
+		}
+	}
+
+	for _, o := range opts {
+		o(&d)
+	}
+
+	added := d.transportOpts
+	d.transportOpts = append(c.transportOpts[:len(c.transportOpts):len(c.transportOpts)], added...)
+
+	if d.httpClient != c.httpClient {
+		// The HTTP client set by opts is tuned as that of c was.
+		if len(d.transportOpts) > 0 {
+			d.httpClient = tunedHTTPClient(d.httpClient, d.transportOpts)
+		}
+
+		if d.noPOSTRedirects {
+			d.httpClient = withoutPOSTRedirects(d.httpClient)
+		}
+	} else {
+		if len(added) > 0 {
+			d.httpClient = tunedHTTPClient(d.httpClient, added)
+		}
+
+		if d.noPOSTRedirects && !c.noPOSTRedirects {
+			d.httpClient = withoutPOSTRedirects(d.httpClient)
+		}
+	}
+
+	// The transports wrapped send with d.
+	d.wrappedTransport = d.buildTransport()
+
+	if d.accept != c.accept {
+		d.jsonAccept = d.accept
+	}
+
+	if len(d.codecs) > len(c.codecs) {
+		d.accept = codecAccept(d.codecs[len(c.codecs):], d.accept)
+	}
+
+	return &d
+}
@@ -0,0 +1,89 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientWith(t *testing.T) {
+	var (
+		gotPath   string
+		gotHeader string
+		gotBody   string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotPath = r.URL.Path
+			gotHeader = r.Header.Get("X-Foo")
+			gotBody = string(b)
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL+"/graphql",
+		WithHeader("X-Foo", "a"),
+		WithExtensions(map[string]interface{}{"a": 1}),
+	)
+
+	d := c.With(
+		WithEndpoint(ts.URL+"/reports"),
+		WithHeader("X-Foo", "b"),
+		WithExtensions(map[string]interface{}{"b": 2}),
+	)
+
+	for _, tc := range []struct {
+		name       string
+		client     *Client
+		wantPath   string
+		wantHeader string
+		wantBody   string
+	}{
+		{"Derived", d, "/reports", "b", `{"extensions":{"a":1,"b":2},"query":"{ foo }","variables":null}`},
+		{"Parent", c, "/graphql", "a", `{"extensions":{"a":1},"query":"{ foo }","variables":null}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.client.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := gotPath, tc.wantPath; got != want {
+				t.Errorf("path = %q, want %q", got, want)
+			}
+
+			if got, want := gotHeader, tc.wantHeader; got != want {
+				t.Errorf("X-Foo = %q, want %q", got, want)
+			}
+
+			if got, want := gotBody, tc.wantBody; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("Stats", func(t *testing.T) {
+		if got, want := c.Stats().Requests, int64(2); got != want {
+			t.Errorf("Requests = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		if err := c.Close(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := d.Query(context.Background(), "{ foo }", nil, nil), ErrClientClosed; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
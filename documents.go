@@ -0,0 +1,365 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// DefaultDocumentIDField is the request field the IDs of trusted documents
+// are sent in by default, as proposed by the GraphQL over HTTP specification.
+const DefaultDocumentIDField = "documentId"
+
+// TrustedDocuments holds trusted documents, the queries a server has been
+// given ahead of time, which clients refer to by ID instead of sending their
+// text. Queries are matched ignoring whitespace, commas and comments, or by
+// operation name for documents loaded from operation maps.
+type TrustedDocuments struct {
+	field string
+
+	// ids maps minified queries to document IDs, and queries document IDs
+	// to minified queries.
+	ids     map[string]string
+	queries map[string]string
+
+	// names maps operation names to document IDs, for matching queries.
+	names map[string]string
+
+	// operationIDs maps the names of the documents' operations to document
+	// IDs, for QueryOperation.
+	operationIDs map[string]string
+
+	// operations caches the operations sent for up to
+	// maxCachedOperations queries, as passed to Query, by query.
+	operations sync.Map
+	cached     int32
+}
+
+// maxCachedOperations bounds the number of operations TrustedDocuments caches,
+// for clients building queries dynamically.
+const maxCachedOperations = 1024
+
+// NewTrustedDocuments returns the trusted documents in documents, mapping
+// document IDs to queries. The IDs are sent in field, or in
+// DefaultDocumentIDField if empty; Relay servers typically expect doc_id.
+func NewTrustedDocuments(documents map[string]string, field string) (*TrustedDocuments, error) {
+	if field == "" {
+		field = DefaultDocumentIDField
+	}
+
+	d := &TrustedDocuments{
+		field:        field,
+		ids:          make(map[string]string, len(documents)),
+		queries:      make(map[string]string, len(documents)),
+		operationIDs: make(map[string]string, len(documents)),
+	}
+
+	for id, query := range documents {
+		doc, err := graphql.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing document %q: %v", id, err)
+		}
+
+		minified, err := graphql.Minify(query)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing document %q: %v", id, err)
+		}
+
+		d.ids[minified] = id
+		d.queries[id] = minified
+
+		if len(doc.Operations) == 1 && doc.Operations[0].Name != "" {
+			d.operationIDs[doc.Operations[0].Name] = id
+		}
+	}
+
+	return d, nil
+}
+
+// LoadRelayQueryMap reads the JSON object mapping document IDs to queries
+// that the Relay compiler writes when persisting queries, and returns its
+// documents. See NewTrustedDocuments for field.
+func LoadRelayQueryMap(r io.Reader, field string) (*TrustedDocuments, error) {
+	var documents map[string]string
+	if err := json.NewDecoder(r).Decode(&documents); err != nil {
+		return nil, fmt.Errorf("error decoding query map: %v", err)
+	}
+
+	return NewTrustedDocuments(documents, field)
+}
+
+// NewNamedTrustedDocuments returns the trusted documents in ids, mapping
+// operation names to document IDs, as used by gateways persisting operations
+// by name. A query is sent by ID if it has a single operation with one of the
+// names. See NewTrustedDocuments for field.
+func NewNamedTrustedDocuments(ids map[string]string, field string) *TrustedDocuments {
+	if field == "" {
+		field = DefaultDocumentIDField
+	}
+
+	d := &TrustedDocuments{
+		field: field,
+		names: make(map[string]string, len(ids)),
+	}
+
+	for name, id := range ids {
+		d.names[name] = id
+	}
+
+	d.operationIDs = d.names
+
+	return d
+}
+
+// apolloManifestFormat is the format of Apollo persisted query manifests.
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+// LoadApolloManifest reads the persisted query manifest that Apollo's
+// generate-persisted-query-manifest tool writes, listing the ID, name and
+// body of each operation, and returns its documents. Operations can then be
+// sent by name with QueryOperation. See NewTrustedDocuments for field.
+func LoadApolloManifest(r io.Reader, field string) (*TrustedDocuments, error) {
+	var manifest struct {
+		Format     string `json:"format"`
+		Version    int    `json:"version"`
+		Operations []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %v", err)
+	}
+
+	if manifest.Format != apolloManifestFormat || manifest.Version != 1 {
+		return nil, fmt.Errorf("unsupported manifest format %q, version %d", manifest.Format, manifest.Version)
+	}
+
+	documents := make(map[string]string, len(manifest.Operations))
+	for _, op := range manifest.Operations {
+		documents[op.ID] = op.Body
+	}
+
+	d, err := NewTrustedDocuments(documents, field)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range manifest.Operations {
+		if op.Name != "" {
+			d.operationIDs[op.Name] = op.ID
+		}
+	}
+
+	return d, nil
+}
+
+// LoadOperationMap reads the JSON object mapping operation names to hashes or
+// IDs that WunderGraph and GraphQL Mesh tooling write when persisting
+// operations, and returns its documents. The object may also be wrapped in a
+// manifest, under "operations". See NewTrustedDocuments for field.
+func LoadOperationMap(r io.Reader, field string) (*TrustedDocuments, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding operation map: %v", err)
+	}
+
+	if ops, ok := raw["operations"]; ok && len(ops) > 0 && ops[0] == '{' {
+		raw = nil
+		if err := json.Unmarshal(ops, &raw); err != nil {
+			return nil, fmt.Errorf("error decoding operation map: %v", err)
+		}
+	}
+
+	ids := make(map[string]string, len(raw))
+
+	for name, v := range raw {
+		var id string
+		if err := json.Unmarshal(v, &id); err != nil {
+			return nil, fmt.Errorf("error decoding operation map: operation %q: %v", name, err)
+		}
+		ids[name] = id
+	}
+
+	return NewNamedTrustedDocuments(ids, field), nil
+}
+
+// ID returns the ID of the document with query, and whether there is one.
+func (d *TrustedDocuments) ID(query string) (string, bool) {
+	minified, err := graphql.Minify(query)
+	if err != nil {
+		return "", false
+	}
+
+	if id, ok := d.ids[minified]; ok {
+		return id, true
+	}
+
+	if len(d.names) == 0 {
+		return "", false
+	}
+
+	doc, err := graphql.ParseQuery(query)
+	if err != nil || len(doc.Operations) != 1 || doc.Operations[0].Name == "" {
+		return "", false
+	}
+
+	id, ok := d.names[doc.Operations[0].Name]
+
+	return id, ok
+}
+
+// OperationID returns the ID of the document with the operation named name,
+// and whether there is one.
+func (d *TrustedDocuments) OperationID(name string) (string, bool) {
+	id, ok := d.operationIDs[name]
+	return id, ok
+}
+
+// operation returns the operation sending query: its document ID if it is a
+// trusted document, or else its text.
+func (d *TrustedDocuments) operation(query string) operation {
+	if op, ok := d.operations.Load(query); ok {
+		return op.(operation)
+	}
+
+	op := operation{query: query}
+
+	if id, ok := d.ID(query); ok {
+		op.prefix = d.prefix(id)
+		op.id = id
+	}
+
+	if atomic.AddInt32(&d.cached, 1) <= maxCachedOperations {
+		d.operations.Store(query, op)
+	}
+
+	return op
+}
+
+// prefix returns the request object referring to the document with id, up
+// to the value of "variables".
+func (d *TrustedDocuments) prefix(id string) []byte {
+	return documentPrefix(d.field, id)
+}
+
+// documentPrefix returns the request object referring to the document with
+// id in field, up to the value of "variables".
+func documentPrefix(field, id string) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	// Encoding strings can't fail.
+	json.NewEncoder(&buf).Encode(field)
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteByte(':')
+	json.NewEncoder(&buf).Encode(id)
+	buf.Truncate(buf.Len() - 1)
+
+	buf.WriteString(variablesKey)
+
+	return buf.Bytes()
+}
+
+// WithTrustedDocuments makes the client send the IDs of trusted documents in
+// docs instead of their text. Other queries are sent as text.
+func WithTrustedDocuments(docs *TrustedDocuments) Option {
+	return func(c *Client) {
+		c.documents = docs
+	}
+}
+
+// QueryDocument sends the trusted document with id, as registered with the
+// server ahead of time, and variables to the server, as Query does, without
+// the text of its query. The ID is sent in the field of the client's trusted
+// documents, see WithTrustedDocuments, or else in DefaultDocumentIDField.
+// Documents whose query is not among the client's trusted documents are
+// routed as mutations, see WithWriteEndpoint.
+//
+// If the server doesn't know the document, an *UnknownDocumentError is
+// returned, also by Query for queries sent by ID.
+func (c *Client) QueryDocument(ctx context.Context, id string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	op := operation{id: id}
+
+	if c.documents != nil {
+		op.query = c.documents.queries[id]
+		op.prefix = c.documents.prefix(id)
+	} else {
+		op.prefix = documentPrefix(DefaultDocumentIDField, id)
+	}
+
+	return c.query(ctx, op, variables, data, reqOpts)
+}
+
+// QueryOperation sends the trusted document with the operation named name,
+// as loaded into the client's trusted documents, and variables to the server,
+// as QueryDocument does, e.g. for operations listed in a manifest loaded with
+// LoadApolloManifest or LoadRelayQueryMap.
+func (c *Client) QueryOperation(ctx context.Context, name string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	if c.documents == nil {
+		return fmt.Errorf("unknown operation %q: no trusted documents", name)
+	}
+
+	id, ok := c.documents.OperationID(name)
+	if !ok {
+		return fmt.Errorf("unknown operation %q", name)
+	}
+
+	return c.QueryDocument(ctx, id, variables, data, reqOpts...)
+}
+
+// UnknownDocumentError is returned for requests sending a trusted document by
+// ID that the server doesn't know, typically because the documents
+// registered with the server have drifted from those of the client.
+type UnknownDocumentError struct {
+	// ID is the ID of the document.
+	ID string
+
+	// Response is the response of the server.
+	Response *ErrorResponse
+}
+
+// Error returns a string representation of the error.
+func (e *UnknownDocumentError) Error() string {
+	return fmt.Sprintf("unknown document %q: %v", e.ID, e.Response)
+}
+
+// Unwrap returns the response of the server.
+func (e *UnknownDocumentError) Unwrap() error {
+	return e.Response
+}
+
+// unknownDocument returns err, returned for a request sending the document
+// with id, if not empty, as an *UnknownDocumentError if the server reported
+// the document as unknown.
+func unknownDocument(id string, err error) error {
+	var errResp *ErrorResponse
+	if id == "" || !errors.As(err, &errResp) {
+		return err
+	}
+
+	for _, e := range errResp.Errors {
+		code, _ := e.Extensions["code"].(string)
+
+		switch {
+		case e.Message == "PersistedQueryNotFound",
+			e.Message == "PersistedDocumentNotFound",
+			code == "PERSISTED_QUERY_NOT_FOUND",
+			code == "PERSISTED_DOCUMENT_NOT_FOUND":
+			return &UnknownDocumentError{ID: id, Response: errResp}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,360 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithTrustedDocuments(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	queryMap := `{
+		"a1b2": "query GetUser($id: ID!) { user(id: $id) { name } }",
+		"c3d4": "query Viewer { viewer { id } }"
+	}`
+
+	for _, tc := range []struct {
+		name     string
+		field    string
+		query    string
+		prepared bool
+		want     string
+	}{
+		{
+			name:  "Document",
+			query: "query GetUser($id: ID!) {\n  user(id: $id) {\n    name\n  }\n}",
+			want:  `{"documentId":"a1b2","variables":{"id":"1"}}`,
+		},
+		{
+			name:  "Field",
+			field: "doc_id",
+			query: "query Viewer { viewer { id } }",
+			want:  `{"doc_id":"c3d4","variables":{"id":"1"}}`,
+		},
+		{
+			name:  "NotDocument",
+			query: "{ foo }",
+			want:  `{"query":"{ foo }","variables":{"id":"1"}}`,
+		},
+		{
+			name:     "Prepared",
+			query:    "query Viewer { viewer { id } }",
+			prepared: true,
+			want:     `{"documentId":"c3d4","variables":{"id":"1"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := LoadRelayQueryMap(strings.NewReader(queryMap), tc.field)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			c := NewClient(ts.URL, WithTrustedDocuments(docs))
+			variables := map[string]interface{}{"id": "1"}
+
+			for n := 0; n < 2; n++ {
+				if tc.prepared {
+					op, err := c.Prepare(tc.query)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					err = op.Query(context.Background(), variables, nil)
+				} else {
+					err = c.Query(context.Background(), tc.query, variables, nil)
+				}
+
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if got, want := gotBody, tc.want; got != want {
+					t.Errorf("body = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("InvalidDocument", func(t *testing.T) {
+		_, err := NewTrustedDocuments(map[string]string{"a": `{ foo(s: "x) }`}, "")
+
+		if err == nil || !strings.HasPrefix(err.Error(), `error parsing document "a"`) {
+			t.Errorf("err = %v, want error parsing document", err)
+		}
+	})
+}
+
+func TestLoadOperationMap(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		json    string
+		query   string
+		wantID  string
+		wantErr string
+	}{
+		{
+			name:   "Map",
+			json:   `{"GetUser":"a1b2","Viewer":"c3d4"}`,
+			query:  "query GetUser($id: ID!) { user(id: $id) { name } }",
+			wantID: "a1b2",
+		},
+		{
+			name:   "Manifest",
+			json:   `{"version":1,"operations":{"GetUser":"a1b2","Viewer":"c3d4"}}`,
+			query:  "query Viewer { viewer { id } }",
+			wantID: "c3d4",
+		},
+		{
+			name:  "Anonymous",
+			json:  `{"GetUser":"a1b2"}`,
+			query: "{ user { name } }",
+		},
+		{
+			name:  "Unknown",
+			json:  `{"GetUser":"a1b2"}`,
+			query: "query Other { user { name } }",
+		},
+		{
+			name:  "MultipleOperations",
+			json:  `{"GetUser":"a1b2"}`,
+			query: "query GetUser { user { name } } query Other { viewer { id } }",
+		},
+		{
+			name:    "InvalidID",
+			json:    `{"GetUser":1}`,
+			wantErr: `error decoding operation map: operation "GetUser": json: cannot unmarshal number into Go value of type string`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			docs, err := LoadOperationMap(strings.NewReader(tc.json), "")
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			id, ok := docs.ID(tc.query)
+
+			if got, want := id, tc.wantID; got != want {
+				t.Errorf("id = %q, want %q", got, want)
+			}
+
+			if got, want := ok, tc.wantID != ""; got != want {
+				t.Errorf("ok = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestQueryDocument(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+
+			if strings.Contains(gotBody, `"unknown"`) {
+				w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+				return
+			}
+
+			w.Write([]byte(`{"data":{"viewer":{"id":"1"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	t.Run("Document", func(t *testing.T) {
+		c := NewClient(ts.URL)
+
+		var data struct {
+			Viewer struct {
+				ID string `json:"id"`
+			} `json:"viewer"`
+		}
+
+		if err := c.QueryDocument(context.Background(), "c3d4", map[string]interface{}{"id": "1"}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, `{"documentId":"c3d4","variables":{"id":"1"}}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+
+		if got, want := data.Viewer.ID, "1"; got != want {
+			t.Errorf("data.Viewer.ID = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Field", func(t *testing.T) {
+		docs, err := NewTrustedDocuments(map[string]string{"c3d4": "query Viewer { viewer { id } }"}, "doc_id")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := NewClient(ts.URL, WithTrustedDocuments(docs))
+
+		if err := c.QueryDocument(context.Background(), "c3d4", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, `{"doc_id":"c3d4","variables":null}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("UnknownDocument", func(t *testing.T) {
+		c := NewClient(ts.URL)
+
+		err := c.QueryDocument(context.Background(), "unknown", nil, nil)
+
+		var docErr *UnknownDocumentError
+		if !errors.As(err, &docErr) {
+			t.Fatalf("err = %v, want %T", err, docErr)
+		}
+
+		if got, want := docErr.ID, "unknown"; got != want {
+			t.Errorf("docErr.ID = %q, want %q", got, want)
+		}
+
+		if got, want := err.Error(), `unknown document "unknown": 200 OK: PersistedQueryNotFound`; got != want {
+			t.Errorf("err.Error() = %q, want %q", got, want)
+		}
+
+		var errResp *ErrorResponse
+		if !errors.As(err, &errResp) {
+			t.Errorf("err = %v, want %T", err, errResp)
+		}
+	})
+
+	t.Run("UnknownTrustedDocument", func(t *testing.T) {
+		docs, err := NewTrustedDocuments(map[string]string{"unknown": "query Viewer { viewer { id } }"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := NewClient(ts.URL, WithTrustedDocuments(docs))
+
+		err = c.Query(context.Background(), "query Viewer { viewer { id } }", nil, nil)
+
+		var docErr *UnknownDocumentError
+		if !errors.As(err, &docErr) {
+			t.Fatalf("err = %v, want %T", err, docErr)
+		}
+	})
+}
+
+func TestLoadApolloManifest(t *testing.T) {
+	manifest := `{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [
+			{"id": "a1b2", "name": "GetUser", "type": "query", "body": "query GetUser($id: ID!) { user(id: $id) { name } }"},
+			{"id": "c3d4", "name": "Viewer", "type": "query", "body": "query Viewer { viewer { id } }"}
+		]
+	}`
+
+	t.Run("Manifest", func(t *testing.T) {
+		docs, err := LoadApolloManifest(strings.NewReader(manifest), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		id, ok := docs.ID("query Viewer {\n  viewer {\n    id\n  }\n}")
+		if got, want := id, "c3d4"; !ok || got != want {
+			t.Errorf("ID = %q, %v, want %q, true", got, ok, want)
+		}
+
+		id, ok = docs.OperationID("GetUser")
+		if got, want := id, "a1b2"; !ok || got != want {
+			t.Errorf("OperationID = %q, %v, want %q, true", got, ok, want)
+		}
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		_, err := LoadApolloManifest(strings.NewReader(`{"format":"other","version":1}`), "")
+
+		if got, want := fmt.Sprint(err), `unsupported manifest format "other", version 1`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestQueryOperation(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	docs, err := LoadRelayQueryMap(strings.NewReader(`{"a1b2": "query GetUser($id: ID!) { user(id: $id) { name } }"}`), "doc_id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := NewClient(ts.URL, WithTrustedDocuments(docs))
+
+	t.Run("Operation", func(t *testing.T) {
+		if err := c.QueryOperation(context.Background(), "GetUser", map[string]interface{}{"id": "1"}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, `{"doc_id":"a1b2","variables":{"id":"1"}}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("UnknownOperation", func(t *testing.T) {
+		err := c.QueryOperation(context.Background(), "Other", nil, nil)
+
+		if got, want := fmt.Sprint(err), `unknown operation "Other"`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NoDocuments", func(t *testing.T) {
+		err := NewClient(ts.URL).QueryOperation(context.Background(), "GetUser", nil, nil)
+
+		if got, want := fmt.Sprint(err), `unknown operation "GetUser": no trusted documents`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package graphqlclient
+
+import "context"
+
+// WithExtensions makes the client send extensions in the "extensions" key of
+// the request object of all requests, e.g. for features of a gateway such as
+// query plans or client hints. See ContextWithExtensions for extensions of
+// single requests.
+func WithExtensions(extensions map[string]interface{}) Option {
+	return func(c *Client) {
+		if c.extensions == nil {
+			c.extensions = make(map[string]interface{}, len(extensions))
+		}
+
+		for k, v := range extensions {
+			c.extensions[k] = v
+		}
+	}
+}
+
+type extensionsKey struct{}
+
+// ContextWithExtensions returns a copy of ctx carrying extensions, which
+// clients send in the "extensions" key of the request object of requests
+// made with the context, in addition to those of WithExtensions. Extensions
+// carried by ctx take precedence over those of the client with the same key,
+// and over those carried by its parents.
+func ContextWithExtensions(ctx context.Context, extensions map[string]interface{}) context.Context {
+	if parent, ok := ctx.Value(extensionsKey{}).(map[string]interface{}); ok {
+		extensions = mergeExtensions(parent, extensions)
+	}
+
+	return context.WithValue(ctx, extensionsKey{}, extensions)
+}
+
+// requestExtensions returns the extensions of a request made with ctx.
+func (c *Client) requestExtensions(ctx context.Context) map[string]interface{} {
+	extensions, _ := ctx.Value(extensionsKey{}).(map[string]interface{})
+
+	if len(c.extensions) == 0 {
+		return extensions
+	}
+
+	if len(extensions) == 0 {
+		return c.extensions
+	}
+
+	return mergeExtensions(c.extensions, extensions)
+}
+
+// mergeExtensions returns a copy of a with the extensions of b added,
+// replacing those of a with the same key.
+func mergeExtensions(a, b map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(a)+len(b))
+
+	for k, v := range a {
+		merged[k] = v
+	}
+
+	for k, v := range b {
+		merged[k] = v
+	}
+
+	return merged
+}
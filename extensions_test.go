@@ -0,0 +1,104 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithExtensions(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			// Streamed variables are followed by a newline.
+			gotBody = strings.Replace(string(b), "\n", "", -1)
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	docs, err := NewTrustedDocuments(map[string]string{"a1": "{ foo }"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hints := WithExtensions(map[string]interface{}{"hints": map[string]string{"b": "2", "a": "1"}})
+
+	ctx := ContextWithExtensions(context.Background(), map[string]interface{}{"trace": true, "hints": "ctx"})
+	ctx = ContextWithExtensions(ctx, map[string]interface{}{"plan": 1})
+
+	for _, tc := range []struct {
+		name  string
+		opts  []Option
+		ctx   context.Context
+		query string
+		want  string
+	}{
+		{
+			name:  "None",
+			ctx:   context.Background(),
+			query: "{ foo }",
+			want:  `{"query":"{ foo }","variables":{"v":1}}`,
+		},
+		{
+			name:  "Client",
+			opts:  []Option{hints},
+			ctx:   context.Background(),
+			query: "{ foo }",
+			want:  `{"extensions":{"hints":{"a":"1","b":"2"}},"query":"{ foo }","variables":{"v":1}}`,
+		},
+		{
+			name:  "Context",
+			opts:  []Option{hints},
+			ctx:   ctx,
+			query: "{ foo }",
+			want:  `{"extensions":{"hints":"ctx","plan":1,"trace":true},"query":"{ foo }","variables":{"v":1}}`,
+		},
+		{
+			name:  "Streaming",
+			opts:  []Option{hints, WithStreamingRequests()},
+			ctx:   context.Background(),
+			query: "{ foo }",
+			want:  `{"extensions":{"hints":{"a":"1","b":"2"}},"query":"{ foo }","variables":{"v":1}}`,
+		},
+		{
+			name:  "TrustedDocuments",
+			opts:  []Option{hints, WithTrustedDocuments(docs)},
+			ctx:   context.Background(),
+			query: "{ foo }",
+			want:  `{"extensions":{"hints":{"a":"1","b":"2"}},"documentId":"a1","variables":{"v":1}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(ts.URL, tc.opts...)
+
+			if err := c.Query(tc.ctx, tc.query, map[string]interface{}{"v": 1}, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := gotBody, tc.want; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		c := NewClient(ts.URL, WithExtensions(map[string]interface{}{"f": func() {}}))
+
+		err := c.Query(context.Background(), "{ foo }", nil, nil)
+
+		if err == nil || !strings.HasPrefix(err.Error(), "error encoding extensions: ") {
+			t.Errorf("err = %v, want error encoding extensions", err)
+		}
+	})
+}
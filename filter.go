@@ -0,0 +1,63 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// EventFilter reports whether a subscription event is to be handled, given
+// its undecoded data payload and its errors.
+type EventFilter func(data json.RawMessage, errs []Error) bool
+
+type eventFilterKey struct{}
+
+// ContextWithEventFilter returns a copy of ctx carrying f, with which
+// subscriptions made with the context drop the events f does not match
+// before they are buffered, decoded or passed to the handler, for servers
+// unable to filter events finely enough. Filters of parent contexts still
+// apply, so that events must match all of them.
+func ContextWithEventFilter(ctx context.Context, f EventFilter) context.Context {
+	if parent := eventFilter(ctx); parent != nil {
+		g := f
+		f = func(data json.RawMessage, errs []Error) bool {
+			return parent(data, errs) && g(data, errs)
+		}
+	}
+	return context.WithValue(ctx, eventFilterKey{}, f)
+}
+
+// eventFilter returns the filter of subscriptions made with ctx, if any.
+func eventFilter(ctx context.Context) EventFilter {
+	f, _ := ctx.Value(eventFilterKey{}).(EventFilter)
+	return f
+}
+
+// MatchEvent returns an EventFilter matching events with value at the
+// dot-separated path in their data payload, as for Result.Get, compared as
+// JSON. Only the objects and arrays along the path are decoded. Events with
+// errors are always matched, so that they are not silently dropped.
+func MatchEvent(path string, value interface{}) EventFilter {
+	var want interface{}
+	if b, err := json.Marshal(value); err == nil {
+		json.Unmarshal(b, &want)
+	}
+
+	return func(data json.RawMessage, errs []Error) bool {
+		if len(errs) > 0 {
+			return true
+		}
+
+		raw, err := rawAtPath(data, path)
+		if err != nil {
+			return false
+		}
+
+		var got interface{}
+		if err := json.Unmarshal(raw, &got); err != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestContextWithEventFilter(t *testing.T) {
+	transport := &scriptedTransport{connections: []scriptedConnection{{
+		events: []string{
+			`{"order":{"status":"NEW","shop":"a"}}`,
+			`{"order":{"status":"PAID","shop":"a"}}`,
+			`{"order":{"status":"PAID","shop":"b"}}`,
+			`{"order":null}`,
+		},
+	}}}
+
+	c := NewClient("http://example.com/graphql", WithSubscriptionTransport(transport))
+
+	ctx := ContextWithEventFilter(context.Background(), MatchEvent("order.status", "PAID"))
+	ctx = ContextWithEventFilter(ctx, func(data json.RawMessage, errs []Error) bool {
+		return !strings.Contains(string(data), `"shop":"b"`)
+	})
+
+	var events []string
+
+	err := c.Subscribe(ctx, "subscription { order { status shop } }", nil,
+		func(data json.RawMessage, errs []Error) error {
+			events = append(events, string(data))
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := strings.Join(events, ","), `{"order":{"status":"PAID","shop":"a"}}`; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestMatchEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		path  string
+		value interface{}
+		data  string
+		errs  []Error
+		want  bool
+	}{
+		{"String", "foo.bar", "x", `{"foo":{"bar":"x"}}`, nil, true},
+		{"Mismatch", "foo.bar", "x", `{"foo":{"bar":"y"}}`, nil, false},
+		{"Number", "foo.0", 1, `{"foo":[1.0,2]}`, nil, true},
+		{"Object", "foo", map[string]int{"a": 1}, `{"foo":{ "a" : 1 }}`, nil, true},
+		{"Missing", "foo.baz", "x", `{"foo":{"bar":"x"}}`, nil, false},
+		{"Null", "foo", "x", `null`, nil, false},
+		{"Errors", "foo", "x", `null`, []Error{{Message: "boom"}}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := MatchEvent(tc.path, tc.value)(json.RawMessage(tc.data), tc.errs), tc.want; got != want {
+				t.Errorf("MatchEvent(%q, %v)(%s) = %v, want %v", tc.path, tc.value, tc.data, got, want)
+			}
+		})
+	}
+}
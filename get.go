@@ -0,0 +1,102 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxGETURLLength is the length of the longest URL sent by clients created
+// with WithGETQueries, below the limit of most servers and CDNs.
+const maxGETURLLength = 8 << 10
+
+// WithGETQueries makes the client send queries as GET requests, as specified
+// by the GraphQL over HTTP specification, so that they can be cached by CDNs
+// and HTTP caches. The members of the request object are sent as URL query
+// parameters instead of in a body: "query", "operationName" and document IDs
+// as strings, "variables" and "extensions" encoded as JSON. The parameters
+// are sorted, and variables encoded canonically, so that the same query and
+// variables always produce the same URL.
+//
+// Documents containing any mutation, or that can't be parsed, are still sent
+// as POST requests, as are queries whose URL would be longer than 8 KiB.
+func WithGETQueries() Option {
+	return func(c *Client) {
+		c.getQueries = true
+	}
+}
+
+// newGETRequest returns a GET request with p encoded in the query of rawURL,
+// or nil if the URL would be longer than maxGETURLLength.
+func newGETRequest(ctx context.Context, rawURL string, p *payload) (*http.Request, error) {
+	params, err := p.params()
+	if err != nil {
+		return nil, err
+	}
+
+	u := withParams(rawURL, params)
+	if len(u) > maxGETURLLength {
+		return nil, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// params returns the members of the request object as URL query parameters:
+// strings as they are and other values encoded as JSON. Null members are left
+// out.
+func (p *payload) params() (url.Values, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := p.write(buf); err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &members); err != nil {
+		return nil, fmt.Errorf("error encoding request: %v", err)
+	}
+
+	params := make(url.Values, len(members))
+
+	for k, v := range members {
+		switch {
+		case bytes.Equal(v, []byte("null")):
+			continue
+		case len(v) > 0 && v[0] == '"':
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return nil, fmt.Errorf("error encoding request: %v", err)
+			}
+			params.Set(k, s)
+		default:
+			params.Set(k, string(v))
+		}
+	}
+
+	return params, nil
+}
+
+// withParams returns rawURL with params added to its query.
+func withParams(rawURL string, params url.Values) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+
+	return rawURL + sep + params.Encode()
+}
@@ -0,0 +1,110 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithGETQueries(t *testing.T) {
+	var (
+		gotMethod string
+		gotQuery  string
+		gotBody   string
+		gotType   string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotMethod = r.Method
+			gotQuery = r.URL.RawQuery
+			gotBody = string(b)
+			gotType = r.Header.Get("Content-Type")
+
+			w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL+"/graphql?key=abc", WithGETQueries(), WithExtensions(map[string]interface{}{"trace": true}))
+
+	t.Run("Query", func(t *testing.T) {
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		err := c.Query(context.Background(), "query GetFoo($id: ID!) { foo(id: $id) }", map[string]interface{}{"id": "1", "b": []int{1, 2}}, &data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotMethod, http.MethodGet; got != want {
+			t.Errorf("method = %q, want %q", got, want)
+		}
+
+		want := "key=abc&extensions=%7B%22trace%22%3Atrue%7D&query=query+GetFoo%28%24id%3A+ID%21%29+%7B+foo%28id%3A+%24id%29+%7D&variables=%7B%22b%22%3A%5B1%2C2%5D%2C%22id%22%3A%221%22%7D"
+		if got := gotQuery; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+
+		if got, want := gotBody, ""; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+
+		if got, want := gotType, ""; got != want {
+			t.Errorf("Content-Type = %q, want %q", got, want)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Mutation", func(t *testing.T) {
+		if err := c.Query(context.Background(), "mutation { deleteFoo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotMethod, http.MethodPost; got != want {
+			t.Errorf("method = %q, want %q", got, want)
+		}
+
+		if got, want := gotType, DefaultContentType; got != want {
+			t.Errorf("Content-Type = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("LongURL", func(t *testing.T) {
+		err := c.Query(context.Background(), "query { foo }", map[string]interface{}{"s": strings.Repeat("x", maxGETURLLength)}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotMethod, http.MethodPost; got != want {
+			t.Errorf("method = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("BuildRequest", func(t *testing.T) {
+		req, err := c.BuildRequest(context.Background(), "{ foo }", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := req.Method, http.MethodGet; got != want {
+			t.Errorf("req.Method = %q, want %q", got, want)
+		}
+
+		if got, want := req.URL.Query().Get("query"), "{ foo }"; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+	})
+}
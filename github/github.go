@@ -0,0 +1,107 @@
+// Package github provides conveniences for using graphqlclient with the
+// GitHub GraphQL API.
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Endpoint is the URL of the GitHub GraphQL API.
+const Endpoint = "https://api.github.com/graphql"
+
+// RateLimitFields selects the fields of RateLimit. Add it to queries to have
+// the rate limit status returned with the data:
+//
+//	query := `query { viewer { login } ` + github.RateLimitFields + ` }`
+const RateLimitFields = "rateLimit { limit cost remaining used resetAt nodeCount }"
+
+// RateLimit is the rate limit status of the GitHub GraphQL API, as selected
+// by RateLimitFields or reported in the X-RateLimit-* response headers.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Cost      int       `json:"cost"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	ResetAt   time.Time `json:"resetAt"`
+	NodeCount int       `json:"nodeCount"`
+}
+
+// RateLimitFromHeader returns the rate limit status reported in the
+// X-RateLimit-* headers of a response, and whether the headers were present.
+// The headers don't report Cost and NodeCount.
+func RateLimitFromHeader(h http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	rl := RateLimit{Remaining: remaining}
+
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	rl.Used, _ = strconv.Atoi(h.Get("X-RateLimit-Used"))
+
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.ResetAt = time.Unix(reset, 0).UTC()
+	}
+
+	return rl, true
+}
+
+// NewClient returns a client for the GitHub GraphQL API, authenticating with
+// token. Before sending a request, the client backs off as the remaining rate
+// limit gets low, as reported by the API in response headers, and waits for
+// the time given by Retry-After headers, which the API sends when secondary
+// rate limits are exceeded. See graphqlclient.RateLimiter.
+//
+// opts are applied after the options set up by NewClient. Replacing the HTTP
+// client with graphqlclient.WithHTTPClient disables backing off.
+func NewClient(token string, opts ...graphqlclient.Option) *graphqlclient.Client {
+	return newClient(Endpoint, token, opts...)
+}
+
+func newClient(url, token string, opts ...graphqlclient.Option) *graphqlclient.Client {
+	limiter := graphqlclient.NewRateLimiter(nil)
+
+	return graphqlclient.NewClient(url, append([]graphqlclient.Option{
+		graphqlclient.WithHTTPClient(&http.Client{
+			Transport: &throttledTransport{limiter: limiter},
+		}),
+		graphqlclient.WithRequestOptions(func(req *http.Request) {
+			req.Header.Set("Authorization", "bearer "+token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}),
+	}, opts...)...)
+}
+
+// WithPreviews sets the Accept header of requests to enable the given API
+// previews, e.g. "starfox" for application/vnd.github.starfox-preview+json.
+func WithPreviews(previews ...string) graphqlclient.Option {
+	accept := make([]string, len(previews))
+	for i, p := range previews {
+		accept[i] = "application/vnd.github." + p + "-preview+json"
+	}
+
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	})
+}
+
+// throttledTransport waits for its rate limiter before sending each request.
+type throttledTransport struct {
+	limiter *graphqlclient.RateLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.limiter.RoundTrip(req)
+}
@@ -0,0 +1,117 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	var (
+		requests  int
+		gotAuth   string
+		gotAccept string
+		resetAt   = time.Now().Add(time.Hour).Unix()
+		remaining = "4000"
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			gotAuth = r.Header.Get("Authorization")
+			gotAccept = r.Header.Get("Accept")
+
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", remaining)
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt, 10))
+			w.Write([]byte(`{"data":{"viewer":{"login":"foo"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := newClient(ts.URL, "foo-token")
+
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+
+	if err := c.Query(context.Background(), "query { viewer { login } }", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data.Viewer.Login, "foo"; got != want {
+		t.Errorf("data.Viewer.Login = %q, want %q", got, want)
+	}
+
+	if got, want := gotAuth, "bearer foo-token"; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+
+	if got, want := gotAccept, "application/vnd.github+json"; got != want {
+		t.Errorf("Accept = %q, want %q", got, want)
+	}
+
+	t.Run("BackOff", func(t *testing.T) {
+		remaining = "0"
+
+		// The first request learns that the rate limit is exhausted.
+		if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		requests = 0
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := c.Query(ctx, "foo-query", nil, nil)
+
+		if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+			t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		if got, want := requests, 0; got != want {
+			t.Errorf("requests = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Previews", func(t *testing.T) {
+		c := newClient(ts.URL, "foo-token", WithPreviews("starfox", "flash"))
+
+		if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotAccept, "application/vnd.github.starfox-preview+json, application/vnd.github.flash-preview+json"; got != want {
+			t.Errorf("Accept = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRateLimitFromHeader(t *testing.T) {
+	h := http.Header{}
+
+	if _, ok := RateLimitFromHeader(h); ok {
+		t.Error("ok = true, want false")
+	}
+
+	h.Set("X-RateLimit-Limit", "5000")
+	h.Set("X-RateLimit-Remaining", "4990")
+	h.Set("X-RateLimit-Used", "10")
+	h.Set("X-RateLimit-Reset", "1600000000")
+
+	rl, ok := RateLimitFromHeader(h)
+	if !ok {
+		t.Fatal("ok = false, want true")
+	}
+
+	if got, want := rl, (RateLimit{Limit: 5000, Remaining: 4990, Used: 10, ResetAt: time.Unix(1600000000, 0).UTC()}); got != want {
+		t.Errorf("rl = %+v, want %+v", got, want)
+	}
+}
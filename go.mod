@@ -1,3 +1,3 @@
 module github.com/TV4/graphqlclient-go
 
-go 1.16
+go 1.23
@@ -0,0 +1,47 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLContentType is the Content-Type of request bodies holding only a
+// query, sent by clients created with WithGraphQLBodies.
+const GraphQLContentType = "application/graphql"
+
+// WithGraphQLBodies makes the client send the query of requests as the body,
+// with the Content-Type header set to GraphQLContentType, instead of in a JSON
+// request object, for minimal servers only accepting such bodies. The other
+// members of the request object are sent as URL query parameters, as by
+// WithGETQueries: "variables" and "extensions" encoded as JSON, and
+// "operationName" and document IDs as strings. Request bodies are neither
+// streamed nor compressed. Queries sent as GET requests, see WithGETQueries,
+// have no body.
+func WithGraphQLBodies() Option {
+	return func(c *Client) {
+		c.graphqlBodies = true
+	}
+}
+
+// newGraphQLRequest returns a POST request with the query of p as its body
+// and its other members in the query of rawURL.
+func newGraphQLRequest(ctx context.Context, rawURL string, p *payload) (*http.Request, error) {
+	params, err := p.params()
+	if err != nil {
+		return nil, err
+	}
+
+	query := params.Get("query")
+	params.Del("query")
+
+	req, err := http.NewRequest(http.MethodPost, withParams(rawURL, params), strings.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", GraphQLContentType)
+
+	return req.WithContext(ctx), nil
+}
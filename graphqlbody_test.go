@@ -0,0 +1,75 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithGraphQLBodies(t *testing.T) {
+	var (
+		gotQuery string
+		gotBody  string
+		gotType  string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotQuery = r.URL.RawQuery
+			gotBody = string(b)
+			gotType = r.Header.Get("Content-Type")
+
+			w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithGraphQLBodies())
+
+	t.Run("Variables", func(t *testing.T) {
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := c.Query(context.Background(), "query GetFoo($id: ID!) { foo(id: $id) }", map[string]interface{}{"id": "1"}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, "query GetFoo($id: ID!) { foo(id: $id) }"; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+
+		if got, want := gotQuery, "variables=%7B%22id%22%3A%221%22%7D"; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+
+		if got, want := gotType, GraphQLContentType; got != want {
+			t.Errorf("Content-Type = %q, want %q", got, want)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NoVariables", func(t *testing.T) {
+		if err := c.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, "{ foo }"; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+
+		if got, want := gotQuery, ""; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+	})
+}
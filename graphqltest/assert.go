@@ -0,0 +1,173 @@
+package graphqltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ReadCall decodes the GraphQL request carried by r. The request body is
+// restored afterwards, so it can still be read by the caller.
+func ReadCall(r *http.Request) (Call, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return Call{}, fmt.Errorf("error reading request body: %v", err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return Call{}, fmt.Errorf("error decoding request body: %v", err)
+	}
+
+	call := Call{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+	}
+
+	if call.OperationName == "" {
+		call.OperationName = operationName(call.Query)
+	}
+
+	return call, nil
+}
+
+// AssertVariables reports an error on t unless the variables of the last call
+// in calls with the given operation name match want. An empty operationName
+// matches anonymous operations. want may be a map or a struct; both sides are
+// compared by their JSON representation, so a struct with JSON tags can be
+// used to describe the expected variables. Every differing value is listed
+// in the failure message.
+func AssertVariables(t testing.TB, calls []Call, operationName string, want interface{}) {
+	t.Helper()
+
+	var (
+		call  Call
+		found bool
+	)
+
+	for n := len(calls) - 1; n >= 0; n-- {
+		if calls[n].OperationName == operationName {
+			call, found = calls[n], true
+			break
+		}
+	}
+
+	if !found {
+		t.Errorf("no call to operation %q", operationName)
+		return
+	}
+
+	if diff := DiffVariables(call.Variables, want); diff != "" {
+		t.Errorf("variables of operation %q differ (-got +want):\n%s", operationName, diff)
+	}
+}
+
+// DiffVariables compares got and want by their JSON representation and
+// returns a human-readable description of the differences, or an empty
+// string if they are equal.
+func DiffVariables(got, want interface{}) string {
+	g, err := normalize(got)
+	if err != nil {
+		return fmt.Sprintf("error encoding got: %v", err)
+	}
+
+	w, err := normalize(want)
+	if err != nil {
+		return fmt.Sprintf("error encoding want: %v", err)
+	}
+
+	var lines []string
+	diff(&lines, "$", g, w)
+
+	return strings.Join(lines, "\n")
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+
+	// A nil map and an empty map both mean "no variables".
+	if m, ok := n.(map[string]interface{}); ok && len(m) == 0 {
+		n = nil
+	}
+
+	return n, nil
+}
+
+func diff(lines *[]string, path string, got, want interface{}) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(g)+len(w))
+		for k := range g {
+			keys = append(keys, k)
+		}
+		for k := range w {
+			if _, ok := g[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			gv, gok := g[k]
+			wv, wok := w[k]
+
+			switch {
+			case !gok:
+				*lines = append(*lines, fmt.Sprintf("+ %s.%s: %s", path, k, encode(wv)))
+			case !wok:
+				*lines = append(*lines, fmt.Sprintf("- %s.%s: %s", path, k, encode(gv)))
+			default:
+				diff(lines, path+"."+k, gv, wv)
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			break
+		}
+
+		for n := range w {
+			diff(lines, fmt.Sprintf("%s[%d]", path, n), g[n], w[n])
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		*lines = append(*lines,
+			fmt.Sprintf("- %s: %s", path, encode(got)),
+			fmt.Sprintf("+ %s: %s", path, encode(want)),
+		)
+	}
+}
+
+func encode(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,111 @@
+package graphqltest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestReadCall(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"query":"query GetFoo($id: ID!) { foo(id: $id) }","variables":{"id":"123"}}`,
+	))
+
+	call, err := ReadCall(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := call.OperationName, "GetFoo"; got != want {
+		t.Errorf("call.OperationName = %q, want %q", got, want)
+	}
+
+	if got, want := call.Variables["id"], "123"; got != want {
+		t.Errorf("call.Variables[\"id\"] = %q, want %q", got, want)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(body) == 0 {
+		t.Error("request body not restored")
+	}
+}
+
+func TestAssertVariables(t *testing.T) {
+	calls := []Call{
+		{OperationName: "GetFoo", Variables: map[string]interface{}{"id": "1"}},
+		{OperationName: "GetFoo", Variables: map[string]interface{}{
+			"id":    "2",
+			"limit": 10,
+			"input": map[string]interface{}{"tags": []interface{}{"a", "b"}},
+		}},
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		type input struct {
+			Tags []string `json:"tags"`
+		}
+
+		want := struct {
+			ID    string `json:"id"`
+			Limit int    `json:"limit"`
+			Input input  `json:"input"`
+		}{"2", 10, input{[]string{"a", "b"}}}
+
+		rt := &recordingTB{}
+		AssertVariables(rt, calls, "GetFoo", want)
+
+		if len(rt.errors) > 0 {
+			t.Errorf("unexpected errors: %q", rt.errors)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		rt := &recordingTB{}
+		AssertVariables(rt, calls, "GetFoo", map[string]interface{}{
+			"id":    "2",
+			"input": map[string]interface{}{"tags": []interface{}{"a", "c"}},
+			"extra": true,
+		})
+
+		if got, want := len(rt.errors), 1; got != want {
+			t.Fatalf("len(errors) = %d, want %d", got, want)
+		}
+
+		for _, want := range []string{
+			`- $.input.tags[1]: "b"`,
+			`+ $.input.tags[1]: "c"`,
+			`+ $.extra: true`,
+			`- $.limit: 10`,
+		} {
+			if !strings.Contains(rt.errors[0], want) {
+				t.Errorf("error %q does not contain %q", rt.errors[0], want)
+			}
+		}
+	})
+
+	t.Run("UnknownOperation", func(t *testing.T) {
+		rt := &recordingTB{}
+		AssertVariables(rt, calls, "GetBar", nil)
+
+		if got, want := len(rt.errors), 1; got != want {
+			t.Fatalf("len(errors) = %d, want %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package graphqltest
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// ValidateOperations validates the operations in documents against the schema
+// given in SDL. documents maps a name, such as a file path or the name of a
+// Go constant, to the text of a GraphQL document; the name is used in error
+// messages. Fragments defined in one document may be used by operations in
+// any other. All problems found are returned, ordered by document and
+// position.
+//
+// Validation covers the rules that catch an operation drifting from the
+// schema: unknown types, fields, arguments, fragments and directives, missing
+// required arguments, leaf and composite fields selected incorrectly,
+// undefined and unused variables and unsupported operation types.
+func ValidateOperations(schemaSDL string, documents map[string]string) []error {
+	schema, err := graphql.ParseSchema(schemaSDL)
+	if err != nil {
+		return []error{fmt.Errorf("error parsing schema: %v", err)}
+	}
+
+	names := make([]string, 0, len(documents))
+	for name := range documents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		errs      []error
+		docs      []*graphql.Document
+		fragments []*graphql.Fragment
+	)
+
+	for _, name := range names {
+		doc, err := graphql.ParseQuerySource(name, documents[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		docs = append(docs, doc)
+		fragments = append(fragments, doc.Fragments...)
+	}
+
+	var validationErrs []*graphql.Error
+
+	for _, doc := range docs {
+		for _, err := range graphql.Validate(schema, &graphql.Document{
+			Operations: doc.Operations,
+			Fragments:  fragments,
+		}) {
+			validationErrs = append(validationErrs, err.(*graphql.Error))
+		}
+	}
+
+	// Problems with fragments are found once for every document.
+	sort.SliceStable(validationErrs, func(i, j int) bool {
+		return validationErrs[i].Pos.Less(validationErrs[j].Pos)
+	})
+
+	for n, err := range validationErrs {
+		if n > 0 && *err == *validationErrs[n-1] {
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// AssertValidOperations reads the schema snapshot at schemaPath, typically a
+// file in testdata, and reports an error on t for every problem found by
+// ValidateOperations. Run it in a test to fail the build when the operations
+// used by a client and the schema of its server diverge.
+func AssertValidOperations(t testing.TB, schemaPath string, documents map[string]string) {
+	t.Helper()
+
+	schemaSDL, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("error reading schema: %v", err)
+	}
+
+	for _, err := range ValidateOperations(string(schemaSDL), documents) {
+		t.Errorf("%v", err)
+	}
+}
+
+// ValidateClientOperations validates the operations registered with c, see
+// Client.Operations, against the schema given in SDL, as ValidateOperations
+// does.
+func ValidateClientOperations(schemaSDL string, c *graphqlclient.Client) []error {
+	return ValidateOperations(schemaSDL, c.Operations())
+}
+
+// AssertValidClientOperations reads the schema snapshot at schemaPath and
+// reports an error on t for every problem found by ValidateClientOperations,
+// as AssertValidOperations does. Run it once the operations of c have been
+// prepared, e.g. by the package initialization of the code using c.
+func AssertValidClientOperations(t testing.TB, schemaPath string, c *graphqlclient.Client) {
+	t.Helper()
+
+	AssertValidOperations(t, schemaPath, c.Operations())
+}
+
+// ReadDocuments reads the files in fsys matching any of the glob patterns,
+// e.g. "queries/*.graphql" in an embed.FS, and returns their contents keyed
+// by path, ready to be passed to ValidateOperations.
+func ReadDocuments(fsys fs.FS, patterns ...string) (map[string]string, error) {
+	documents := map[string]string{}
+
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range matches {
+			b, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, err
+			}
+			documents[name] = string(b)
+		}
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,94 @@
+package graphqltest
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestAssertValidOperations(t *testing.T) {
+	documents, err := ReadDocuments(os.DirFS("testdata"), "queries/*.graphql")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := len(documents), 2; got != want {
+		t.Fatalf("len(documents) = %d, want %d", got, want)
+	}
+
+	documents["inline"] = `query GetName($id: ID!) { user(id: $id) { name } }`
+
+	AssertValidOperations(t, "testdata/schema.graphql", documents)
+}
+
+func TestValidateOperations(t *testing.T) {
+	schemaSDL, err := ioutil.ReadFile("testdata/schema.graphql")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	errs := ValidateOperations(string(schemaSDL), map[string]string{
+		"a.graphql": `query A($id: ID!) { user(id: $id) { ...F age } }`,
+		"b.graphql": `query B { user(id: 1) { ...F } }`,
+		"c.graphql": `fragment F on User { id email }`,
+		"d.graphql": `query D {`,
+	})
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+
+	want := []string{
+		`d.graphql:1:10: unexpected end of document`,
+		`a.graphql:1:42: cannot query field "age" on type "User"`,
+		`c.graphql:1:25: cannot query field "email" on type "User"`,
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateClientOperations(t *testing.T) {
+	schemaSDL, err := ioutil.ReadFile("testdata/schema.graphql")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs, err := graphqlclient.NewTrustedDocuments(map[string]string{
+		"1": `query GetEmail { user(id: 1) { email } }`,
+	}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := graphqlclient.NewClient("", graphqlclient.WithTrustedDocuments(docs))
+
+	for _, query := range []string{
+		`query GetName($id: ID!) { user(id: $id) { name } }`,
+		`query GetAge { user(id: 1) { age } }`,
+		`query GetEmail { user(id: 1) { email } }`,
+	} {
+		if _, err := c.Prepare(query); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var got []string
+	for _, err := range ValidateClientOperations(string(schemaSDL), c) {
+		got = append(got, err.Error())
+	}
+
+	want := []string{
+		`document 1:1:27: cannot query field "email" on type "User"`,
+		`prepared GetAge:1:30: cannot query field "age" on type "User"`,
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,168 @@
+// Package graphqltest provides utilities for testing code that uses
+// graphqlclient.
+package graphqltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// ErrNoResponse is returned by FakeClient.Query when there are no scripted
+// responses left in its queue.
+var ErrNoResponse = errors.New("graphqltest: no scripted response left")
+
+// Call records the arguments of one call to FakeClient.Query.
+type Call struct {
+	Query         string
+	OperationName string
+
+	// Variables is a shallow copy of the variables passed to Query, so
+	// that callers reusing their map, as paginators do, don't change it.
+	Variables map[string]interface{}
+}
+
+// Response is a scripted response returned by FakeClient. If Err is set it is
+// returned as is. Otherwise, if Errors is non-empty, a
+// *graphqlclient.ErrorResponse carrying them is returned. Otherwise Data is
+// encoded as JSON and decoded into the caller's data argument, just like the
+// "data" field of a real response would be. Data may be a json.RawMessage.
+type Response struct {
+	Data   interface{}
+	Errors []graphqlclient.Error
+	Err    error
+}
+
+// FakeClient is a graphqlclient.Querier that returns scripted responses in
+// the order they were queued and records every call made to it. The zero
+// value is ready to use. It is safe for concurrent use.
+type FakeClient struct {
+	mu        sync.Mutex
+	responses []Response
+	calls     []Call
+}
+
+var _ graphqlclient.Querier = (*FakeClient)(nil)
+
+// Push appends responses to the queue.
+func (f *FakeClient) Push(responses ...Response) *FakeClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses = append(f.responses, responses...)
+
+	return f
+}
+
+// RespondData queues a response with the given data.
+func (f *FakeClient) RespondData(data interface{}) *FakeClient {
+	return f.Push(Response{Data: data})
+}
+
+// RespondJSON queues a response whose data is the given JSON document.
+func (f *FakeClient) RespondJSON(data string) *FakeClient {
+	return f.Push(Response{Data: json.RawMessage(data)})
+}
+
+// RespondErrors queues a response containing the given GraphQL errors.
+func (f *FakeClient) RespondErrors(errs ...graphqlclient.Error) *FakeClient {
+	return f.Push(Response{Errors: errs})
+}
+
+// RespondErr queues an error, e.g. to simulate a transport failure.
+func (f *FakeClient) RespondErr(err error) *FakeClient {
+	return f.Push(Response{Err: err})
+}
+
+// Query records the call and returns the next scripted response. If the
+// queue is empty, ErrNoResponse is returned. reqOpts are ignored.
+func (f *FakeClient) Query(ctx context.Context, query string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{
+		Query:         query,
+		OperationName: operationName(query),
+		Variables:     copyVariables(variables),
+	})
+
+	if len(f.responses) == 0 {
+		f.mu.Unlock()
+		return ErrNoResponse
+	}
+
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if resp.Err != nil {
+		return resp.Err
+	}
+
+	if len(resp.Errors) > 0 {
+		return &graphqlclient.ErrorResponse{
+			StatusCode: http.StatusOK,
+			Errors:     resp.Errors,
+		}
+	}
+
+	b, err := json.Marshal(resp.Data)
+	if err != nil {
+		return fmt.Errorf("error encoding scripted data: %v", err)
+	}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return fmt.Errorf("error decoding data payload: %v", err)
+	}
+
+	return nil
+}
+
+// Calls returns the calls made so far, in order.
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// Pending returns the number of scripted responses not yet consumed.
+func (f *FakeClient) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.responses)
+}
+
+var operationNameRE = regexp.MustCompile(`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// operationName returns the name of the first operation in query, or an
+// empty string if it is anonymous.
+func operationName(query string) string {
+	if m := operationNameRE.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// copyVariables returns a shallow copy of variables, nil if it is nil.
+func copyVariables(variables map[string]interface{}) map[string]interface{} {
+	if variables == nil {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		c[k] = v
+	}
+
+	return c
+}
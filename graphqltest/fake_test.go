@@ -0,0 +1,117 @@
+package graphqltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestFakeClient(t *testing.T) {
+	t.Run("ScriptedResponses", func(t *testing.T) {
+		errTransport := errors.New("transport error")
+
+		f := &FakeClient{}
+		f.RespondJSON(`{"foo":"bar"}`).
+			RespondErrors(graphqlclient.Error{Message: "error-msg"}).
+			RespondErr(errTransport)
+
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := f.Query(context.Background(), "query GetFoo { foo }", map[string]interface{}{"id": 1}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+
+		err := f.Query(context.Background(), "{ foo }", nil, &data)
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &graphqlclient.ErrorResponse{})
+		}
+
+		if got, want := errResp.Errors[0].Message, "error-msg"; got != want {
+			t.Errorf("errResp.Errors[0].Message = %q, want %q", got, want)
+		}
+
+		if err := f.Query(context.Background(), "{ foo }", nil, &data); err != errTransport {
+			t.Errorf("err = %v, want %v", err, errTransport)
+		}
+
+		if err := f.Query(context.Background(), "{ foo }", nil, &data); err != ErrNoResponse {
+			t.Errorf("err = %v, want %v", err, ErrNoResponse)
+		}
+
+		if got, want := f.Pending(), 0; got != want {
+			t.Errorf("f.Pending() = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("RecordedCalls", func(t *testing.T) {
+		f := &FakeClient{}
+		f.RespondData(map[string]string{"foo": "bar"})
+
+		var data interface{}
+
+		if err := f.Query(context.Background(), "query GetFoo($id: ID!) { foo(id: $id) }", map[string]interface{}{"id": "123"}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		calls := f.Calls()
+
+		if got, want := len(calls), 1; got != want {
+			t.Fatalf("len(calls) = %d, want %d", got, want)
+		}
+
+		if got, want := calls[0].OperationName, "GetFoo"; got != want {
+			t.Errorf("calls[0].OperationName = %q, want %q", got, want)
+		}
+
+		if got, want := calls[0].Variables["id"], "123"; got != want {
+			t.Errorf("calls[0].Variables[\"id\"] = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ReusedVariables", func(t *testing.T) {
+		f := &FakeClient{}
+		f.RespondJSON(`{}`).RespondJSON(`{}`)
+
+		variables := map[string]interface{}{"after": "a"}
+
+		if err := f.Query(context.Background(), "{ foo }", variables, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		variables["after"] = "b"
+
+		if err := f.Query(context.Background(), "{ foo }", variables, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		calls := f.Calls()
+
+		for i, want := range []string{"a", "b"} {
+			if got := calls[i].Variables["after"]; got != want {
+				t.Errorf("calls[%d].Variables[\"after\"] = %q, want %q", i, got, want)
+			}
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		f := &FakeClient{}
+		f.RespondJSON(`{}`)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := f.Query(ctx, "{ foo }", nil, nil); err != context.Canceled {
+			t.Errorf("err = %v, want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package graphqltest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultTransport is an http.RoundTripper that wraps another RoundTripper and
+// injects failures at the given probabilities (0 to 1), so retry, caching and
+// timeout configuration can be exercised under adverse conditions. Each kind
+// of fault is decided independently, in the order the fields are declared.
+// Set the fields before use; a FaultTransport must not be modified while in
+// use.
+type FaultTransport struct {
+	// Transport is the underlying transport. If nil, http.DefaultTransport
+	// is used.
+	Transport http.RoundTripper
+
+	// Latency is added before the request is sent, with probability
+	// LatencyProbability. The delay is cut short if the request's context
+	// is done.
+	Latency            time.Duration
+	LatencyProbability float64
+
+	// ResetProbability is the probability that the request fails with a
+	// connection reset error without reaching the server.
+	ResetProbability float64
+
+	// TooManyRequestsProbability is the probability that a 429 response is
+	// returned without reaching the server. RetryAfter, if non-zero, is
+	// sent in its Retry-After header.
+	TooManyRequestsProbability float64
+	RetryAfter                 time.Duration
+
+	// MalformedJSONProbability is the probability that the body of the
+	// server's response is replaced by invalid JSON.
+	MalformedJSONProbability float64
+
+	// TruncateProbability is the probability that the body of the server's
+	// response is cut off halfway, ending in io.ErrUnexpectedEOF.
+	TruncateProbability float64
+
+	// Rand is the source of randomness. If nil, a source seeded with the
+	// current time is used. Set it to get reproducible faults.
+	Rand *rand.Rand
+
+	mu   sync.Mutex
+	once sync.Once
+}
+
+// ErrConnectionReset is the error injected by FaultTransport to simulate a
+// connection reset. It wraps syscall.ECONNRESET.
+var ErrConnectionReset = fmt.Errorf("graphqltest: injected fault: %w", syscall.ECONNRESET)
+
+// RoundTrip implements http.RoundTripper.
+func (f *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.hit(f.LatencyProbability) {
+		t := time.NewTimer(f.Latency)
+		select {
+		case <-t.C:
+		case <-req.Context().Done():
+			t.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+
+	if f.hit(f.ResetProbability) {
+		closeBody(req)
+		return nil, ErrConnectionReset
+	}
+
+	if f.hit(f.TooManyRequestsProbability) {
+		closeBody(req)
+
+		header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+		if f.RetryAfter > 0 {
+			header.Set("Retry-After", strconv.Itoa(int(f.RetryAfter.Seconds())))
+		}
+
+		body := http.StatusText(http.StatusTooManyRequests)
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", http.StatusTooManyRequests, body),
+			StatusCode:    http.StatusTooManyRequests,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	transport := f.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case f.hit(f.MalformedJSONProbability):
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"data":{`)))
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+	case f.hit(f.TruncateProbability):
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(&truncatedReader{r: bytes.NewReader(body[:len(body)/2])})
+		resp.ContentLength = -1
+		resp.Header.Del("Content-Length")
+	}
+
+	return resp, nil
+}
+
+// closeBody closes the body of req, as RoundTrip must, even when it is not
+// sent.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+func (f *FaultTransport) hit(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+
+	f.once.Do(func() {
+		if f.Rand == nil {
+			f.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.Rand.Float64() < p
+}
+
+type truncatedReader struct {
+	r io.Reader
+}
+
+func (t *truncatedReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,117 @@
+package graphqltest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestFaultTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		},
+	))
+	defer ts.Close()
+
+	query := func(ft *FaultTransport) error {
+		c := graphqlclient.New(ts.URL, &http.Client{Transport: ft})
+
+		var data interface{}
+		return c.Query(context.Background(), "{ foo }", nil, &data)
+	}
+
+	t.Run("NoFaults", func(t *testing.T) {
+		if err := query(&FaultTransport{}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		start := time.Now()
+
+		if err := query(&FaultTransport{Latency: 20 * time.Millisecond, LatencyProbability: 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, min := time.Since(start), 20*time.Millisecond; got < min {
+			t.Errorf("elapsed = %v, want at least %v", got, min)
+		}
+	})
+
+	t.Run("ConnectionReset", func(t *testing.T) {
+		err := query(&FaultTransport{ResetProbability: 1})
+
+		if err == nil || !strings.Contains(err.Error(), syscall.ECONNRESET.Error()) {
+			t.Errorf("err = %v, want %v", err, syscall.ECONNRESET)
+		}
+	})
+
+	t.Run("TooManyRequests", func(t *testing.T) {
+		err := query(&FaultTransport{TooManyRequestsProbability: 1})
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &graphqlclient.ErrorResponse{})
+		}
+
+		if got, want := errResp.StatusCode, http.StatusTooManyRequests; got != want {
+			t.Errorf("errResp.StatusCode = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("ClosesBody", func(t *testing.T) {
+		for name, ft := range map[string]*FaultTransport{
+			"ConnectionReset": {ResetProbability: 1},
+			"TooManyRequests": {TooManyRequestsProbability: 1},
+		} {
+			body := &closeRecorder{Reader: strings.NewReader(`{"query":"{ foo }"}`)}
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL, body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp, err := ft.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+
+			if !body.closed {
+				t.Errorf("%s: request body not closed", name)
+			}
+		}
+	})
+
+	t.Run("MalformedJSON", func(t *testing.T) {
+		err := query(&FaultTransport{MalformedJSONProbability: 1})
+
+		if err == nil || !strings.HasPrefix(err.Error(), "error decoding response") {
+			t.Errorf("err = %v, want decoding error", err)
+		}
+	})
+
+	t.Run("TruncatedBody", func(t *testing.T) {
+		err := query(&FaultTransport{TruncateProbability: 1})
+
+		if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+			t.Errorf("err = %v, want unexpected EOF", err)
+		}
+	})
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
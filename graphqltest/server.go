@@ -0,0 +1,124 @@
+package graphqltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Server is an in-memory GraphQL server for tests. It records every request
+// it receives as a Call before passing it on to its handler.
+type Server struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewServer starts and returns a new Server serving requests with h. The
+// caller should call Close when finished, to shut it down.
+func NewServer(h http.Handler) *Server {
+	s := &Server{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if call, err := ReadCall(r); err == nil {
+				s.mu.Lock()
+				s.calls = append(s.calls, call)
+				s.mu.Unlock()
+			}
+
+			h.ServeHTTP(w, r)
+		},
+	))
+
+	return s
+}
+
+// NewDataServer starts a Server that responds to every request with data.
+func NewDataServer(data interface{}) *Server {
+	return NewServer(DataHandler(data))
+}
+
+// NewErrorServer starts a Server that responds to every request with the given
+// status code and errors.
+func NewErrorServer(statusCode int, errs ...graphqlclient.Error) *Server {
+	return NewServer(ErrorHandler(statusCode, errs...))
+}
+
+// NewPartialDataServer starts a Server that responds to every request with
+// both data and errors.
+func NewPartialDataServer(data interface{}, errs ...graphqlclient.Error) *Server {
+	return NewServer(PartialDataHandler(data, errs...))
+}
+
+// NewRawServer starts a Server that responds to every request with the given
+// status code, content type and body, e.g. to simulate a proxy error page.
+func NewRawServer(statusCode int, contentType, body string) *Server {
+	return NewServer(RawHandler(statusCode, contentType, body))
+}
+
+// Calls returns the requests received so far, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+// DataHandler returns a handler responding with 200 OK and the given data.
+// data may be a json.RawMessage.
+func DataHandler(data interface{}) http.Handler {
+	return responseHandler(http.StatusOK, data, nil)
+}
+
+// ErrorHandler returns a handler responding with the given status code and an
+// "errors" array, without any data.
+func ErrorHandler(statusCode int, errs ...graphqlclient.Error) http.Handler {
+	return responseHandler(statusCode, nil, errs)
+}
+
+// PartialDataHandler returns a handler responding with 200 OK, data and an
+// "errors" array, as servers do when some fields could not be resolved.
+func PartialDataHandler(data interface{}, errs ...graphqlclient.Error) http.Handler {
+	return responseHandler(http.StatusOK, data, errs)
+}
+
+// RawHandler returns a handler responding with the given status code, content
+// type and body, as is.
+func RawHandler(statusCode int, contentType, body string) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.WriteHeader(statusCode)
+			w.Write([]byte(body))
+		},
+	)
+}
+
+func responseHandler(statusCode int, data interface{}, errs []graphqlclient.Error) http.Handler {
+	response := struct {
+		Data   interface{}           `json:"data,omitempty"`
+		Errors []graphqlclient.Error `json:"errors,omitempty"`
+	}{data, errs}
+
+	body, err := json.Marshal(response)
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err != nil {
+				http.Error(w, "graphqltest: error encoding response: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			w.Write(body)
+		},
+	)
+}
@@ -0,0 +1,89 @@
+package graphqltest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestServer(t *testing.T) {
+	t.Run("Data", func(t *testing.T) {
+		ts := NewDataServer(map[string]string{"foo": "bar"})
+		defer ts.Close()
+
+		c := graphqlclient.New(ts.URL, ts.Client())
+
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := c.Query(context.Background(), "query GetFoo { foo }", map[string]interface{}{"id": "1"}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+
+		AssertVariables(t, ts.Calls(), "GetFoo", map[string]interface{}{"id": "1"})
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ts := NewErrorServer(http.StatusBadRequest, graphqlclient.Error{Message: "error-msg"})
+		defer ts.Close()
+
+		err := graphqlclient.New(ts.URL, ts.Client()).Query(context.Background(), "{ foo }", nil, nil)
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &graphqlclient.ErrorResponse{})
+		}
+
+		if got, want := errResp.StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("errResp.StatusCode = %d, want %d", got, want)
+		}
+
+		if got, want := errResp.Errors[0].Message, "error-msg"; got != want {
+			t.Errorf("errResp.Errors[0].Message = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("PartialData", func(t *testing.T) {
+		ts := NewPartialDataServer(map[string]interface{}{"foo": nil}, graphqlclient.Error{Message: "error-msg"})
+		defer ts.Close()
+
+		err := graphqlclient.New(ts.URL, ts.Client()).Query(context.Background(), "{ foo }", nil, nil)
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &graphqlclient.ErrorResponse{})
+		}
+
+		if got, want := errResp.StatusCode, http.StatusOK; got != want {
+			t.Errorf("errResp.StatusCode = %d, want %d", got, want)
+		}
+
+		if got, want := errResp.Body, []byte(`{"data":{"foo":null},"errors":[{"message":"error-msg"}]}`); !bytes.Equal(got, want) {
+			t.Errorf("errResp.Body = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		ts := NewRawServer(http.StatusBadGateway, "text/html", "<html>bad gateway</html>")
+		defer ts.Close()
+
+		err := graphqlclient.New(ts.URL, ts.Client()).Query(context.Background(), "{ foo }", nil, nil)
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &graphqlclient.ErrorResponse{})
+		}
+
+		if got, want := errResp.Body, []byte("<html>bad gateway</html>"); !bytes.Equal(got, want) {
+			t.Errorf("errResp.Body = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,336 @@
+package graphqltest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+// GraphQLTransportWS is the WebSocket subprotocol spoken by
+// SubscriptionServer, see
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+const GraphQLTransportWS = "graphql-transport-ws"
+
+type eventKind int
+
+const (
+	eventNext eventKind = iota
+	eventError
+	eventComplete
+	eventDisconnect
+	eventPause
+)
+
+// Event is one step in the script played by SubscriptionServer for a
+// subscription.
+type Event struct {
+	kind  eventKind
+	data  interface{}
+	errs  []graphqlclient.Error
+	delay time.Duration
+}
+
+// Next returns an event sending a "next" message with the given data. data
+// may be a json.RawMessage.
+func Next(data interface{}) Event {
+	return Event{kind: eventNext, data: data}
+}
+
+// NextWithErrors returns an event sending a "next" message with both data and
+// errors.
+func NextWithErrors(data interface{}, errs ...graphqlclient.Error) Event {
+	return Event{kind: eventNext, data: data, errs: errs}
+}
+
+// Error returns an event sending an "error" message, which terminates the
+// subscription.
+func Error(errs ...graphqlclient.Error) Event {
+	return Event{kind: eventError, errs: errs}
+}
+
+// Complete returns an event sending a "complete" message, which terminates
+// the subscription.
+func Complete() Event {
+	return Event{kind: eventComplete}
+}
+
+// Disconnect returns an event closing the connection abruptly, without a
+// close frame, to simulate a network failure.
+func Disconnect() Event {
+	return Event{kind: eventDisconnect}
+}
+
+// Pause returns an event waiting for d before the next event is played.
+func Pause(d time.Duration) Event {
+	return Event{kind: eventPause, delay: d}
+}
+
+// SubscriptionServer is an in-memory GraphQL server speaking the
+// graphql-transport-ws protocol. Every subscription it receives is recorded
+// and answered by playing the events scripted for its operation name.
+// Subscriptions whose script does not terminate them stay open until the
+// client completes them or the connection closes.
+type SubscriptionServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	scripts      map[string][]Event
+	calls        []Call
+	initPayloads []map[string]interface{}
+	conns        map[*websocket.Conn]struct{}
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// NewSubscriptionServer starts and returns a new SubscriptionServer. The
+// caller should call Close when finished, to shut it down.
+func NewSubscriptionServer() *SubscriptionServer {
+	s := &SubscriptionServer{
+		scripts: map[string][]Event{},
+		conns:   map[*websocket.Conn]struct{}{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveWS))
+
+	return s
+}
+
+// WSURL returns the ws:// URL of the server.
+func (s *SubscriptionServer) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// Script sets the events played for subscriptions to the named operation. The
+// script for the empty operation name is used for operations without a script
+// of their own.
+func (s *SubscriptionServer) Script(operationName string, events ...Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[operationName] = events
+}
+
+// Subscriptions returns the subscriptions received so far, in order.
+func (s *SubscriptionServer) Subscriptions() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+// InitPayloads returns the payloads of the connection_init messages received
+// so far, in order.
+func (s *SubscriptionServer) InitPayloads() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]map[string]interface{}(nil), s.initPayloads...)
+}
+
+// DisconnectAll abruptly closes all open connections.
+func (s *SubscriptionServer) DisconnectAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close closes all open connections and shuts down the server.
+func (s *SubscriptionServer) Close() {
+	s.DisconnectAll()
+	s.Server.Close()
+}
+
+func (s *SubscriptionServer) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r, []string{GraphQLTransportWS})
+	if err != nil {
+		return
+	}
+
+	if conn.Subprotocol() != GraphQLTransportWS {
+		conn.WriteClose(4406, "Subprotocol not acceptable")
+		conn.Close()
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		wg       sync.WaitGroup
+		activeMu sync.Mutex
+		active   = map[string]context.CancelFunc{}
+		acked    bool
+	)
+
+	defer func() {
+		cancel()
+		wg.Wait()
+		conn.Close()
+
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	for {
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(p, &msg); err != nil {
+			conn.WriteClose(4400, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			if acked {
+				conn.WriteClose(4429, "Too many initialisation requests")
+				return
+			}
+
+			var payload map[string]interface{}
+			json.Unmarshal(msg.Payload, &payload)
+
+			s.mu.Lock()
+			s.initPayloads = append(s.initPayloads, payload)
+			s.mu.Unlock()
+
+			acked = true
+			s.write(conn, wsMessage{Type: "connection_ack"})
+		case "ping":
+			s.write(conn, wsMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acked {
+				conn.WriteClose(4401, "Unauthorized")
+				return
+			}
+
+			var payload struct {
+				Query         string                 `json:"query"`
+				OperationName string                 `json:"operationName"`
+				Variables     map[string]interface{} `json:"variables"`
+			}
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				conn.WriteClose(4400, "Invalid message received")
+				return
+			}
+
+			call := Call{
+				Query:         payload.Query,
+				OperationName: payload.OperationName,
+				Variables:     payload.Variables,
+			}
+			if call.OperationName == "" {
+				call.OperationName = operationName(call.Query)
+			}
+
+			activeMu.Lock()
+			_, exists := active[msg.ID]
+			activeMu.Unlock()
+
+			if exists {
+				conn.WriteClose(4409, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+
+			s.mu.Lock()
+			s.calls = append(s.calls, call)
+			events, ok := s.scripts[call.OperationName]
+			if !ok {
+				events = s.scripts[""]
+			}
+			s.mu.Unlock()
+
+			subCtx, subCancel := context.WithCancel(ctx)
+
+			activeMu.Lock()
+			active[msg.ID] = subCancel
+			activeMu.Unlock()
+
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				defer func() {
+					activeMu.Lock()
+					delete(active, id)
+					activeMu.Unlock()
+					subCancel()
+				}()
+
+				s.play(subCtx, conn, id, events)
+			}(msg.ID)
+		case "complete":
+			activeMu.Lock()
+			if subCancel, ok := active[msg.ID]; ok {
+				subCancel()
+			}
+			activeMu.Unlock()
+		default:
+			conn.WriteClose(4400, "Invalid message received")
+			return
+		}
+	}
+}
+
+func (s *SubscriptionServer) play(ctx context.Context, conn *websocket.Conn, id string, events []Event) {
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch e.kind {
+		case eventNext:
+			payload, _ := json.Marshal(struct {
+				Data   interface{}           `json:"data,omitempty"`
+				Errors []graphqlclient.Error `json:"errors,omitempty"`
+			}{e.data, e.errs})
+			s.write(conn, wsMessage{ID: id, Type: "next", Payload: payload})
+		case eventError:
+			payload, _ := json.Marshal(e.errs)
+			s.write(conn, wsMessage{ID: id, Type: "error", Payload: payload})
+			return
+		case eventComplete:
+			s.write(conn, wsMessage{ID: id, Type: "complete"})
+			return
+		case eventDisconnect:
+			conn.Close()
+			return
+		case eventPause:
+			t := time.NewTimer(e.delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+		}
+	}
+
+	<-ctx.Done()
+}
+
+func (s *SubscriptionServer) write(conn *websocket.Conn, msg wsMessage) {
+	b, _ := json.Marshal(msg)
+	conn.WriteMessage(websocket.TextMessage, b)
+}
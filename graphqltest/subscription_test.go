@@ -0,0 +1,139 @@
+package graphqltest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+func dialSubscriptionServer(t *testing.T, s *SubscriptionServer) *websocket.Conn {
+	t.Helper()
+
+	d := &websocket.Dialer{Subprotocols: []string{GraphQLTransportWS}}
+
+	conn, _, err := d.Dial(context.Background(), s.WSURL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return conn
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, msg string) {
+	t.Helper()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	_, p, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var msg wsMessage
+	if err := json.Unmarshal(p, &msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return msg
+}
+
+func TestSubscriptionServer(t *testing.T) {
+	t.Run("ScriptedEvents", func(t *testing.T) {
+		s := NewSubscriptionServer()
+		defer s.Close()
+
+		s.Script("OnFoo",
+			Next(map[string]string{"foo": "1"}),
+			Pause(10*time.Millisecond),
+			Next(json.RawMessage(`{"foo":"2"}`)),
+			Error(graphqlclient.Error{Message: "error-msg"}),
+		)
+
+		conn := dialSubscriptionServer(t, s)
+		defer conn.Close()
+
+		writeMessage(t, conn, `{"type":"connection_init","payload":{"token":"secret"}}`)
+
+		if got, want := readMessage(t, conn).Type, "connection_ack"; got != want {
+			t.Fatalf("message type = %q, want %q", got, want)
+		}
+
+		writeMessage(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription OnFoo { foo }","variables":{"id":"123"}}}`)
+
+		for _, want := range []wsMessage{
+			{ID: "1", Type: "next", Payload: json.RawMessage(`{"data":{"foo":"1"}}`)},
+			{ID: "1", Type: "next", Payload: json.RawMessage(`{"data":{"foo":"2"}}`)},
+			{ID: "1", Type: "error", Payload: json.RawMessage(`[{"message":"error-msg"}]`)},
+		} {
+			got := readMessage(t, conn)
+
+			if got.ID != want.ID || got.Type != want.Type || string(got.Payload) != string(want.Payload) {
+				t.Errorf("message = %+v, want %+v", got, want)
+			}
+		}
+
+		AssertVariables(t, s.Subscriptions(), "OnFoo", map[string]interface{}{"id": "123"})
+
+		if got, want := s.InitPayloads()[0]["token"], "secret"; got != want {
+			t.Errorf("init payload token = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("SubscribeBeforeInit", func(t *testing.T) {
+		s := NewSubscriptionServer()
+		defer s.Close()
+
+		conn := dialSubscriptionServer(t, s)
+		defer conn.Close()
+
+		writeMessage(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { foo }"}}`)
+
+		_, _, err := conn.ReadMessage()
+
+		closeErr, ok := err.(*websocket.CloseError)
+		if !ok {
+			t.Fatalf("err is %T, want %T", err, &websocket.CloseError{})
+		}
+
+		if got, want := closeErr.Code, 4401; got != want {
+			t.Errorf("closeErr.Code = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s := NewSubscriptionServer()
+		defer s.Close()
+
+		s.Script("", Next(map[string]string{"foo": "1"}), Disconnect())
+
+		conn := dialSubscriptionServer(t, s)
+		defer conn.Close()
+
+		writeMessage(t, conn, `{"type":"connection_init"}`)
+		readMessage(t, conn)
+
+		writeMessage(t, conn, `{"id":"1","type":"subscribe","payload":{"query":"subscription { foo }"}}`)
+
+		if got, want := readMessage(t, conn).Type, "next"; got != want {
+			t.Fatalf("message type = %q, want %q", got, want)
+		}
+
+		if _, _, err := conn.ReadMessage(); err == nil {
+			t.Fatal("err is nil")
+		} else if _, ok := err.(*websocket.CloseError); ok {
+			t.Errorf("err = %v, want abrupt disconnect", err)
+		}
+	})
+}
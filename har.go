@@ -0,0 +1,622 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactedHeaders are the headers whose values HARRecorder always
+// replaces with "[REDACTED]".
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+const redacted = "[REDACTED]"
+
+// HARRecorder is an http.RoundTripper recording the requests it sends and the
+// responses it receives, so they can be exported as a HAR (HTTP Archive)
+// file, e.g. to be shared with the team running the server when debugging a
+// disagreement. Use it as the Transport of the *http.Client passed to New.
+//
+// Recording is bounded: only requests sent within the window after the
+// recorder was created are recorded, and only the most recent entries are
+// kept. Response bodies are read into memory before being handed on, except
+// for streamed responses, text/event-stream and multipart ones, which are
+// recorded as they are read. Bodies compressed with gzip are recorded
+// decompressed.
+type HARRecorder struct {
+	// RedactHeaders lists headers, in addition to
+	// DefaultRedactedHeaders, whose values are not recorded.
+	RedactHeaders []string
+
+	// RedactVariables lists GraphQL variables whose values are not
+	// recorded, whether sent in request bodies, including batches, or
+	// URLs. Request bodies and variables that can't be decoded are then
+	// recorded as "[REDACTED]".
+	RedactVariables []string
+
+	// RedactFields lists the fields of response objects, at any depth,
+	// whose values are not recorded, e.g. "email" for the data payload
+	// {"user":{"email":"..."}}.
+	RedactFields []string
+
+	transport  http.RoundTripper
+	until      time.Time
+	maxEntries int
+
+	mu      sync.Mutex
+	entries []*harEntry
+}
+
+// NewHARRecorder returns a HARRecorder sending requests using transport, or
+// http.DefaultTransport if nil. Requests are recorded for window after the
+// call, or indefinitely if window is 0. At most maxEntries entries are kept;
+// when full, the oldest entry is dropped for every new one.
+func NewHARRecorder(transport http.RoundTripper, window time.Duration, maxEntries int) *HARRecorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	r := &HARRecorder{
+		transport:  transport,
+		maxEntries: maxEntries,
+	}
+
+	if window > 0 {
+		r.until = time.Now().Add(window)
+	}
+
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	if !r.until.IsZero() && start.After(r.until) {
+		return r.transport.RoundTrip(req)
+	}
+
+	var reqBody []byte
+
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+
+	entry := &harEntry{
+		StartedDateTime: start.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            float64(elapsed) / float64(time.Millisecond),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []struct{}{},
+			Headers:     r.headers(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []struct{}{},
+			Headers:     r.headers(resp.Header),
+			Content: harContent{
+				MimeType: resp.Header.Get("Content-Type"),
+			},
+			HeadersSize: -1,
+		},
+		Cache: struct{}{},
+		Timings: harTimings{
+			Send:    0,
+			Wait:    float64(elapsed) / float64(time.Millisecond),
+			Receive: 0,
+		},
+	}
+
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+
+	r.recordURL(entry, req.URL)
+
+	if reqBody != nil {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(r.redactBody(gunzipped(reqBody, req.Header))),
+		}
+	}
+
+	if isStreamed(resp) {
+		// Recorded as read, see harStream.
+		resp.Body = &harStream{ReadCloser: resp.Body, r: r, entry: entry, gzip: isGzipped(resp.Header)}
+	} else {
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		var body io.Reader = bytes.NewReader(respBody)
+		if err != nil {
+			// Hand the read error on to the caller once the body is
+			// consumed.
+			body = io.MultiReader(body, errReader{err})
+		}
+		resp.Body = ioutil.NopCloser(body)
+
+		entry.Response.BodySize = len(respBody)
+		entry.Response.Content.Text = string(r.redactResponse(gunzipped(respBody, resp.Header)))
+		entry.Response.Content.Size = len(entry.Response.Content.Text)
+	}
+
+	r.mu.Lock()
+	if r.maxEntries > 0 && len(r.entries) >= r.maxEntries {
+		r.entries = append(r.entries[:0], r.entries[len(r.entries)-r.maxEntries+1:]...)
+	}
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// recordURL records u, the URL of the request of entry, with the GraphQL
+// variables sent in it redacted.
+func (r *HARRecorder) recordURL(entry *harEntry, u *url.URL) {
+	var (
+		pairs   = strings.Split(u.RawQuery, "&")
+		changed bool
+	)
+
+	for i, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		k, v := pair, ""
+		if n := strings.IndexByte(pair, '='); n >= 0 {
+			k, v = pair[:n], pair[n+1:]
+		}
+
+		if name, err := url.QueryUnescape(k); err == nil && name == "variables" && len(r.RedactVariables) > 0 {
+			// Variables that can't be decoded are not recorded.
+			b := []byte(redacted)
+			if vars, err := url.QueryUnescape(v); err == nil {
+				if redactedVars, ok := r.redactVariables([]byte(vars)); ok {
+					b = redactedVars
+				}
+			}
+
+			v = url.QueryEscape(string(b))
+			pairs[i] = k + "=" + v
+			changed = true
+		}
+
+		entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+	}
+
+	if changed {
+		u2 := *u
+		u2.RawQuery = strings.Join(pairs, "&")
+		entry.Request.URL = u2.String()
+	}
+}
+
+// WriteHAR writes the recorded entries to w as a HAR 1.2 document.
+func (r *HARRecorder) WriteHAR(w io.Writer) error {
+	r.mu.Lock()
+	entries := make([]harEntry, len(r.entries))
+	for i, e := range r.entries {
+		entries[i] = *e
+	}
+	r.mu.Unlock()
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Creator struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"creator"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "graphqlclient-go"
+	har.Log.Creator.Version = Version()
+	har.Log.Entries = entries
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(har)
+}
+
+// Len returns the number of recorded entries.
+func (r *HARRecorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.entries)
+}
+
+// Reset discards all recorded entries.
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+func (r *HARRecorder) headers(h http.Header) []harNameValue {
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	headers := []harNameValue{}
+
+	for _, k := range names {
+		for _, v := range h[k] {
+			if r.isRedactedHeader(k) {
+				v = redacted
+			}
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
+
+	return headers
+}
+
+func (r *HARRecorder) isRedactedHeader(name string) bool {
+	for _, lists := range [][]string{DefaultRedactedHeaders, r.RedactHeaders} {
+		for _, h := range lists {
+			if strings.EqualFold(h, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactBody returns body, a request object or a batch of them, with the
+// variables in RedactVariables redacted, or "[REDACTED]" if it can't be
+// decoded, so that no variable is ever recorded unredacted.
+func (r *HARRecorder) redactBody(body []byte) []byte {
+	if len(r.RedactVariables) == 0 {
+		return body
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []byte(redacted)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		r.redactRequest(v)
+	case []interface{}:
+		for _, req := range v {
+			req, ok := req.(map[string]interface{})
+			if !ok {
+				return []byte(redacted)
+			}
+			r.redactRequest(req)
+		}
+	default:
+		return []byte(redacted)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(redacted)
+	}
+
+	return b
+}
+
+// redactRequest redacts the variables in RedactVariables in req, a request
+// object.
+func (r *HARRecorder) redactRequest(req map[string]interface{}) {
+	if vars, ok := req["variables"].(map[string]interface{}); ok {
+		r.redactNames(vars, r.RedactVariables)
+	}
+}
+
+// redactVariables returns vars, a JSON object of variables, with the
+// variables in RedactVariables redacted, and whether it could be decoded.
+func (r *HARRecorder) redactVariables(vars []byte) ([]byte, bool) {
+	var v map[string]interface{}
+	if err := json.Unmarshal(vars, &v); err != nil || v == nil {
+		return nil, false
+	}
+
+	r.redactNames(v, r.RedactVariables)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
+
+// redactResponse returns body, a JSON response object, with the values of the
+// fields in RedactFields redacted at any depth.
+func (r *HARRecorder) redactResponse(body []byte) []byte {
+	if len(r.RedactFields) == 0 {
+		return body
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	r.redactFields(v)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return b
+}
+
+func (r *HARRecorder) redactFields(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		r.redactNames(v, r.RedactFields)
+
+		for _, e := range v {
+			r.redactFields(e)
+		}
+	case []interface{}:
+		for _, e := range v {
+			r.redactFields(e)
+		}
+	}
+}
+
+func (r *HARRecorder) redactNames(m map[string]interface{}, names []string) {
+	for _, name := range names {
+		if _, ok := m[name]; ok {
+			m[name] = redacted
+		}
+	}
+}
+
+// redactLine returns line, a line of a streamed response, with the values of
+// the fields in RedactFields redacted if it holds a JSON object, possibly as
+// the data of a server-sent event.
+func (r *HARRecorder) redactLine(line []byte) []byte {
+	if len(r.RedactFields) == 0 {
+		return line
+	}
+
+	// Lines of multipart responses end with a carriage return.
+	body := bytes.TrimSuffix(line, []byte("\r"))
+
+	var prefix []byte
+	if bytes.HasPrefix(body, []byte("data:")) {
+		prefix, body = []byte("data: "), body[len("data:"):]
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return line
+	}
+
+	var buf bytes.Buffer
+	buf.Write(prefix)
+	buf.Write(r.redactResponse(body))
+
+	if bytes.HasSuffix(line, []byte("\r")) {
+		buf.WriteByte('\r')
+	}
+
+	return buf.Bytes()
+}
+
+// harStream records a streamed response body as it is read, line by line, in
+// the entry of its response.
+type harStream struct {
+	io.ReadCloser
+
+	r     *HARRecorder
+	entry *harEntry
+	gzip  bool
+
+	// line holds the incomplete last line read, and raw, if the body is
+	// compressed with gzip, the bytes read until it is closed.
+	line []byte
+	raw  []byte
+}
+
+func (s *harStream) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	s.entry.Response.BodySize += n
+
+	if s.gzip {
+		// Decompressed once complete, see Close.
+		s.raw = append(s.raw, p[:n]...)
+		return n, err
+	}
+
+	s.line = append(s.line, p[:n]...)
+
+	for {
+		i := bytes.IndexByte(s.line, '\n')
+		if i < 0 {
+			break
+		}
+
+		s.record(s.line[:i])
+		s.entry.Response.Content.Text += "\n"
+		s.line = s.line[i+1:]
+	}
+
+	if err != nil && len(s.line) > 0 {
+		s.record(s.line)
+		s.line = nil
+	}
+
+	return n, err
+}
+
+// record records line; the recorder must be locked.
+func (s *harStream) record(line []byte) {
+	s.entry.Response.Content.Text += string(s.r.redactLine(line))
+	s.entry.Response.Content.Size = len(s.entry.Response.Content.Text)
+}
+
+func (s *harStream) Close() error {
+	s.r.mu.Lock()
+	if s.gzip && s.raw != nil {
+		body := gunzipped(s.raw, http.Header{"Content-Encoding": {"gzip"}})
+		s.raw = nil
+
+		for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+			s.record(bytes.TrimSuffix(line, []byte("\n")))
+			if bytes.HasSuffix(line, []byte("\n")) {
+				s.entry.Response.Content.Text += "\n"
+			}
+		}
+	} else if len(s.line) > 0 {
+		s.record(s.line)
+		s.line = nil
+	}
+	s.r.mu.Unlock()
+
+	return s.ReadCloser.Close()
+}
+
+// isStreamed reports whether resp is streamed, as server-sent events or
+// multipart parts, and must not be read before being handed on.
+func isStreamed(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "text/event-stream" || strings.HasPrefix(mediaType, "multipart/")
+}
+
+// isGzipped reports whether a body sent with header is compressed with gzip.
+func isGzipped(header http.Header) bool {
+	return strings.EqualFold(strings.TrimSpace(header.Get("Content-Encoding")), "gzip")
+}
+
+// gunzipped returns body, sent with header, decompressed if it is compressed
+// with gzip, or as is if it is not or can't be decompressed.
+func gunzipped(body []byte, header http.Header) []byte {
+	if !isGzipped(header) {
+		return body
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return body
+	}
+
+	return b
+}
+
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []struct{}     `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []struct{}     `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,239 @@
+package graphqlclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHARRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		},
+	))
+	defer ts.Close()
+
+	rec := NewHARRecorder(nil, 0, 2)
+	rec.RedactVariables = []string{"password"}
+
+	c := New(ts.URL, &http.Client{Transport: rec}, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer secret")
+	})
+
+	for n := 0; n < 3; n++ {
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := c.Query(context.Background(), "query { foo }", map[string]interface{}{"user": "x", "password": "hunter2"}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+	}
+
+	if got, want := rec.Len(), 2; got != want {
+		t.Errorf("rec.Len() = %d, want %d", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := rec.WriteHAR(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "secret") || strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("HAR contains sensitive values: %s", buf.String())
+	}
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := har.Log.Version, "1.2"; got != want {
+		t.Errorf("version = %q, want %q", got, want)
+	}
+
+	entry := har.Log.Entries[0]
+
+	if got, want := entry.Request.Method, http.MethodPost; got != want {
+		t.Errorf("request method = %q, want %q", got, want)
+	}
+
+	if got, want := entry.Request.PostData.Text, `{"query":"query { foo }","variables":{"password":"[REDACTED]","user":"x"}}`; got != want {
+		t.Errorf("request body = %q, want %q", got, want)
+	}
+
+	if got, want := entry.Response.Content.Text, `{"data":{"foo":"bar"}}`; got != want {
+		t.Errorf("response body = %q, want %q", got, want)
+	}
+
+	rec.Reset()
+
+	if got, want := rec.Len(), 0; got != want {
+		t.Errorf("rec.Len() = %d, want %d", got, want)
+	}
+
+	t.Run("Redaction", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"data":{"user":{"email":"alice@example.com","name":"Alice"}}}`))
+			},
+		))
+		defer ts.Close()
+
+		for _, tc := range []struct {
+			name string
+			opts []Option
+		}{
+			{"GET", []Option{WithGETQueries()}},
+			{"Gzip", []Option{WithGzipRequests(0)}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				rec := NewHARRecorder(nil, 0, 0)
+				rec.RedactVariables = []string{"password"}
+				rec.RedactFields = []string{"email"}
+
+				c := NewClient(ts.URL, append(tc.opts, WithHTTPClient(&http.Client{Transport: rec}))...)
+
+				if err := c.Query(context.Background(), "query { user { email name } }", map[string]interface{}{"password": "hunter2"}, nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				var buf bytes.Buffer
+				if err := rec.WriteHAR(&buf); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "alice@example.com") {
+					t.Errorf("HAR contains sensitive values: %s", buf.String())
+				}
+
+				if !strings.Contains(buf.String(), "Alice") {
+					t.Errorf("HAR lacks the response: %s", buf.String())
+				}
+			})
+		}
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"data":{"foo":"bar"}}]`))
+			},
+		))
+		defer ts.Close()
+
+		rec := NewHARRecorder(nil, 0, 0)
+		rec.RedactVariables = []string{"password"}
+
+		c := NewClient(ts.URL, WithHTTPClient(&http.Client{Transport: rec}))
+
+		_, err := c.QueryBatch(context.Background(), []Operation{
+			{Query: "query { foo }", Variables: map[string]interface{}{"user": "x", "password": "hunter2"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Bodies that can't be decoded are not recorded.
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"variables":{"password":"hunter2"`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		resp, err := (&http.Client{Transport: rec}).Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		var buf bytes.Buffer
+		if err := rec.WriteHAR(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if strings.Contains(buf.String(), "hunter2") {
+			t.Errorf("HAR contains sensitive values: %s", buf.String())
+		}
+
+		if !strings.Contains(buf.String(), `\"user\":\"x\"`) {
+			t.Errorf("HAR lacks the batch: %s", buf.String())
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		next := make(chan struct{})
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("data: {\"email\":\"alice@example.com\"}\n\n"))
+				w.(http.Flusher).Flush()
+
+				<-next
+			},
+		))
+		defer ts.Close()
+
+		rec := NewHARRecorder(nil, 0, 0)
+		rec.RedactFields = []string{"email"}
+
+		resp, err := (&http.Client{Transport: rec}).Get(ts.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Read before the server finishes the response.
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		close(next)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := line, "data: {\"email\":\"alice@example.com\"}\n"; got != want {
+			t.Errorf("line = %q, want %q", got, want)
+		}
+
+		resp.Body.Close()
+
+		var buf bytes.Buffer
+		if err := rec.WriteHAR(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := buf.String(), `"text": "data: {\"email\":\"[REDACTED]\"}\n`; !strings.Contains(got, want) {
+			t.Errorf("HAR = %s, want it to contain %s", got, want)
+		}
+	})
+}
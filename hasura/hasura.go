@@ -0,0 +1,85 @@
+// Package hasura provides options for sending the headers Hasura GraphQL
+// Engine authorizes requests with.
+package hasura
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Header names.
+const (
+	AdminSecretHeader = "X-Hasura-Admin-Secret"
+	RoleHeader        = "X-Hasura-Role"
+)
+
+// WithAdminSecret makes the client send secret in the X-Hasura-Admin-Secret
+// header of all requests.
+func WithAdminSecret(secret string) graphqlclient.Option {
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		req.Header.Set(AdminSecretHeader, secret)
+	})
+}
+
+// WithRole makes the client send role in the X-Hasura-Role header of all
+// requests, unless the context of the request has a Session with a role.
+func WithRole(role string) graphqlclient.Option {
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		if req.Header.Get(RoleHeader) == "" {
+			req.Header.Set(RoleHeader, role)
+		}
+	})
+}
+
+// Session holds the role and session variables of a request, e.g. those of
+// the end user a service sends requests on behalf of.
+type Session struct {
+	// Role is sent in the X-Hasura-Role header, unless empty.
+	Role string
+
+	// Variables are sent as headers. Names are prefixed with X-Hasura- if
+	// they aren't already, so that both "user-id" and "X-Hasura-User-Id"
+	// are sent as X-Hasura-User-Id.
+	Variables map[string]string
+}
+
+type sessionKey struct{}
+
+// ContextWithSession returns a copy of ctx carrying s. Clients created with
+// WithSessionFromContext send the session of the context passed to Query.
+func ContextWithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFromContext returns the Session carried by ctx, and whether it has
+// one.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(Session)
+	return s, ok
+}
+
+// WithSessionFromContext makes the client send the role and session
+// variables of the Session carried by the context of each request, if any.
+// Use it before WithRole to have the session's role take precedence.
+func WithSessionFromContext() graphqlclient.Option {
+	return graphqlclient.WithRequestOptions(func(req *http.Request) {
+		s, ok := SessionFromContext(req.Context())
+		if !ok {
+			return
+		}
+
+		if s.Role != "" {
+			req.Header.Set(RoleHeader, s.Role)
+		}
+
+		for name, value := range s.Variables {
+			if !strings.HasPrefix(strings.ToLower(name), "x-hasura-") {
+				name = "X-Hasura-" + name
+			}
+			req.Header.Set(name, value)
+		}
+	})
+}
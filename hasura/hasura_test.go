@@ -0,0 +1,82 @@
+package hasura
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestOptions(t *testing.T) {
+	var header http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := graphqlclient.NewClient(ts.URL,
+		WithAdminSecret("foo-secret"),
+		WithSessionFromContext(),
+		WithRole("anonymous"),
+	)
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		want map[string]string
+	}{
+		{
+			name: "NoSession",
+			ctx:  context.Background(),
+			want: map[string]string{
+				"X-Hasura-Admin-Secret": "foo-secret",
+				"X-Hasura-Role":         "anonymous",
+				"X-Hasura-User-Id":      "",
+			},
+		},
+		{
+			name: "Session",
+			ctx: ContextWithSession(context.Background(), Session{
+				Role: "user",
+				Variables: map[string]string{
+					"user-id":          "42",
+					"X-Hasura-Org-Id":  "7",
+					"x-hasura-team-id": "3",
+				},
+			}),
+			want: map[string]string{
+				"X-Hasura-Admin-Secret": "foo-secret",
+				"X-Hasura-Role":         "user",
+				"X-Hasura-User-Id":      "42",
+				"X-Hasura-Org-Id":       "7",
+				"X-Hasura-Team-Id":      "3",
+			},
+		},
+		{
+			name: "SessionWithoutRole",
+			ctx:  ContextWithSession(context.Background(), Session{Variables: map[string]string{"user-id": "42"}}),
+			want: map[string]string{
+				"X-Hasura-Role":    "anonymous",
+				"X-Hasura-User-Id": "42",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := c.Query(tc.ctx, "foo-query", nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, want := range tc.want {
+				if got := header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,529 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// incrementalAccept is the Accept header of requests for incremental
+// delivery, preferring multipart responses as specified by the 2022-08-24
+// draft of the @defer and @stream RFC.
+const incrementalAccept = "multipart/mixed;deferSpec=20220824, " + DefaultAccept
+
+// IncrementalPayload is a payload of a response delivered incrementally with
+// @defer and @stream: either the initial payload, or the result of a
+// deferred fragment or the items of a stream.
+type IncrementalPayload struct {
+	// Initial is true for the initial payload.
+	Initial bool
+
+	// Data is the data payload of the initial payload or of a deferred
+	// fragment, to be merged at Path. Items are the items streamed, to be
+	// appended to the list at Path.
+	Data  json.RawMessage
+	Items []json.RawMessage
+
+	// Path is the path in the result of Data or Items, of object keys and
+	// list indexes, and Label the label of the @defer or @stream directive
+	// delivering them.
+	Path  []interface{}
+	Label string
+
+	// Errors are the errors of the payload.
+	Errors []Error
+
+	// HasNext is true if more payloads follow.
+	HasNext bool
+}
+
+// IncrementalHandler is called with each payload of a response delivered
+// incrementally. If it returns an error, the response is closed and
+// QueryIncremental returns the error.
+type IncrementalHandler func(p IncrementalPayload) error
+
+// QueryIncremental sends query with variables to the server, as Query does,
+// accepting incremental delivery over multipart/mixed for queries using
+// @defer and @stream, and calls handler with the initial payload and each
+// incremental payload as it is received, until the last one. Responses that
+// are not delivered incrementally, including those of transports, see
+// WithTransport, are handled as a single initial payload. Errors of payloads
+// are passed to handler rather than returned; a response with a non-2xx
+// status code is returned as an *ErrorResponse. Decode limits, see
+// WithDecodeLimits, apply to each part of a response.
+func (c *Client) QueryIncremental(ctx context.Context, query string, variables map[string]interface{}, handler IncrementalHandler, reqOpts ...func(*http.Request)) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	op := operation{query: query}
+	if c.documents != nil {
+		op = c.documents.operation(query)
+	}
+
+	return withOperationName(c.queryIncremental(ctx, op, variables, handler, reqOpts), operationName(ctx))
+}
+
+// queryIncremental sends op with variables, calling handler with the payloads
+// of the response.
+func (c *Client) queryIncremental(ctx context.Context, op operation, variables map[string]interface{}, handler IncrementalHandler, reqOpts []func(*http.Request)) error {
+	if c.wrappedTransport != nil {
+		var data json.RawMessage
+
+		err := c.queryTransport(ctx, op, variables, &data, reqOpts)
+
+		var errResp *ErrorResponse
+		if err != nil && (!errors.As(err, &errResp) || errResp.StatusCode/100 != 2) {
+			return err
+		}
+
+		p := IncrementalPayload{Initial: true, Data: data}
+		if errResp != nil {
+			p.Errors = errResp.Errors
+		}
+
+		return handler(p)
+	}
+
+	if err := c.lifecycle.begin(); err != nil {
+		return err
+	}
+	defer c.lifecycle.end()
+
+	// Set ahead of the request options, which may override it.
+	reqOpts = append([]func(*http.Request){func(req *http.Request) {
+		req.Header.Set("Accept", incrementalAccept)
+	}}, reqOpts...)
+
+	req, release, _, err := c.prepareRequest(ctx, op, variables, reqOpts, false)
+	if err != nil {
+		return err
+	}
+	if release != nil {
+		defer release()
+	}
+
+	return c.exchange(ctx, req, nil, true, func(resp *response) error {
+		if resp.boundary == "" {
+			return c.handleResponse(resp, handler)
+		}
+		return c.handleParts(resp, handler)
+	})
+}
+
+// handleResponse calls handler with resp, a response not delivered
+// incrementally, as the initial payload.
+func (c *Client) handleResponse(resp *response, handler IncrementalHandler) error {
+	var data json.RawMessage
+
+	if resp.codec != nil {
+		err := c.decodeWithCodec(resp.codec, resp.StatusCode, resp.body, &data)
+
+		var errResp *ErrorResponse
+		if err != nil && (!errors.As(err, &errResp) || errResp.StatusCode/100 != 2) {
+			return err
+		}
+
+		p := IncrementalPayload{Initial: true, Data: data}
+		if errResp != nil {
+			p.Errors = errResp.Errors
+		}
+
+		return handler(p)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var extensions *json.RawMessage
+	if resp.meta != nil {
+		extensions = &resp.meta.Extensions
+	}
+
+	errs, dataErr, err := decodeResponse(io.TeeReader(resp.body, &headWriter{buf: buf, max: maxErrorBodySize}), &data, resp.StatusCode/100 == 2, c.envelope, c.lenient, extensions)
+
+	var limitErr *DecodeLimitError
+	if errors.As(err, &limitErr) || errors.As(dataErr, &limitErr) {
+		return limitErr
+	}
+
+	switch {
+	case resp.StatusCode/100 != 2:
+		return &ErrorResponse{
+			StatusCode: resp.StatusCode,
+			Errors:     errs,
+			Body:       errorBody(buf),
+			hasData:    err == nil && dataErr != errNoData,
+		}
+	case err == io.EOF && resp.empty():
+		return &EmptyResponseError{StatusCode: resp.StatusCode}
+	case err != nil:
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return handler(IncrementalPayload{
+		Initial: true,
+		Data:    data,
+		Errors:  errs,
+	})
+}
+
+// handleParts calls handler with the payloads of the parts of resp, a
+// response delivered incrementally.
+func (c *Client) handleParts(resp *response, handler IncrementalHandler) error {
+	mr := multipart.NewReader(resp.body, resp.boundary)
+
+	for initial := true; ; initial = false {
+		part, err := mr.NextPart()
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("error reading response: missing final payload")
+			}
+			return fmt.Errorf("error reading response: %v", err)
+		}
+
+		var r io.Reader = part
+		if c.decodeLimits != nil {
+			r = &limitReader{r: r, limits: *c.decodeLimits}
+		}
+
+		var res incrementalResult
+		if err := c.decodePart(r, &res); err != nil {
+			var limitErr *DecodeLimitError
+			if errors.As(err, &limitErr) {
+				return limitErr
+			}
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+
+		if initial && resp.meta != nil {
+			resp.meta.Extensions = res.Extensions
+		}
+
+		for _, p := range res.payloads(initial) {
+			if err := handler(p); err != nil {
+				return err
+			}
+		}
+
+		if !res.HasNext {
+			return nil
+		}
+	}
+}
+
+// decodePart decodes a part of a response delivered incrementally into res,
+// reading its data payload and errors from the fields of the client's
+// envelope, see WithEnvelopeFields, and its errors leniently if configured,
+// see WithLenientResponses.
+func (c *Client) decodePart(r io.Reader, res *incrementalResult) error {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return err
+	}
+
+	for key, v := range fields {
+		var err error
+
+		switch {
+		case strings.EqualFold(key, c.envelope.errors) && c.lenient:
+			res.Errors, err = decodeLenientErrors(v)
+		case strings.EqualFold(key, c.envelope.errors):
+			err = json.Unmarshal(v, &res.Errors)
+		case strings.EqualFold(key, c.envelope.data):
+			res.Data = v
+		case key == "items":
+			err = json.Unmarshal(v, &res.Items)
+		case key == "path":
+			err = json.Unmarshal(v, &res.Path)
+		case key == "label":
+			err = json.Unmarshal(v, &res.Label)
+		case key == "hasNext":
+			err = json.Unmarshal(v, &res.HasNext)
+		case key == "incremental":
+			err = json.Unmarshal(v, &res.Incremental)
+		case key == "extensions":
+			res.Extensions = v
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incrementalResult is a part of a response delivered incrementally, or a
+// response that is not.
+type incrementalResult struct {
+	incrementalItem
+
+	HasNext     bool              `json:"hasNext"`
+	Incremental []incrementalItem `json:"incremental"`
+	Extensions  json.RawMessage   `json:"extensions"`
+}
+
+// incrementalItem is an item of the "incremental" array of a part, or, as
+// sent by servers implementing earlier drafts, a part itself.
+type incrementalItem struct {
+	Data   json.RawMessage   `json:"data"`
+	Items  []json.RawMessage `json:"items"`
+	Path   []interface{}     `json:"path"`
+	Label  string            `json:"label"`
+	Errors []Error           `json:"errors"`
+}
+
+// payloads returns the payloads of a part, the first one of which is the
+// initial payload if initial is true.
+func (r *incrementalResult) payloads(initial bool) []IncrementalPayload {
+	items := r.Incremental
+	if initial || (items == nil && (r.Data != nil || r.Items != nil || len(r.Errors) > 0)) {
+		items = append([]incrementalItem{r.incrementalItem}, items...)
+	}
+
+	payloads := make([]IncrementalPayload, len(items))
+
+	for i, item := range items {
+		payloads[i] = IncrementalPayload{
+			Initial: initial && i == 0,
+			Data:    item.Data,
+			Items:   item.Items,
+			Path:    item.Path,
+			Label:   item.Label,
+			Errors:  item.Errors,
+			HasNext: r.HasNext,
+		}
+	}
+
+	return payloads
+}
+
+// IncrementalResult merges the payloads of a response delivered
+// incrementally into a single result, merging the data of deferred fragments
+// into the objects at their paths and setting streamed items in the lists at
+// theirs:
+//
+//	var res graphqlclient.IncrementalResult
+//	if err := c.QueryIncremental(ctx, query, variables, res.Apply); err != nil {
+//		return err
+//	}
+//
+//	var data Data
+//	if err := res.Decode(&data); err != nil {
+//		return err
+//	}
+//
+// The zero value is an empty result.
+type IncrementalResult struct {
+	data interface{}
+	errs []Error
+}
+
+// Apply merges p into the result.
+func (r *IncrementalResult) Apply(p IncrementalPayload) error {
+	r.errs = append(r.errs, p.Errors...)
+
+	if p.Initial {
+		r.data = nil
+	}
+
+	if len(p.Data) > 0 && string(p.Data) != "null" {
+		v, err := decodeJSONValue(p.Data)
+		if err != nil {
+			return fmt.Errorf("error decoding payload: %v", err)
+		}
+
+		if p.Initial {
+			r.data = v
+			return nil
+		}
+
+		target, err := valueAtPath(r.data, p.Path)
+		if err != nil {
+			return err
+		}
+
+		if err := mergeJSON(target, v, p.Path); err != nil {
+			return err
+		}
+	}
+
+	if p.Items != nil {
+		if len(p.Path) < 2 {
+			return fmt.Errorf("path %v: not the path of streamed items", p.Path)
+		}
+
+		start, ok := pathIndex(p.Path[len(p.Path)-1])
+		if !ok {
+			return fmt.Errorf("path %v: last element is not a list index", p.Path)
+		}
+
+		parent, err := valueAtPath(r.data, p.Path[:len(p.Path)-2])
+		if err != nil {
+			return err
+		}
+
+		list, key, err := listAtPath(parent, p.Path[:len(p.Path)-1])
+		if err != nil {
+			return err
+		}
+
+		for i, raw := range p.Items {
+			item, err := decodeJSONValue(raw)
+			if err != nil {
+				return fmt.Errorf("error decoding payload: %v", err)
+			}
+
+			for len(list) <= start+i {
+				list = append(list, nil)
+			}
+			list[start+i] = item
+		}
+
+		setPathElement(parent, key, list)
+	}
+
+	return nil
+}
+
+// Data returns the merged data payload.
+func (r *IncrementalResult) Data() (json.RawMessage, error) {
+	return json.Marshal(r.data)
+}
+
+// Decode decodes the merged data payload into v.
+func (r *IncrementalResult) Decode(v interface{}) error {
+	b, err := r.Data()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Errors returns the errors of all payloads applied.
+func (r *IncrementalResult) Errors() []Error {
+	return r.errs
+}
+
+// decodeJSONValue decodes raw into a tree of maps, slices and scalars,
+// keeping numbers as json.Number.
+func decodeJSONValue(raw json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// valueAtPath returns the value at path, of object keys and list indexes, in
+// v.
+func valueAtPath(v interface{}, path []interface{}) (interface{}, error) {
+	for i, elem := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			key, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("path %v: %v is not an object key", path[:i+1], elem)
+			}
+			v = node[key]
+		case []interface{}:
+			n, ok := pathIndex(elem)
+			if !ok || n >= len(node) {
+				return nil, fmt.Errorf("path %v: %v is not a list index", path[:i+1], elem)
+			}
+			v = node[n]
+		default:
+			return nil, fmt.Errorf("path %v: %w", path[:i+1], ErrPathNotFound)
+		}
+	}
+
+	return v, nil
+}
+
+// listAtPath returns the list at path in parent, the value at all but the
+// last element of path, and the last element.
+func listAtPath(parent interface{}, path []interface{}) ([]interface{}, interface{}, error) {
+	key := path[len(path)-1]
+
+	v, err := valueAtPath(parent, []interface{}{key})
+	if err != nil {
+		return nil, nil, fmt.Errorf("path %v: %v", path, err)
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		return v, key, nil
+	case nil:
+		return nil, key, nil
+	}
+
+	return nil, nil, fmt.Errorf("path %v: not a list", path)
+}
+
+// setPathElement sets the value of key, an object key or list index, in
+// parent.
+func setPathElement(parent, key, v interface{}) {
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		parent[key.(string)] = v
+	case []interface{}:
+		n, _ := pathIndex(key)
+		parent[n] = v
+	}
+}
+
+// mergeJSON merges the object src into the object dst, recursively.
+func mergeJSON(dst, src interface{}, path []interface{}) error {
+	d, ok := dst.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("path %v: not an object", path)
+	}
+
+	s, ok := src.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("path %v: payload is not an object", path)
+	}
+
+	for k, v := range s {
+		if dv, ok := d[k].(map[string]interface{}); ok {
+			if sv, ok := v.(map[string]interface{}); ok {
+				if err := mergeJSON(dv, sv, append(path[:len(path):len(path)], k)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		d[k] = v
+	}
+
+	return nil
+}
+
+// pathIndex returns elem, a path element decoded from JSON, as a list index.
+func pathIndex(elem interface{}) (int, bool) {
+	switch n := elem.(type) {
+	case float64:
+		if n >= 0 && n == float64(int(n)) {
+			return int(n), true
+		}
+	case int:
+		return n, n >= 0
+	case json.Number:
+		i, err := strconv.Atoi(string(n))
+		return i, err == nil && i >= 0
+	}
+	return 0, false
+}
@@ -0,0 +1,330 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_QueryIncremental(t *testing.T) {
+	const multipartBody = "\r\n---\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"data":{"user":{"id":"1"}},"hasNext":true}` + "\r\n---\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"incremental":[{"data":{"name":"a"},"path":["user"],"label":"name"},{"items":[{"id":"2"}],"path":["user","friends",0]}],"hasNext":true}` + "\r\n---\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"data":{"age":3},"path":["user"],"errors":[{"message":"partial"}],"hasNext":true}` + "\r\n---\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n\r\n" +
+		`{"hasNext":false}` + "\r\n-----\r\n"
+
+	for _, tc := range []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "Multipart",
+			status:      http.StatusOK,
+			contentType: `multipart/mixed; boundary="-"; deferSpec=20220824`,
+			body:        multipartBody,
+			want: []string{
+				`initial {"user":{"id":"1"}} [] [] "" [] true`,
+				`{"name":"a"} [] [user] "name" [] true`,
+				`null [{"id":"2"}] [user friends 0] "" [] true`,
+				`{"age":3} [] [user] "" [{partial [] [] map[]}] true`,
+			},
+			wantErr: "<nil>",
+		},
+		{
+			name:        "Truncated",
+			status:      http.StatusOK,
+			contentType: `multipart/mixed; boundary="-"`,
+			body:        multipartBody[:strings.Index(multipartBody, `{"incremental"`)],
+			want: []string{
+				`initial {"user":{"id":"1"}} [] [] "" [] true`,
+			},
+			wantErr: "error decoding response: unexpected EOF",
+		},
+		{
+			name:        "JSON",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"data":{"user":{"id":"1","name":"a"}}}`,
+			want: []string{
+				`initial {"user":{"id":"1","name":"a"}} [] [] "" [] false`,
+			},
+			wantErr: "<nil>",
+		},
+		{
+			name:        "ErrorResponse",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"errors":[{"message":"invalid"}]}`,
+			wantErr:     "400 Bad Request: invalid",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if got, want := r.Header.Get("Accept"), incrementalAccept; got != want {
+						t.Errorf("Accept = %q, want %q", got, want)
+					}
+
+					w.Header().Set("Content-Type", tc.contentType)
+					w.WriteHeader(tc.status)
+					w.Write([]byte(tc.body))
+				},
+			))
+			defer ts.Close()
+
+			var got []string
+
+			err := NewClient(ts.URL).QueryIncremental(context.Background(), "query { user { id ... @defer { name } } }", nil,
+				func(p IncrementalPayload) error {
+					s := fmt.Sprintf("%s %s %v %q %v %v", p.Data, p.Items, p.Path, p.Label, p.Errors, p.HasNext)
+					if p.Initial {
+						s = "initial " + s
+					}
+					got = append(got, s)
+					return nil
+				},
+			)
+
+			if got, want := fmt.Sprint(err), tc.wantErr; got != want {
+				t.Errorf("err = %q, want %q", got, want)
+			}
+
+			if got, want := strings.Join(got, "\n"), strings.Join(tc.want, "\n"); got != want {
+				t.Errorf("payloads =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	t.Run("HandlerError", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+				w.Write([]byte(multipartBody))
+			},
+		))
+		defer ts.Close()
+
+		errStop := errors.New("stop")
+
+		err := NewClient(ts.URL).QueryIncremental(context.Background(), "query { user { id } }", nil,
+			func(IncrementalPayload) error { return errStop },
+		)
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	collect := func(got *[]string) IncrementalHandler {
+		return func(p IncrementalPayload) error {
+			*got = append(*got, fmt.Sprintf("%s %v", p.Data, p.Errors))
+			return nil
+		}
+	}
+
+	t.Run("DecodeLimits", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+				w.Write([]byte(multipartBody))
+			},
+		))
+		defer ts.Close()
+
+		var got []string
+
+		c := NewClient(ts.URL, WithDecodeLimits(DecodeLimits{MaxDepth: 3}))
+		err := c.QueryIncremental(context.Background(), "query { user { id } }", nil, collect(&got))
+
+		var limitErr *DecodeLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("err = %v, want *DecodeLimitError", err)
+		}
+
+		if got, want := limitErr.Limit, "depth"; got != want {
+			t.Errorf("Limit = %q, want %q", got, want)
+		}
+
+		if got, want := strings.Join(got, "\n"), `{"user":{"id":"1"}} []`; got != want {
+			t.Errorf("payloads = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/elsewhere", http.StatusTemporaryRedirect)
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithRedirects(false))
+		err := c.QueryIncremental(context.Background(), "query { user { id } }", nil, collect(new([]string)))
+
+		var redirectErr *RedirectError
+		if !errors.As(err, &redirectErr) {
+			t.Fatalf("err = %v, want *RedirectError", err)
+		}
+
+		if got, want := redirectErr.Location, "/elsewhere"; got != want {
+			t.Errorf("Location = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+				w.Header().Set("X-Request-Id", "abc")
+				w.Write([]byte("\r\n---\r\n\r\n" +
+					`{"data":{"user":{"id":"1"}},"extensions":{"cost":1},"hasNext":false}` + "\r\n-----\r\n"))
+			},
+		))
+		defer ts.Close()
+
+		meta := &ResponseMetadata{}
+
+		err := NewClient(ts.URL).QueryIncremental(contextWithResponseMetadata(context.Background(), meta), "query { user { id } }", nil, collect(new([]string)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := meta.StatusCode, http.StatusOK; got != want {
+			t.Errorf("StatusCode = %d, want %d", got, want)
+		}
+
+		if got, want := meta.Header.Get("X-Request-Id"), "abc"; got != want {
+			t.Errorf("X-Request-Id = %q, want %q", got, want)
+		}
+
+		if got, want := string(meta.Extensions), `{"cost":1}`; got != want {
+			t.Errorf("Extensions = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+				w.Write([]byte("\r\n---\r\n\r\n" +
+					`{"result":{"user":{"id":"1"}},"problems":"partial","hasNext":false}` + "\r\n-----\r\n"))
+			},
+		))
+		defer ts.Close()
+
+		var got []string
+
+		c := NewClient(ts.URL, WithEnvelopeFields("result", "problems"), WithLenientResponses())
+		if err := c.QueryIncremental(context.Background(), "query { user { id } }", nil, collect(&got)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, "\n"), `{"user":{"id":"1"}} [{partial [] [] map[]}]`; got != want {
+			t.Errorf("payloads = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Transport", func(t *testing.T) {
+		var got []string
+
+		c := NewClient("http://example.com", WithTransport(transportFunc(
+			func(ctx context.Context, req *Request) (*Response, error) {
+				return &Response{
+					Data:   json.RawMessage(`{"user":{"id":"1"}}`),
+					Errors: []Error{{Message: "partial"}},
+				}, nil
+			},
+		)))
+
+		if err := c.QueryIncremental(context.Background(), "query { user { id } }", nil, collect(&got)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, "\n"), `{"user":{"id":"1"}} [{partial [] [] map[]}]`; got != want {
+			t.Errorf("payloads = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestIncrementalResult(t *testing.T) {
+	payloads := []IncrementalPayload{
+		{Initial: true, Data: json.RawMessage(`{"user":{"id":"1","profile":{"age":3},"friends":[{"id":"2"}]}}`), HasNext: true},
+		{Data: json.RawMessage(`{"name":"a","profile":{"bio":"b"}}`), Path: []interface{}{"user"}, HasNext: true},
+		{Items: []json.RawMessage{json.RawMessage(`{"id":"3"}`), json.RawMessage(`{"id":"4"}`)}, Path: []interface{}{"user", "friends", 1.0}, HasNext: true},
+		{Items: []json.RawMessage{json.RawMessage(`"x"`)}, Path: []interface{}{"user", "tags", 0.0}, HasNext: true},
+		{Data: json.RawMessage(`{"name":"c"}`), Path: []interface{}{"user", "friends", 0.0}, Errors: []Error{{Message: "partial"}}},
+	}
+
+	var res IncrementalResult
+
+	for _, p := range payloads {
+		if err := res.Apply(p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	data, err := res.Data()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"user":{"friends":[{"id":"2","name":"c"},{"id":"3"},{"id":"4"}],"id":"1","name":"a","profile":{"age":3,"bio":"b"},"tags":["x"]}}`
+
+	if got := string(data); got != want {
+		t.Errorf("res.Data() = %s, want %s", got, want)
+	}
+
+	if got, want := fmt.Sprint(res.Errors()), "[{partial [] [] map[]}]"; got != want {
+		t.Errorf("res.Errors() = %s, want %s", got, want)
+	}
+
+	var v struct {
+		User struct {
+			Profile struct {
+				Age int `json:"age"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+
+	if err := res.Decode(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := v.User.Profile.Age, 3; got != want {
+		t.Errorf("age = %d, want %d", got, want)
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			p    IncrementalPayload
+		}{
+			{"NotObject", IncrementalPayload{Data: json.RawMessage(`{"a":1}`), Path: []interface{}{"user", "id"}}},
+			{"MissingPath", IncrementalPayload{Data: json.RawMessage(`{"a":1}`), Path: []interface{}{"user", "id", "x"}}},
+			{"NotList", IncrementalPayload{Items: []json.RawMessage{json.RawMessage(`1`)}, Path: []interface{}{"user", 0.0}}},
+			{"NoIndex", IncrementalPayload{Items: []json.RawMessage{json.RawMessage(`1`)}, Path: []interface{}{"user", "friends"}}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				var res IncrementalResult
+				res.Apply(IncrementalPayload{Initial: true, Data: json.RawMessage(`{"user":{"id":"1","friends":[]}}`)})
+
+				if err := res.Apply(tc.p); err == nil {
+					t.Error("err = nil, want error")
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// Package graphql implements a parser for GraphQL documents, both executable
+// documents (operations and fragments) and type system documents (schemas in
+// SDL), and a validator checking operations against a schema.
+package graphql
+
+import "fmt"
+
+// Pos is a position in a source document. Line and Column start at 1. Source
+// is the name of the document, if it was given one when parsed.
+type Pos struct {
+	Source string
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	if p.Source != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Source, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Less reports whether p comes before q.
+func (p Pos) Less(q Pos) bool {
+	if p.Source != q.Source {
+		return p.Source < q.Source
+	}
+	return p.Line < q.Line || (p.Line == q.Line && p.Column < q.Column)
+}
+
+// Error is a syntax or validation error.
+type Error struct {
+	Message string
+	Pos     Pos
+}
+
+func (e *Error) Error() string {
+	if e.Pos.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// Operation types.
+const (
+	Query        = "query"
+	Mutation     = "mutation"
+	Subscription = "subscription"
+)
+
+// Document is a parsed executable document.
+type Document struct {
+	Operations []*Operation
+	Fragments  []*Fragment
+}
+
+// Operation is an operation definition.
+type Operation struct {
+	Type                string
+	Name                string
+	VariableDefinitions []*VariableDefinition
+	Directives          []*Directive
+	SelectionSet        []Selection
+	Pos                 Pos
+}
+
+// VariableDefinition is one variable definition of an operation.
+type VariableDefinition struct {
+	Name         string
+	Type         *Type
+	DefaultValue *Value
+	Pos          Pos
+}
+
+// Fragment is a fragment definition.
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Pos           Pos
+}
+
+// Selection is one of *Field, *FragmentSpread and *InlineFragment.
+type Selection interface {
+	position() Pos
+}
+
+// Field is a field selection.
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Pos          Pos
+}
+
+// FragmentSpread is a named fragment spread.
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Pos        Pos
+}
+
+// InlineFragment is an inline fragment.
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Pos           Pos
+}
+
+func (f *Field) position() Pos          { return f.Pos }
+func (f *FragmentSpread) position() Pos { return f.Pos }
+func (f *InlineFragment) position() Pos { return f.Pos }
+
+// Directive is a directive applied to a definition or selection.
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Pos       Pos
+}
+
+// Argument is a named argument value.
+type Argument struct {
+	Name  string
+	Value *Value
+	Pos   Pos
+}
+
+// ValueKind is the kind of a Value.
+type ValueKind int
+
+// Value kinds.
+const (
+	VariableValue ValueKind = iota
+	IntValue
+	FloatValue
+	StringValue
+	BooleanValue
+	NullValue
+	EnumValue
+	ListValue
+	ObjectValue
+)
+
+// Value is an input value. Raw holds the variable name for variables, the
+// literal text for numbers, the decoded string for strings and the name for
+// booleans, null and enum values. List and Object hold the elements of lists
+// and the fields of objects.
+type Value struct {
+	Kind   ValueKind
+	Raw    string
+	List   []*Value
+	Object []*Argument
+	Pos    Pos
+}
+
+// Type is a type reference. Exactly one of Name and Elem is set.
+type Type struct {
+	Name    string
+	Elem    *Type
+	NonNull bool
+}
+
+// NamedType returns the name of the innermost named type.
+func (t *Type) NamedType() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+func (t *Type) String() string {
+	var s string
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	} else {
+		s = t.Name
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// DefinitionKind is the kind of a named type in a schema.
+type DefinitionKind string
+
+// Definition kinds.
+const (
+	Scalar      DefinitionKind = "SCALAR"
+	Object      DefinitionKind = "OBJECT"
+	Interface   DefinitionKind = "INTERFACE"
+	Union       DefinitionKind = "UNION"
+	Enum        DefinitionKind = "ENUM"
+	InputObject DefinitionKind = "INPUT_OBJECT"
+)
+
+// Schema is a parsed type system document.
+type Schema struct {
+	Types        map[string]*Definition
+	Directives   map[string]*DirectiveDefinition
+	Query        string
+	Mutation     string
+	Subscription string
+}
+
+// Definition is a named type definition.
+type Definition struct {
+	Kind       DefinitionKind
+	Name       string
+	Fields     []*FieldDefinition
+	Interfaces []string
+	Types      []string
+	EnumValues []string
+	Pos        Pos
+}
+
+// Field returns the field with the given name, or nil.
+func (d *Definition) Field(name string) *FieldDefinition {
+	for _, f := range d.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FieldDefinition is a field of an object or interface type, or of an input
+// object type, or an argument definition.
+type FieldDefinition struct {
+	Name         string
+	Arguments    []*FieldDefinition
+	Type         *Type
+	DefaultValue *Value
+	Pos          Pos
+}
+
+// DirectiveDefinition is a directive definition.
+type DirectiveDefinition struct {
+	Name      string
+	Arguments []*FieldDefinition
+	Locations []string
+}
@@ -0,0 +1,311 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokEOF:
+		return "end of document"
+	case tokPunct:
+		return "punctuator"
+	case tokName:
+		return "name"
+	case tokInt:
+		return "integer"
+	case tokFloat:
+		return "float"
+	default:
+		return "string"
+	}
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   Pos
+}
+
+type lexer struct {
+	name string
+	src  string
+	off  int
+	line int
+	col  int
+}
+
+func newLexer(name, src string) *lexer {
+	return &lexer{name: name, src: src, line: 1, col: 1}
+}
+
+func (l *lexer) pos() Pos {
+	return Pos{Source: l.name, Line: l.line, Column: l.col}
+}
+
+func (l *lexer) errorf(pos Pos, msg string) {
+	panic(&Error{Message: msg, Pos: pos})
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n; i++ {
+		if l.src[l.off] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.off++
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.off < len(l.src) {
+		switch c := l.src[l.off]; {
+		case c == ' ', c == '\t', c == ',', c == '\n':
+			l.advance(1)
+		case c == '\r':
+			l.off++
+			if l.off < len(l.src) && l.src[l.off] == '\n' {
+				l.off++
+			}
+			l.line++
+			l.col = 1
+		case c == '#':
+			for l.off < len(l.src) && l.src[l.off] != '\n' && l.src[l.off] != '\r' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.off:], "\ufeff"):
+			l.off += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token.
+func (l *lexer) next() token {
+	l.skipIgnored()
+
+	pos := l.pos()
+
+	if l.off >= len(l.src) {
+		return token{kind: tokEOF, pos: pos}
+	}
+
+	c := l.src[l.off]
+
+	switch {
+	case strings.HasPrefix(l.src[l.off:], "..."):
+		l.advance(3)
+		return token{kind: tokPunct, value: "...", pos: pos}
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokPunct, value: string(c), pos: pos}
+	case isNameStart(c):
+		start := l.off
+		for l.off < len(l.src) && isNameContinue(l.src[l.off]) {
+			l.advance(1)
+		}
+		return token{kind: tokName, value: l.src[start:l.off], pos: pos}
+	case c == '-' || isDigit(c):
+		return l.number(pos)
+	case strings.HasPrefix(l.src[l.off:], `"""`):
+		return l.blockString(pos)
+	case c == '"':
+		return l.string(pos)
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.off:])
+	l.errorf(pos, "unexpected character "+strconv.QuoteRune(r))
+	return token{}
+}
+
+func (l *lexer) number(pos Pos) token {
+	start := l.off
+	kind := tokInt
+
+	if l.src[l.off] == '-' {
+		l.advance(1)
+	}
+
+	digits := func() {
+		if l.off >= len(l.src) || !isDigit(l.src[l.off]) {
+			l.errorf(l.pos(), "invalid number")
+		}
+		for l.off < len(l.src) && isDigit(l.src[l.off]) {
+			l.advance(1)
+		}
+	}
+
+	if l.off < len(l.src) && l.src[l.off] == '0' {
+		l.advance(1)
+		if l.off < len(l.src) && isDigit(l.src[l.off]) {
+			l.errorf(pos, "invalid number, unexpected digit after 0")
+		}
+	} else {
+		digits()
+	}
+
+	if l.off < len(l.src) && l.src[l.off] == '.' {
+		kind = tokFloat
+		l.advance(1)
+		digits()
+	}
+
+	if l.off < len(l.src) && (l.src[l.off] == 'e' || l.src[l.off] == 'E') {
+		kind = tokFloat
+		l.advance(1)
+		if l.off < len(l.src) && (l.src[l.off] == '+' || l.src[l.off] == '-') {
+			l.advance(1)
+		}
+		digits()
+	}
+
+	if l.off < len(l.src) && (l.src[l.off] == '.' || isNameStart(l.src[l.off])) {
+		l.errorf(l.pos(), "invalid number")
+	}
+
+	return token{kind: kind, value: l.src[start:l.off], pos: pos}
+}
+
+func (l *lexer) string(pos Pos) token {
+	l.advance(1)
+
+	var b strings.Builder
+
+	for {
+		if l.off >= len(l.src) || l.src[l.off] == '\n' || l.src[l.off] == '\r' {
+			l.errorf(pos, "unterminated string")
+		}
+
+		c := l.src[l.off]
+
+		switch c {
+		case '"':
+			l.advance(1)
+			return token{kind: tokString, value: b.String(), pos: pos}
+		case '\\':
+			if l.off+1 >= len(l.src) {
+				l.errorf(pos, "unterminated string")
+			}
+
+			esc := l.src[l.off+1]
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.off+6 > len(l.src) {
+					l.errorf(pos, "invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(l.src[l.off+2:l.off+6], 16, 32)
+				if err != nil {
+					l.errorf(l.pos(), "invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				l.advance(4)
+			default:
+				l.errorf(l.pos(), "invalid escape sequence")
+			}
+			l.advance(2)
+		default:
+			_, size := utf8.DecodeRuneInString(l.src[l.off:])
+			b.WriteString(l.src[l.off : l.off+size])
+			l.off += size
+			l.col++
+		}
+	}
+}
+
+func (l *lexer) blockString(pos Pos) token {
+	l.advance(3)
+
+	var b strings.Builder
+
+	for {
+		if l.off >= len(l.src) {
+			l.errorf(pos, "unterminated block string")
+		}
+
+		switch {
+		case strings.HasPrefix(l.src[l.off:], `"""`):
+			l.advance(3)
+			return token{kind: tokString, value: blockStringValue(b.String()), pos: pos}
+		case strings.HasPrefix(l.src[l.off:], `\"""`):
+			b.WriteString(`"""`)
+			l.advance(4)
+		default:
+			b.WriteByte(l.src[l.off])
+			l.advance(1)
+		}
+	}
+}
+
+// blockStringValue removes the common indentation and leading and trailing
+// blank lines of a block string, as described in the GraphQL spec.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.Replace(strings.Replace(raw, "\r\n", "\n", -1), "\r", "\n", -1), "\n")
+
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+
+	if common > 0 {
+		for n := 1; n < len(lines); n++ {
+			if len(lines[n]) >= common {
+				lines[n] = lines[n][common:]
+			} else {
+				lines[n] = ""
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
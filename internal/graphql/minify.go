@@ -0,0 +1,40 @@
+package graphql
+
+import "strings"
+
+// Minify returns src with ignored tokens, i.e. whitespace, commas and
+// comments, removed, keeping single spaces only where needed to separate
+// names and numbers. Strings are kept as written.
+func Minify(src string) (s string, err error) {
+	defer recoverError(&err)
+
+	l := newLexer("", src)
+
+	var (
+		b    strings.Builder
+		prev tokenKind
+	)
+
+	b.Grow(len(src))
+
+	for {
+		l.skipIgnored()
+		start := l.off
+
+		tok := l.next()
+		if tok.kind == tokEOF {
+			return b.String(), nil
+		}
+
+		if isWord(prev) && isWord(tok.kind) {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(src[start:l.off])
+		prev = tok.kind
+	}
+}
+
+func isWord(k tokenKind) bool {
+	return k == tokName || k == tokInt || k == tokFloat
+}
@@ -0,0 +1,56 @@
+package graphql
+
+import "testing"
+
+func TestMinify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"Empty", "", ""},
+		{"Shorthand", "{ a }", "{a}"},
+		{
+			"Operation",
+			`
+			# comment
+			query GetUser($id: ID!, $first: Int = 10) @cached {
+				user(id: $id) {
+					friends(first: $first, list: [1, 2 3], name: "a  b, c") { ...F }
+					... on Admin { level }
+				}
+			}
+
+			fragment F on User { id, name }
+			`,
+			`query GetUser($id:ID!$first:Int=10)@cached{user(id:$id){friends(first:$first list:[1 2 3]name:"a  b, c"){...F}...on Admin{level}}}fragment F on User{id name}`,
+		},
+		{"BlockString", "{ a(s: \"\"\"\n  x\n\"\"\") }", "{a(s:\"\"\"\n  x\n\"\"\")}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Minify(tc.src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("Minify(%q) = %q, want %q", tc.src, got, tc.want)
+			}
+
+			again, err := Minify(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if again != got {
+				t.Errorf("Minify(%q) = %q, want %q", got, again, got)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		if _, err := Minify(`{ a(s: "x) }`); err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+}
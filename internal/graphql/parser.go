@@ -0,0 +1,580 @@
+package graphql
+
+import "fmt"
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func newParser(name, src string) *parser {
+	p := &parser{lex: newLexer(name, src)}
+	p.tok = p.lex.next()
+	return p
+}
+
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		e, ok := r.(*Error)
+		if !ok {
+			panic(r)
+		}
+		*err = e
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, args...), Pos: p.tok.pos})
+}
+
+func (p *parser) unexpected() {
+	if p.tok.kind == tokEOF {
+		p.errorf("unexpected %s", p.tok.kind)
+	}
+	p.errorf("unexpected %s %q", p.tok.kind, p.tok.value)
+}
+
+func (p *parser) advance() token {
+	t := p.tok
+	p.tok = p.lex.next()
+	return t
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.tok.kind == tokName && p.tok.value == keyword
+}
+
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipKeyword(keyword string) bool {
+	if p.peekKeyword(keyword) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) token {
+	if !p.peek(punct) {
+		p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) expectKeyword(keyword string) {
+	if !p.peekKeyword(keyword) {
+		p.unexpected()
+	}
+	p.advance()
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.unexpected()
+	}
+	return p.advance().value
+}
+
+// ParseQuery parses an executable document.
+func ParseQuery(src string) (*Document, error) {
+	return ParseQuerySource("", src)
+}
+
+// ParseQuerySource parses an executable document, recording name as the
+// Source of all positions in it.
+func ParseQuerySource(name, src string) (doc *Document, err error) {
+	defer recoverError(&err)
+
+	p := newParser(name, src)
+	doc = &Document{}
+
+	for {
+		switch {
+		case p.tok.kind == tokEOF:
+			if len(doc.Operations) == 0 && len(doc.Fragments) == 0 {
+				p.errorf("document contains no definitions")
+			}
+			return doc, nil
+		case p.peek("{"):
+			op := &Operation{Type: Query, Pos: p.tok.pos}
+			op.SelectionSet = p.selectionSet()
+			doc.Operations = append(doc.Operations, op)
+		case p.peekKeyword(Query), p.peekKeyword(Mutation), p.peekKeyword(Subscription):
+			doc.Operations = append(doc.Operations, p.operation())
+		case p.peekKeyword("fragment"):
+			doc.Fragments = append(doc.Fragments, p.fragment())
+		default:
+			p.unexpected()
+		}
+	}
+}
+
+func (p *parser) operation() *Operation {
+	pos := p.tok.pos
+	op := &Operation{Pos: pos, Type: p.advance().value}
+
+	if p.tok.kind == tokName {
+		op.Name = p.advance().value
+	}
+
+	if p.skip("(") {
+		for !p.skip(")") {
+			v := &VariableDefinition{Pos: p.tok.pos}
+			p.expect("$")
+			v.Name = p.name()
+			p.expect(":")
+			v.Type = p.typeRef()
+			if p.skip("=") {
+				v.DefaultValue = p.value(true)
+			}
+			p.directives()
+			op.VariableDefinitions = append(op.VariableDefinitions, v)
+		}
+	}
+
+	op.Directives = p.directives()
+	op.SelectionSet = p.selectionSet()
+
+	return op
+}
+
+func (p *parser) fragment() *Fragment {
+	f := &Fragment{Pos: p.tok.pos}
+	p.expectKeyword("fragment")
+
+	if p.peekKeyword("on") {
+		p.unexpected()
+	}
+
+	f.Name = p.name()
+	p.expectKeyword("on")
+	f.TypeCondition = p.name()
+	f.Directives = p.directives()
+	f.SelectionSet = p.selectionSet()
+
+	return f
+}
+
+func (p *parser) selectionSet() []Selection {
+	pos := p.expect("{").pos
+
+	var sels []Selection
+
+	for !p.skip("}") {
+		sels = append(sels, p.selection())
+	}
+
+	if len(sels) == 0 {
+		panic(&Error{Message: "empty selection set", Pos: pos})
+	}
+
+	return sels
+}
+
+func (p *parser) selection() Selection {
+	pos := p.tok.pos
+
+	if p.skip("...") {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			f := &FragmentSpread{Pos: pos, Name: p.name()}
+			f.Directives = p.directives()
+			return f
+		}
+
+		f := &InlineFragment{Pos: pos}
+		if p.skipKeyword("on") {
+			f.TypeCondition = p.name()
+		}
+		f.Directives = p.directives()
+		f.SelectionSet = p.selectionSet()
+
+		return f
+	}
+
+	f := &Field{Pos: pos, Name: p.name()}
+
+	if p.skip(":") {
+		f.Alias = f.Name
+		f.Name = p.name()
+	}
+
+	f.Arguments = p.arguments(false)
+	f.Directives = p.directives()
+
+	if p.peek("{") {
+		f.SelectionSet = p.selectionSet()
+	}
+
+	return f
+}
+
+func (p *parser) arguments(constant bool) []*Argument {
+	if !p.skip("(") {
+		return nil
+	}
+
+	var args []*Argument
+
+	for !p.skip(")") {
+		pos := p.tok.pos
+		a := &Argument{Pos: pos, Name: p.name()}
+		p.expect(":")
+		a.Value = p.value(constant)
+		args = append(args, a)
+	}
+
+	return args
+}
+
+func (p *parser) directives() []*Directive {
+	var dirs []*Directive
+
+	for p.peek("@") {
+		d := &Directive{Pos: p.advance().pos}
+		d.Name = p.name()
+		d.Arguments = p.arguments(false)
+		dirs = append(dirs, d)
+	}
+
+	return dirs
+}
+
+func (p *parser) value(constant bool) *Value {
+	v := &Value{Pos: p.tok.pos}
+
+	switch p.tok.kind {
+	case tokInt:
+		v.Kind, v.Raw = IntValue, p.advance().value
+	case tokFloat:
+		v.Kind, v.Raw = FloatValue, p.advance().value
+	case tokString:
+		v.Kind, v.Raw = StringValue, p.advance().value
+	case tokName:
+		switch p.tok.value {
+		case "true", "false":
+			v.Kind = BooleanValue
+		case "null":
+			v.Kind = NullValue
+		default:
+			v.Kind = EnumValue
+		}
+		v.Raw = p.advance().value
+	case tokPunct:
+		switch {
+		case p.peek("$") && !constant:
+			p.advance()
+			v.Kind, v.Raw = VariableValue, p.name()
+		case p.skip("["):
+			v.Kind = ListValue
+			for !p.skip("]") {
+				v.List = append(v.List, p.value(constant))
+			}
+		case p.skip("{"):
+			v.Kind = ObjectValue
+			for !p.skip("}") {
+				pos := p.tok.pos
+				f := &Argument{Pos: pos, Name: p.name()}
+				p.expect(":")
+				f.Value = p.value(constant)
+				v.Object = append(v.Object, f)
+			}
+		default:
+			p.unexpected()
+		}
+	default:
+		p.unexpected()
+	}
+
+	return v
+}
+
+func (p *parser) typeRef() *Type {
+	var t *Type
+
+	if p.skip("[") {
+		t = &Type{Elem: p.typeRef()}
+		p.expect("]")
+	} else {
+		t = &Type{Name: p.name()}
+	}
+
+	if p.skip("!") {
+		t.NonNull = true
+	}
+
+	return t
+}
+
+// ParseSchema parses a type system document. Type extensions are merged into
+// the types they extend. Built-in scalars are always defined.
+func ParseSchema(src string) (schema *Schema, err error) {
+	defer recoverError(&err)
+
+	p := newParser("", src)
+
+	schema = &Schema{
+		Types:      map[string]*Definition{},
+		Directives: map[string]*DirectiveDefinition{},
+	}
+
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		schema.Types[name] = &Definition{Kind: Scalar, Name: name}
+	}
+
+	var (
+		extensions   []*Definition
+		schemaDefPos *Pos
+	)
+
+	for p.tok.kind != tokEOF {
+		if p.tok.kind == tokString {
+			p.advance()
+		}
+
+		extend := p.skipKeyword("extend")
+		pos := p.tok.pos
+
+		if p.tok.kind != tokName {
+			p.unexpected()
+		}
+
+		switch keyword := p.tok.value; keyword {
+		case "schema":
+			p.advance()
+			p.directives()
+
+			if !extend {
+				if schemaDefPos != nil {
+					p.errorf("schema must be defined only once")
+				}
+				schemaDefPos = &pos
+			}
+
+			if p.skip("{") {
+				for !p.skip("}") {
+					opType := p.name()
+					p.expect(":")
+					typeName := p.name()
+
+					switch opType {
+					case Query:
+						schema.Query = typeName
+					case Mutation:
+						schema.Mutation = typeName
+					case Subscription:
+						schema.Subscription = typeName
+					default:
+						p.errorf("unknown operation type %q", opType)
+					}
+				}
+			}
+		case "directive":
+			p.advance()
+			p.expect("@")
+
+			d := &DirectiveDefinition{Name: p.name()}
+			d.Arguments = p.argumentDefinitions()
+			p.skipKeyword("repeatable")
+			p.expectKeyword("on")
+			p.skip("|")
+			d.Locations = append(d.Locations, p.name())
+			for p.skip("|") {
+				d.Locations = append(d.Locations, p.name())
+			}
+
+			schema.Directives[d.Name] = d
+		case "scalar", "type", "interface", "union", "enum", "input":
+			p.advance()
+
+			def := p.typeDefinition(keyword)
+			def.Pos = pos
+
+			if extend {
+				extensions = append(extensions, def)
+				break
+			}
+
+			if _, ok := schema.Types[def.Name]; ok && !isBuiltinScalar(def) {
+				panic(&Error{Message: fmt.Sprintf("type %q defined more than once", def.Name), Pos: pos})
+			}
+
+			schema.Types[def.Name] = def
+		default:
+			p.unexpected()
+		}
+	}
+
+	for _, ext := range extensions {
+		def, ok := schema.Types[ext.Name]
+		if !ok || def.Kind != ext.Kind {
+			return nil, &Error{Message: fmt.Sprintf("cannot extend undefined %s %q", ext.Kind, ext.Name), Pos: ext.Pos}
+		}
+
+		def.Fields = append(def.Fields, ext.Fields...)
+		def.Interfaces = append(def.Interfaces, ext.Interfaces...)
+		def.Types = append(def.Types, ext.Types...)
+		def.EnumValues = append(def.EnumValues, ext.EnumValues...)
+	}
+
+	if schemaDefPos == nil {
+		for _, root := range []struct {
+			name string
+			dst  *string
+		}{
+			{"Query", &schema.Query},
+			{"Mutation", &schema.Mutation},
+			{"Subscription", &schema.Subscription},
+		} {
+			if *root.dst == "" {
+				if _, ok := schema.Types[root.name]; ok {
+					*root.dst = root.name
+				}
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+func isBuiltinScalar(def *Definition) bool {
+	switch def.Name {
+	case "Int", "Float", "String", "Boolean", "ID":
+		return def.Kind == Scalar
+	}
+	return false
+}
+
+func (p *parser) typeDefinition(keyword string) *Definition {
+	def := &Definition{Name: p.name()}
+
+	switch keyword {
+	case "scalar":
+		def.Kind = Scalar
+		p.directives()
+	case "type", "interface":
+		def.Kind = Object
+		if keyword == "interface" {
+			def.Kind = Interface
+		}
+
+		if p.skipKeyword("implements") {
+			p.skip("&")
+			def.Interfaces = append(def.Interfaces, p.name())
+			for p.skip("&") || (p.tok.kind == tokName && p.nextIsImplementedName()) {
+				def.Interfaces = append(def.Interfaces, p.name())
+			}
+		}
+
+		p.directives()
+
+		if p.skip("{") {
+			for !p.skip("}") {
+				def.Fields = append(def.Fields, p.fieldDefinition())
+			}
+		}
+	case "union":
+		def.Kind = Union
+		p.directives()
+
+		if p.skip("=") {
+			p.skip("|")
+			def.Types = append(def.Types, p.name())
+			for p.skip("|") {
+				def.Types = append(def.Types, p.name())
+			}
+		}
+	case "enum":
+		def.Kind = Enum
+		p.directives()
+
+		if p.skip("{") {
+			for !p.skip("}") {
+				if p.tok.kind == tokString {
+					p.advance()
+				}
+				def.EnumValues = append(def.EnumValues, p.name())
+				p.directives()
+			}
+		}
+	case "input":
+		def.Kind = InputObject
+		p.directives()
+
+		if p.skip("{") {
+			for !p.skip("}") {
+				def.Fields = append(def.Fields, p.inputValueDefinition())
+			}
+		}
+	}
+
+	return def
+}
+
+// nextIsImplementedName reports whether the current name token continues a
+// legacy, whitespace-separated implements list rather than starting the
+// next definition.
+func (p *parser) nextIsImplementedName() bool {
+	switch p.tok.value {
+	case "type", "interface", "union", "enum", "input", "scalar", "schema", "directive", "extend":
+		return false
+	}
+	return true
+}
+
+func (p *parser) fieldDefinition() *FieldDefinition {
+	if p.tok.kind == tokString {
+		p.advance()
+	}
+
+	pos := p.tok.pos
+	f := &FieldDefinition{Pos: pos, Name: p.name()}
+	f.Arguments = p.argumentDefinitions()
+	p.expect(":")
+	f.Type = p.typeRef()
+	p.directives()
+
+	return f
+}
+
+func (p *parser) argumentDefinitions() []*FieldDefinition {
+	if !p.skip("(") {
+		return nil
+	}
+
+	var args []*FieldDefinition
+
+	for !p.skip(")") {
+		args = append(args, p.inputValueDefinition())
+	}
+
+	return args
+}
+
+func (p *parser) inputValueDefinition() *FieldDefinition {
+	if p.tok.kind == tokString {
+		p.advance()
+	}
+
+	pos := p.tok.pos
+	f := &FieldDefinition{Pos: pos, Name: p.name()}
+	p.expect(":")
+	f.Type = p.typeRef()
+
+	if p.skip("=") {
+		f.DefaultValue = p.value(true)
+	}
+
+	p.directives()
+
+	return f
+}
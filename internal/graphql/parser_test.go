@@ -0,0 +1,229 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Run("Operations", func(t *testing.T) {
+		doc, err := ParseQuery(`
+			# comment
+			query GetUser($id: ID!, $first: Int = 10, $tags: [String!]) @cached {
+				user(id: $id) {
+					id
+					friends: connections(first: $first, filter: {tags: $tags, kind: FRIEND, min: -1.5e3}) {
+						...UserFields
+						... on Admin { level }
+						... @include(if: true) { name }
+					}
+				}
+			}
+
+			mutation { setName(name: "a\"bé") }
+
+			fragment UserFields on User { id, name }
+		`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := len(doc.Operations), 2; got != want {
+			t.Fatalf("len(doc.Operations) = %d, want %d", got, want)
+		}
+
+		op := doc.Operations[0]
+
+		if got, want := op.Type, Query; got != want {
+			t.Errorf("op.Type = %q, want %q", got, want)
+		}
+
+		if got, want := op.Name, "GetUser"; got != want {
+			t.Errorf("op.Name = %q, want %q", got, want)
+		}
+
+		if got, want := len(op.VariableDefinitions), 3; got != want {
+			t.Fatalf("len(op.VariableDefinitions) = %d, want %d", got, want)
+		}
+
+		if got, want := op.VariableDefinitions[2].Type.String(), "[String!]"; got != want {
+			t.Errorf("type of $tags = %q, want %q", got, want)
+		}
+
+		if got, want := op.VariableDefinitions[1].DefaultValue.Raw, "10"; got != want {
+			t.Errorf("default of $first = %q, want %q", got, want)
+		}
+
+		user := op.SelectionSet[0].(*Field)
+		friends := user.SelectionSet[1].(*Field)
+
+		if got, want := friends.Alias+":"+friends.Name, "friends:connections"; got != want {
+			t.Errorf("field = %q, want %q", got, want)
+		}
+
+		if got, want := len(friends.SelectionSet), 3; got != want {
+			t.Fatalf("len(friends.SelectionSet) = %d, want %d", got, want)
+		}
+
+		if _, ok := friends.SelectionSet[0].(*FragmentSpread); !ok {
+			t.Errorf("selection is %T, want %T", friends.SelectionSet[0], &FragmentSpread{})
+		}
+
+		if f, ok := friends.SelectionSet[1].(*InlineFragment); !ok || f.TypeCondition != "Admin" {
+			t.Errorf("selection is %#v, want inline fragment on Admin", friends.SelectionSet[1])
+		}
+
+		mut := doc.Operations[1]
+
+		if got, want := mut.Type, Mutation; got != want {
+			t.Errorf("mut.Type = %q, want %q", got, want)
+		}
+
+		if got, want := mut.SelectionSet[0].(*Field).Arguments[0].Value.Raw, "a\"bé"; got != want {
+			t.Errorf("string value = %q, want %q", got, want)
+		}
+
+		if got, want := doc.Fragments[0].TypeCondition, "User"; got != want {
+			t.Errorf("fragment type condition = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Shorthand", func(t *testing.T) {
+		doc, err := ParseQuery(`{ foo }`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := doc.Operations[0].Type, Query; got != want {
+			t.Errorf("op.Type = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("SyntaxErrors", func(t *testing.T) {
+		for _, tc := range []struct {
+			src     string
+			wantErr string
+		}{
+			{"", "1:1: document contains no definitions"},
+			{"query {", "1:8: unexpected end of document"},
+			{"query { }", "1:7: empty selection set"},
+			{"{ foo(a: 01) }", "1:10: invalid number"},
+			{"{ foo(a: \"x) }", "1:10: unterminated string"},
+			{"query Foo($a Int) { foo }", `1:14: unexpected name "Int"`},
+			{"type Foo { bar: Int }", `1:1: unexpected name "type"`},
+		} {
+			_, err := ParseQuery(tc.src)
+			if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+				t.Errorf("ParseQuery(%q) err = %v, want %q", tc.src, err, tc.wantErr)
+			}
+		}
+	})
+}
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema(`
+		"""
+		The root.
+		"""
+		schema { query: Root mutation: Mutations }
+
+		directive @auth(role: String) repeatable on FIELD_DEFINITION | OBJECT
+
+		scalar Time
+
+		type Root {
+			"Returns a user."
+			user(id: ID!, active: Boolean = true): User
+			search(term: String!): [SearchResult!]!
+		}
+
+		type Mutations { noop: Boolean }
+
+		interface Node { id: ID! }
+
+		type User implements Node & Named @auth(role: "x") {
+			id: ID!
+			name: String
+		}
+
+		interface Named { name: String }
+
+		union SearchResult = | User | Org
+
+		type Org implements Node Named { id: ID! name: String }
+
+		enum Role { ADMIN "normal" USER }
+
+		input UserFilter { role: Role = USER, tags: [String!] }
+
+		extend type User { role: Role }
+	`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := schema.Query+","+schema.Mutation+","+schema.Subscription, "Root,Mutations,"; got != want {
+		t.Errorf("root types = %q, want %q", got, want)
+	}
+
+	user := schema.Types["User"]
+
+	if got, want := strings.Join(user.Interfaces, ","), "Node,Named"; got != want {
+		t.Errorf("user.Interfaces = %q, want %q", got, want)
+	}
+
+	if user.Field("role") == nil {
+		t.Error("extension field User.role not merged")
+	}
+
+	if got, want := strings.Join(schema.Types["Org"].Interfaces, ","), "Node,Named"; got != want {
+		t.Errorf("org.Interfaces = %q, want %q", got, want)
+	}
+
+	if got, want := strings.Join(schema.Types["SearchResult"].Types, ","), "User,Org"; got != want {
+		t.Errorf("union types = %q, want %q", got, want)
+	}
+
+	if got, want := strings.Join(schema.Types["Role"].EnumValues, ","), "ADMIN,USER"; got != want {
+		t.Errorf("enum values = %q, want %q", got, want)
+	}
+
+	if got, want := schema.Types["Root"].Field("search").Type.String(), "[SearchResult!]!"; got != want {
+		t.Errorf("Root.search type = %q, want %q", got, want)
+	}
+
+	if _, ok := schema.Directives["auth"]; !ok {
+		t.Error("directive @auth not defined")
+	}
+
+	if got, want := schema.Types["String"].Kind, Scalar; got != want {
+		t.Errorf("String kind = %q, want %q", got, want)
+	}
+
+	t.Run("DefaultRootTypes", func(t *testing.T) {
+		schema, err := ParseSchema(`type Query { a: Int } type Subscription { b: Int }`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := schema.Query+","+schema.Mutation+","+schema.Subscription, "Query,,Subscription"; got != want {
+			t.Errorf("root types = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			src     string
+			wantErr string
+		}{
+			{"type A { a: Int } type A { b: Int }", `1:19: type "A" defined more than once`},
+			{"extend type A { a: Int }", `1:8: cannot extend undefined OBJECT "A"`},
+			{"query { a }", `1:1: unexpected name "query"`},
+		} {
+			_, err := ParseSchema(tc.src)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ParseSchema(%q) err = %v, want %q", tc.src, err, tc.wantErr)
+			}
+		}
+	})
+}
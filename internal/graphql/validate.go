@@ -0,0 +1,280 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks the operations and fragments in doc against schema and
+// returns the errors found, ordered by position. It covers the rules that
+// catch a client drifting from a server's schema: operation types supported
+// by the schema, fields and arguments that exist, required arguments that are
+// given, leaf and composite fields selected correctly, known types in
+// fragments and variable definitions, known fragments and directives, and
+// defined and used variables. It is not a full implementation of the
+// validation section of the GraphQL spec.
+func Validate(schema *Schema, doc *Document) []error {
+	v := &validator{
+		schema:    schema,
+		fragments: map[string]*Fragment{},
+	}
+
+	for _, f := range doc.Fragments {
+		if _, ok := v.fragments[f.Name]; ok {
+			v.errorf(f.Pos, "fragment %q is defined more than once", f.Name)
+		}
+		v.fragments[f.Name] = f
+
+		if def := v.schema.Types[f.TypeCondition]; def == nil || !isComposite(def) {
+			v.errorf(f.Pos, "fragment %q cannot condition on non composite type %q", f.Name, f.TypeCondition)
+		}
+	}
+
+	names := map[string]bool{}
+
+	for _, op := range doc.Operations {
+		if op.Name != "" {
+			if names[op.Name] {
+				v.errorf(op.Pos, "operation %q is defined more than once", op.Name)
+			}
+			names[op.Name] = true
+		} else if len(doc.Operations) > 1 {
+			v.errorf(op.Pos, "anonymous operation must be the only defined operation")
+		}
+
+		v.operation(op)
+	}
+
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		return v.errs[i].(*Error).Pos.Less(v.errs[j].(*Error).Pos)
+	})
+
+	// Fragments used by several operations are validated once for each of
+	// them, so the same error may have been reported more than once.
+	var errs []error
+	for n, err := range v.errs {
+		if n > 0 && *err.(*Error) == *v.errs[n-1].(*Error) {
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+type validator struct {
+	schema    *Schema
+	fragments map[string]*Fragment
+	errs      []error
+
+	// Per operation state.
+	usedVars     map[string]bool
+	visitedFrags map[string]bool
+}
+
+func (v *validator) errorf(pos Pos, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Pos: pos})
+}
+
+func (v *validator) operation(op *Operation) {
+	var rootName string
+
+	switch op.Type {
+	case Query:
+		rootName = v.schema.Query
+	case Mutation:
+		rootName = v.schema.Mutation
+	case Subscription:
+		rootName = v.schema.Subscription
+	}
+
+	root := v.schema.Types[rootName]
+	if root == nil {
+		v.errorf(op.Pos, "schema does not support %s operations", op.Type)
+		return
+	}
+
+	v.usedVars = map[string]bool{}
+	v.visitedFrags = map[string]bool{}
+
+	defined := map[string]bool{}
+
+	for _, vd := range op.VariableDefinitions {
+		if defined[vd.Name] {
+			v.errorf(vd.Pos, "variable $%s is defined more than once", vd.Name)
+		}
+		defined[vd.Name] = true
+
+		def := v.schema.Types[vd.Type.NamedType()]
+		switch {
+		case def == nil:
+			v.errorf(vd.Pos, "variable $%s has unknown type %q", vd.Name, vd.Type.NamedType())
+		case !isInput(def):
+			v.errorf(vd.Pos, "variable $%s cannot be of non-input type %q", vd.Name, vd.Type)
+		}
+	}
+
+	v.directives(op.Directives)
+	v.selectionSet(root, op.SelectionSet, op.Type == Query)
+
+	for _, vd := range op.VariableDefinitions {
+		if !v.usedVars[vd.Name] {
+			v.errorf(vd.Pos, "variable $%s is never used", vd.Name)
+		}
+	}
+
+	var undefined []string
+	for name := range v.usedVars {
+		if !defined[name] {
+			undefined = append(undefined, name)
+		}
+	}
+	sort.Strings(undefined)
+
+	for _, name := range undefined {
+		v.errorf(op.Pos, "variable $%s is not defined by operation", name)
+	}
+}
+
+func (v *validator) selectionSet(parent *Definition, sels []Selection, queryRoot bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *Field:
+			v.field(parent, sel, queryRoot)
+		case *InlineFragment:
+			v.directives(sel.Directives)
+
+			typ := parent
+			if sel.TypeCondition != "" {
+				typ = v.schema.Types[sel.TypeCondition]
+				if typ == nil || !isComposite(typ) {
+					v.errorf(sel.Pos, "fragment cannot condition on non composite type %q", sel.TypeCondition)
+					continue
+				}
+			}
+
+			v.selectionSet(typ, sel.SelectionSet, false)
+		case *FragmentSpread:
+			v.directives(sel.Directives)
+
+			f, ok := v.fragments[sel.Name]
+			if !ok {
+				v.errorf(sel.Pos, "unknown fragment %q", sel.Name)
+				continue
+			}
+
+			if v.visitedFrags[sel.Name] {
+				continue
+			}
+			v.visitedFrags[sel.Name] = true
+
+			if typ := v.schema.Types[f.TypeCondition]; typ != nil && isComposite(typ) {
+				v.directives(f.Directives)
+				v.selectionSet(typ, f.SelectionSet, false)
+			}
+		}
+	}
+}
+
+func (v *validator) field(parent *Definition, f *Field, queryRoot bool) {
+	v.directives(f.Directives)
+
+	for _, a := range f.Arguments {
+		v.value(a.Value)
+	}
+
+	switch {
+	case f.Name == "__typename":
+		if f.SelectionSet != nil {
+			v.errorf(f.Pos, "field %q must not have a selection since type \"String!\" has no subfields", f.Name)
+		}
+		return
+	case queryRoot && (f.Name == "__schema" || f.Name == "__type"):
+		// Introspection fields are not validated.
+		return
+	}
+
+	def := parent.Field(f.Name)
+	if def == nil || parent.Kind == Union {
+		v.errorf(f.Pos, "cannot query field %q on type %q", f.Name, parent.Name)
+		return
+	}
+
+	given := map[string]bool{}
+
+	for _, a := range f.Arguments {
+		given[a.Name] = true
+
+		found := false
+		for _, ad := range def.Arguments {
+			if ad.Name == a.Name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			v.errorf(a.Pos, "unknown argument %q on field \"%s.%s\"", a.Name, parent.Name, f.Name)
+		}
+	}
+
+	for _, ad := range def.Arguments {
+		if ad.Type.NonNull && ad.DefaultValue == nil && !given[ad.Name] {
+			v.errorf(f.Pos, "field %q argument %q of type %q is required, but it was not provided", f.Name, ad.Name, ad.Type)
+		}
+	}
+
+	typ := v.schema.Types[def.Type.NamedType()]
+	if typ == nil {
+		v.errorf(f.Pos, "field \"%s.%s\" has unknown type %q", parent.Name, f.Name, def.Type.NamedType())
+		return
+	}
+
+	switch {
+	case isComposite(typ) && f.SelectionSet == nil:
+		v.errorf(f.Pos, "field %q of type %q must have a selection of subfields", f.Name, def.Type)
+	case !isComposite(typ) && f.SelectionSet != nil:
+		v.errorf(f.Pos, "field %q must not have a selection since type %q has no subfields", f.Name, def.Type)
+	case f.SelectionSet != nil:
+		v.selectionSet(typ, f.SelectionSet, false)
+	}
+}
+
+func (v *validator) directives(dirs []*Directive) {
+	for _, d := range dirs {
+		switch d.Name {
+		case "skip", "include", "deprecated", "specifiedBy", "defer", "stream", "live":
+		default:
+			if _, ok := v.schema.Directives[d.Name]; !ok {
+				v.errorf(d.Pos, "unknown directive \"@%s\"", d.Name)
+			}
+		}
+
+		for _, a := range d.Arguments {
+			v.value(a.Value)
+		}
+	}
+}
+
+func (v *validator) value(val *Value) {
+	switch val.Kind {
+	case VariableValue:
+		v.usedVars[val.Raw] = true
+	case ListValue:
+		for _, e := range val.List {
+			v.value(e)
+		}
+	case ObjectValue:
+		for _, f := range val.Object {
+			v.value(f.Value)
+		}
+	}
+}
+
+func isComposite(def *Definition) bool {
+	return def.Kind == Object || def.Kind == Interface || def.Kind == Union
+}
+
+func isInput(def *Definition) bool {
+	return def.Kind == Scalar || def.Kind == Enum || def.Kind == InputObject
+}
@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+	type Query {
+		user(id: ID!): User
+		search(term: String!, first: Int = 10): [SearchResult!]!
+		node(id: ID!): Node
+	}
+
+	type Mutation {
+		rename(id: ID!, name: String!): User
+	}
+
+	interface Node { id: ID! }
+
+	type User implements Node {
+		id: ID!
+		name: String
+		friends(first: Int): [User!]!
+		role: Role
+	}
+
+	type Org implements Node { id: ID! title: String }
+
+	union SearchResult = User | Org
+
+	enum Role { ADMIN USER }
+
+	input Filter { role: Role }
+`
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseSchema(testSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		wantErrs []string
+	}{
+		{
+			name: "Valid",
+			query: `
+				query GetUser($id: ID!, $term: String!) {
+					user(id: $id) { ...UserFields friends { id } }
+					search(term: $term) {
+						__typename
+						... on Org { title }
+						... on User { name @include(if: true) }
+					}
+					node(id: $id) { id ... on User { role } }
+					__schema { types { name } }
+				}
+
+				mutation Rename { rename(id: "1", name: "x") { id } }
+
+				fragment UserFields on User { id name }
+			`,
+		},
+		{
+			name:     "UnknownField",
+			query:    `{ user(id: 1) { id age } }`,
+			wantErrs: []string{`1:20: cannot query field "age" on type "User"`},
+		},
+		{
+			name:     "UnknownArgument",
+			query:    `{ user(id: 1, name: "x") { id } }`,
+			wantErrs: []string{`1:15: unknown argument "name" on field "Query.user"`},
+		},
+		{
+			name:     "MissingRequiredArgument",
+			query:    `{ user { id } }`,
+			wantErrs: []string{`1:3: field "user" argument "id" of type "ID!" is required, but it was not provided`},
+		},
+		{
+			name:  "LeafAndCompositeSelections",
+			query: `{ user(id: 1) { name { x } } node(id: 1) }`,
+			wantErrs: []string{
+				`1:17: field "name" must not have a selection since type "String" has no subfields`,
+				`1:30: field "node" of type "Node" must have a selection of subfields`,
+			},
+		},
+		{
+			name:     "FieldOnUnion",
+			query:    `{ search(term: "x") { id } }`,
+			wantErrs: []string{`1:23: cannot query field "id" on type "SearchResult"`},
+		},
+		{
+			name:     "UnsupportedOperationType",
+			query:    `subscription { user(id: 1) { id } }`,
+			wantErrs: []string{`1:1: schema does not support subscription operations`},
+		},
+		{
+			name:  "Variables",
+			query: `query Q($a: User, $b: Int, $c: Filter) { user(id: $d) { id } }`,
+			wantErrs: []string{
+				`1:1: variable $d is not defined by operation`,
+				`1:9: variable $a cannot be of non-input type "User"`,
+				`1:9: variable $a is never used`,
+				`1:19: variable $b is never used`,
+				`1:28: variable $c is never used`,
+			},
+		},
+		{
+			name:  "Fragments",
+			query: `{ user(id: 1) { ...Missing ... on Nope { id } } } fragment F on String { id }`,
+			wantErrs: []string{
+				`1:17: unknown fragment "Missing"`,
+				`1:28: fragment cannot condition on non composite type "Nope"`,
+				`1:51: fragment "F" cannot condition on non composite type "String"`,
+			},
+		},
+		{
+			name:     "UnknownDirective",
+			query:    `{ user(id: 1) { id @nope } }`,
+			wantErrs: []string{`1:20: unknown directive "@nope"`},
+		},
+		{
+			name:  "OperationNames",
+			query: `query A { node(id: 1) { id } } query A { node(id: 1) { id } } { node(id: 1) { id } }`,
+			wantErrs: []string{
+				`1:32: operation "A" is defined more than once`,
+				`1:63: anonymous operation must be the only defined operation`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var gotErrs []string
+			for _, err := range Validate(schema, doc) {
+				gotErrs = append(gotErrs, err.Error())
+			}
+
+			if got, want := strings.Join(gotErrs, "\n"), strings.Join(tc.wantErrs, "\n"); got != want {
+				t.Errorf("errors =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,591 @@
+// Package websocket implements the subset of the WebSocket protocol (RFC 6455)
+// needed by the GraphQL subscription transports: the opening handshake on
+// both the client and the server side, text/binary messages, fragmentation,
+// ping/pong and the closing handshake. Extensions such as compression are not
+// supported.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message types (frame opcodes).
+const (
+	TextMessage   = 0x1
+	BinaryMessage = 0x2
+	CloseMessage  = 0x8
+	PingMessage   = 0x9
+	PongMessage   = 0xA
+
+	continuationFrame = 0x0
+)
+
+// Close status codes.
+const (
+	CloseNormalClosure   = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseNoStatus        = 1005
+	CloseAbnormalClosure = 1006
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// DefaultMaxMessageSize is the default limit on the size of received messages.
+const DefaultMaxMessageSize = 32 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrProtocol is returned when the peer violates the WebSocket protocol.
+var ErrProtocol = errors.New("websocket: protocol error")
+
+// ErrMessageTooBig is returned when a received message exceeds the
+// connection's MaxMessageSize.
+var ErrMessageTooBig = errors.New("websocket: message too big")
+
+// CloseError is returned by ReadMessage when the peer has sent a close frame.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with status %d %s", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection. ReadMessage must only be called from one
+// goroutine at a time; all write methods are safe for concurrent use.
+type Conn struct {
+	// MaxMessageSize is the maximum size of a received message. Defaults to
+	// DefaultMaxMessageSize.
+	MaxMessageSize int64
+
+	conn        net.Conn
+	br          *bufio.Reader
+	client      bool
+	subprotocol string
+
+	wmu       sync.Mutex
+	closeSent bool
+
+	pongHandler func([]byte)
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, client bool, subprotocol string) *Conn {
+	return &Conn{
+		MaxMessageSize: DefaultMaxMessageSize,
+		conn:           conn,
+		br:             br,
+		client:         client,
+		subprotocol:    subprotocol,
+	}
+}
+
+// Subprotocol returns the subprotocol negotiated during the handshake.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetPongHandler sets a function that is called from ReadMessage for every
+// pong frame received.
+func (c *Conn) SetPongHandler(h func(appData []byte)) {
+	c.pongHandler = h
+}
+
+// SetReadDeadline sets the deadline for future reads.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future writes.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Close closes the underlying network connection without sending a close
+// frame. Use WriteClose first to close the connection cleanly.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// ReadMessage reads the next text or binary message. Ping frames are answered
+// automatically. If the peer sends a close frame it is echoed back, and a
+// *CloseError is returned.
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	var (
+		msgType int
+		msg     []byte
+	)
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				c.pongHandler(payload)
+			}
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNoStatus}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.WriteClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case TextMessage, BinaryMessage:
+			if msgType != 0 {
+				return 0, nil, ErrProtocol
+			}
+			msgType = op
+		case continuationFrame:
+			if msgType == 0 {
+				return 0, nil, ErrProtocol
+			}
+		default:
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(msg)+len(payload)) > c.MaxMessageSize {
+			c.WriteClose(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+
+		msg = append(msg, payload...)
+
+		if fin {
+			return msgType, msg, nil
+		}
+	}
+}
+
+// WriteMessage sends data as a single text or binary message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping frame.
+func (c *Conn) Ping(appData []byte) error {
+	return c.writeFrame(PingMessage, appData)
+}
+
+// WriteClose sends a close frame with the given status code and reason. Only
+// the first call has any effect.
+func (c *Conn) WriteClose(code int, reason string) error {
+	c.wmu.Lock()
+	sent := c.closeSent
+	c.closeSent = true
+	c.wmu.Unlock()
+
+	if sent {
+		return nil
+	}
+
+	var payload []byte
+	if code != CloseNoStatus {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	return c.writeFrameLocked(CloseMessage, payload)
+}
+
+func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = h[0]&0x80 != 0
+	op = int(h[0] & 0x0f)
+	masked := h[1]&0x80 != 0
+
+	if h[0]&0x70 != 0 || masked == c.client {
+		return false, 0, nil, ErrProtocol
+	}
+
+	size := int64(h[1] & 0x7f)
+
+	switch size {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = int64(binary.BigEndian.Uint64(b[:]))
+	}
+
+	if op >= CloseMessage && (size > 125 || !fin) {
+		return false, 0, nil, ErrProtocol
+	}
+
+	if size < 0 || size > c.MaxMessageSize {
+		c.WriteClose(CloseMessageTooBig, "")
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for n := range payload {
+			payload[n] ^= key[n%4]
+		}
+	}
+
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op int, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+
+	return c.writeFrameLocked(op, data)
+}
+
+func (c *Conn) writeFrameLocked(op int, data []byte) error {
+	frame := make([]byte, 0, 14+len(data))
+	frame = append(frame, 0x80|byte(op))
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(data) <= 125:
+		frame = append(frame, maskBit|byte(len(data)))
+	case len(data) <= 0xffff:
+		frame = append(frame, maskBit|126, byte(len(data)>>8), byte(len(data)))
+	default:
+		frame = append(frame, maskBit|127)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(len(data)))
+		frame = append(frame, b[:]...)
+	}
+
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		frame = append(frame, key[:]...)
+
+		start := len(frame)
+		frame = append(frame, data...)
+		for n := range data {
+			frame[start+n] ^= key[n%4]
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Dialer contains options for connecting to a WebSocket server.
+type Dialer struct {
+	// NetDialContext is used to create the TCP connection. If nil, a
+	// net.Dialer is used.
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// TLSClientConfig is used for wss:// URLs.
+	TLSClientConfig *tls.Config
+
+	// Subprotocols lists the subprotocols offered to the server, in order
+	// of preference.
+	Subprotocols []string
+
+	// Header is sent with the handshake request.
+	Header http.Header
+}
+
+// HandshakeError is returned by Dial when the server does not accept the
+// WebSocket handshake. Response is the server's response; its body has been
+// read into Body (up to 2048 bytes).
+type HandshakeError struct {
+	Response *http.Response
+	Body     []byte
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("websocket: bad handshake: %s", e.Response.Status)
+}
+
+// Dial connects to the WebSocket server at rawurl. The context bounds the
+// time spent establishing the connection; it has no effect on the returned
+// Conn.
+func (d *Dialer) Dial(ctx context.Context, rawurl string) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secure bool
+
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		secure = true
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported URL scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dial := d.NetDialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The watcher interrupts the handshake once ctx is done. It must have
+	// exited before the deadline is cleared, or it could set it again.
+	stop, stopped := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	stopWatcher := func() {
+		if stop != nil {
+			close(stop)
+			<-stopped
+			stop = nil
+		}
+	}
+	defer stopWatcher()
+
+	if secure {
+		cfg := &tls.Config{}
+		if d.TLSClientConfig != nil {
+			cfg = d.TLSClientConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, nil, contextError(ctx, err)
+		}
+		conn = tlsConn
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+
+	for k, vs := range d.Header {
+		req.Header[k] = vs
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, contextError(ctx, err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, contextError(ctx, err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		body := make([]byte, 2048)
+		n, _ := io.ReadFull(resp.Body, body)
+		conn.Close()
+		return nil, resp, &HandshakeError{Response: resp, Body: body[:n]}
+	}
+
+	stopWatcher()
+
+	if err := ctx.Err(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return newConn(conn, br, true, resp.Header.Get("Sec-WebSocket-Protocol")), resp, nil
+}
+
+// Upgrade performs the server side of the WebSocket handshake. The first of
+// the client's offered subprotocols that is also in subprotocols is selected.
+// If the handshake fails, an HTTP error response is sent to the client.
+func Upgrade(w http.ResponseWriter, r *http.Request, subprotocols []string) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "websocket: bad handshake", http.StatusBadRequest)
+		return nil, errors.New("websocket: bad handshake")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "websocket: missing key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+
+	var subprotocol string
+offered:
+	for _, p := range strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		p = strings.TrimSpace(p)
+		for _, s := range subprotocols {
+			if p == s {
+				subprotocol = s
+				break offered
+			}
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket: hijacking not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		resp.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	resp.WriteString("\r\n")
+
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return newConn(conn, rw.Reader, false, subprotocol), nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDialUpgrade(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := Upgrade(w, r, []string{"proto-b"})
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			for {
+				op, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if err := conn.WriteMessage(op, msg); err != nil {
+					return
+				}
+			}
+		},
+	))
+	defer ts.Close()
+
+	d := &Dialer{Subprotocols: []string{"proto-a", "proto-b"}}
+
+	conn, _, err := d.Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	if got, want := conn.Subprotocol(), "proto-b"; got != want {
+		t.Errorf("conn.Subprotocol() = %q, want %q", got, want)
+	}
+
+	var gotPong []byte
+	conn.SetPongHandler(func(p []byte) { gotPong = p })
+
+	if err := conn.Ping([]byte("ping-data")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, msg := range [][]byte{[]byte("short"), bytes.Repeat([]byte("x"), 300), bytes.Repeat([]byte("y"), 70000)} {
+		if err := conn.WriteMessage(TextMessage, msg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		op, got, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if op != TextMessage {
+			t.Errorf("op = %d, want %d", op, TextMessage)
+		}
+
+		if !bytes.Equal(got, msg) {
+			t.Errorf("echoed message of length %d differs from sent message of length %d", len(got), len(msg))
+		}
+	}
+
+	if got, want := gotPong, []byte("ping-data"); !bytes.Equal(got, want) {
+		t.Errorf("pong = %q, want %q", got, want)
+	}
+
+	if err := conn.WriteClose(CloseNormalClosure, "bye"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, _, err = conn.ReadMessage()
+
+	closeErr, ok := err.(*CloseError)
+	if !ok {
+		t.Fatalf("err is %T, want %T", err, &CloseError{})
+	}
+
+	if got, want := closeErr.Code, CloseNormalClosure; got != want {
+		t.Errorf("closeErr.Code = %d, want %d", got, want)
+	}
+}
+
+func TestDial_BadHandshake(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusForbidden)
+		},
+	))
+	defer ts.Close()
+
+	_, _, err := (&Dialer{}).Dial(context.Background(), ts.URL)
+
+	hsErr, ok := err.(*HandshakeError)
+	if !ok {
+		t.Fatalf("err is %T, want %T", err, &HandshakeError{})
+	}
+
+	if got, want := hsErr.Response.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("StatusCode = %d, want %d", got, want)
+	}
+
+	if got, want := string(hsErr.Body), "nope\n"; got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Package latency provides an http.RoundTripper adding simulated latency to
+// responses, to exercise the timeout and retry behavior of clients.
+package latency
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Distribution describes how simulated latencies are distributed.
+type Distribution interface {
+	// Sample returns a latency drawn from the distribution.
+	Sample(r *rand.Rand) time.Duration
+}
+
+// Fixed returns a Distribution that always yields d.
+func Fixed(d time.Duration) Distribution {
+	return fixed(d)
+}
+
+type fixed time.Duration
+
+func (f fixed) Sample(*rand.Rand) time.Duration {
+	return time.Duration(f)
+}
+
+// Normal returns a normal Distribution with the given mean and standard
+// deviation. Negative samples are clamped to zero.
+func Normal(mean, stddev time.Duration) Distribution {
+	return normal{mean, stddev}
+}
+
+type normal struct {
+	mean, stddev time.Duration
+}
+
+func (n normal) Sample(r *rand.Rand) time.Duration {
+	d := time.Duration(r.NormFloat64()*float64(n.stddev)) + n.mean
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// HistogramBucket is one bucket of a latency histogram: Weight is the
+// relative frequency of latencies in the range [Min, Max).
+type HistogramBucket struct {
+	Min    time.Duration
+	Max    time.Duration
+	Weight float64
+}
+
+// Histogram returns a Distribution following a recorded latency histogram,
+// e.g. one exported from a metrics system. A bucket is picked according to
+// the bucket weights and a latency is drawn uniformly from its range.
+func Histogram(buckets ...HistogramBucket) Distribution {
+	h := histogram{buckets: buckets}
+	for _, b := range buckets {
+		h.total += b.Weight
+	}
+	return h
+}
+
+type histogram struct {
+	buckets []HistogramBucket
+	total   float64
+}
+
+func (h histogram) Sample(r *rand.Rand) time.Duration {
+	if len(h.buckets) == 0 {
+		return 0
+	}
+
+	w := r.Float64() * h.total
+
+	b := h.buckets[len(h.buckets)-1]
+	for _, bucket := range h.buckets {
+		if w < bucket.Weight {
+			b = bucket
+			break
+		}
+		w -= bucket.Weight
+	}
+
+	if b.Max <= b.Min {
+		return b.Min
+	}
+
+	return b.Min + time.Duration(r.Int63n(int64(b.Max-b.Min)))
+}
+
+// Transport is an http.RoundTripper that delays every response by a
+// latency drawn from Distribution, to exercise timeout and retry behavior
+// without touching the server. It can be used outside of tests, e.g. in a
+// staging environment. The delay is cut short if the request's context is
+// done, in which case the response is discarded and the context's error is
+// returned.
+type Transport struct {
+	// Transport is the underlying transport. If nil, http.DefaultTransport
+	// is used.
+	Transport http.RoundTripper
+
+	// Distribution determines the added latency. If nil, no latency is
+	// added.
+	Distribution Distribution
+
+	// Rand is the source of randomness. If nil, a source seeded with the
+	// current time is used.
+	Rand *rand.Rand
+
+	mu   sync.Mutex
+	once sync.Once
+}
+
+// RoundTrip implements http.RoundTripper.
+func (l *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := l.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil || l.Distribution == nil {
+		return resp, err
+	}
+
+	t := time.NewTimer(l.sample())
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return resp, nil
+	case <-req.Context().Done():
+		resp.Body.Close()
+		return nil, req.Context().Err()
+	}
+}
+
+func (l *Transport) sample() time.Duration {
+	l.once.Do(func() {
+		if l.Rand == nil {
+			l.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.Distribution.Sample(l.Rand)
+}
@@ -0,0 +1,71 @@
+package latency
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+	"github.com/TV4/graphqlclient-go/graphqltest"
+)
+
+func TestDistribution(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	if got, want := Fixed(time.Second).Sample(r), time.Second; got != want {
+		t.Errorf("Fixed(1s).Sample() = %v, want %v", got, want)
+	}
+
+	for n := 0; n < 100; n++ {
+		if got := Normal(0, time.Second).Sample(r); got < 0 {
+			t.Fatalf("Normal(0, 1s).Sample() = %v, want >= 0", got)
+		}
+	}
+
+	h := Histogram(
+		HistogramBucket{Min: 0, Max: 10 * time.Millisecond, Weight: 0},
+		HistogramBucket{Min: time.Second, Max: 2 * time.Second, Weight: 1},
+	)
+
+	for n := 0; n < 100; n++ {
+		if got := h.Sample(r); got < time.Second || got >= 2*time.Second {
+			t.Fatalf("h.Sample() = %v, want in [1s, 2s)", got)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	ts := graphqltest.NewDataServer(map[string]string{"foo": "bar"})
+	defer ts.Close()
+
+	t.Run("Delay", func(t *testing.T) {
+		c := graphqlclient.New(ts.URL, &http.Client{
+			Transport: &Transport{Distribution: Fixed(20 * time.Millisecond)},
+		})
+
+		start := time.Now()
+
+		if err := c.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, min := time.Since(start), 20*time.Millisecond; got < min {
+			t.Errorf("elapsed = %v, want at least %v", got, min)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		c := graphqlclient.New(ts.URL, &http.Client{
+			Transport: &Transport{Distribution: Fixed(time.Minute)},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := c.Query(ctx, "{ foo }", nil, nil); err == nil {
+			t.Error("err is nil")
+		}
+	})
+}
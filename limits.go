@@ -0,0 +1,144 @@
+package graphqlclient
+
+import (
+	"io"
+	"strconv"
+)
+
+// DecodeLimits limits the responses decoded by a client, to protect it from
+// pathological or malicious responses. Zero fields are not limited.
+type DecodeLimits struct {
+	// MaxDepth is the maximum nesting depth of objects and arrays. The
+	// response object itself has depth 1.
+	MaxDepth int
+
+	// MaxStringLength is the maximum length of strings, including object
+	// keys, in bytes as encoded in the response.
+	MaxStringLength int
+
+	// MaxArrayLength is the maximum number of elements of arrays.
+	MaxArrayLength int
+}
+
+// DecodeLimitError is the error returned by Query when a response exceeds a
+// limit set with WithDecodeLimits.
+type DecodeLimitError struct {
+	// Limit is the name of the exceeded limit: "depth", "string length" or
+	// "array length".
+	Limit string
+
+	// Max is the value of the exceeded limit.
+	Max int
+}
+
+// Error returns a string representation of the error.
+func (e *DecodeLimitError) Error() string {
+	return "response exceeds maximum " + e.Limit + " of " + strconv.Itoa(e.Max)
+}
+
+// limitReader checks the JSON read through it against limits as it is read,
+// and fails with a *DecodeLimitError when a limit is exceeded, before the
+// decoder has buffered the offending value in full. It relies on the decoder
+// reading from it to report syntax errors.
+type limitReader struct {
+	r      io.Reader
+	limits DecodeLimits
+
+	// arrays holds, for each enclosing object and array, the number of
+	// elements seen if it's an array, or -1 if it's an object.
+	arrays []int
+
+	inString bool
+	escaped  bool
+	strLen   int
+
+	err error
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	n, err := l.r.Read(p)
+
+	for _, c := range p[:n] {
+		if l.err = l.scan(c); l.err != nil {
+			return 0, l.err
+		}
+	}
+
+	return n, err
+}
+
+func (l *limitReader) scan(c byte) error {
+	if l.inString {
+		switch {
+		case l.escaped:
+			l.escaped = false
+		case c == '\\':
+			l.escaped = true
+		case c == '"':
+			l.inString = false
+			return nil
+		}
+
+		l.strLen++
+		if max := l.limits.MaxStringLength; max > 0 && l.strLen > max {
+			return &DecodeLimitError{Limit: "string length", Max: max}
+		}
+
+		return nil
+	}
+
+	switch c {
+	case ' ', '\t', '\r', '\n', ':':
+		return nil
+	case ',':
+		if top := len(l.arrays) - 1; top >= 0 && l.arrays[top] >= 0 {
+			l.arrays[top]++
+			return l.checkArray(l.arrays[top])
+		}
+		return nil
+	case ']', '}':
+		if len(l.arrays) > 0 {
+			l.arrays = l.arrays[:len(l.arrays)-1]
+		}
+		return nil
+	}
+
+	// c starts or continues a value. The first one in an array makes it
+	// have one element.
+	if top := len(l.arrays) - 1; top >= 0 && l.arrays[top] == 0 {
+		l.arrays[top] = 1
+		if err := l.checkArray(1); err != nil {
+			return err
+		}
+	}
+
+	switch c {
+	case '"':
+		l.inString = true
+		l.strLen = 0
+	case '[', '{':
+		elems := 0
+		if c == '{' {
+			elems = -1
+		}
+
+		l.arrays = append(l.arrays, elems)
+
+		if max := l.limits.MaxDepth; max > 0 && len(l.arrays) > max {
+			return &DecodeLimitError{Limit: "depth", Max: max}
+		}
+	}
+
+	return nil
+}
+
+func (l *limitReader) checkArray(elems int) error {
+	if max := l.limits.MaxArrayLength; max > 0 && elems > max {
+		return &DecodeLimitError{Limit: "array length", Max: max}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithDecodeLimits(t *testing.T) {
+	limits := DecodeLimits{MaxDepth: 4, MaxStringLength: 8, MaxArrayLength: 3}
+
+	for _, tc := range []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"WithinLimits", http.StatusOK, `{"data":{"a":[[1,2,3],["12345678"],[]],"b":{"c":"x\"y"}}}`, "<nil>"},
+		{"Depth", http.StatusOK, `{"data":{"a":{"b":{"c":[1]}}}}`, "response exceeds maximum depth of 4"},
+		{"String", http.StatusOK, `{"data":{"a":"123456789"}}`, "response exceeds maximum string length of 8"},
+		{"EscapedString", http.StatusOK, `{"data":{"a":"1234567\"8"}}`, "response exceeds maximum string length of 8"},
+		{"Key", http.StatusOK, `{"data":{"123456789":1}}`, "response exceeds maximum string length of 8"},
+		{"Array", http.StatusOK, `{"data":{"a":[1,[],{},"x"]}}`, "response exceeds maximum array length of 3"},
+		{"Errors", http.StatusBadRequest, `{"errors":[{},{},{},{}]}`, "response exceeds maximum array length of 3"},
+		{"Large", http.StatusOK, `{"data":"` + strings.Repeat("x", 1<<20) + `"}`, "response exceeds maximum string length of 8"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient("http://example.com",
+				WithDecodeLimits(limits),
+				WithHTTPClient(&http.Client{Transport: staticTransport(tc.status, []byte(tc.body))}),
+			)
+
+			var data interface{}
+
+			err := c.Query(context.Background(), "foo-query", nil, &data)
+
+			if got, want := fmt.Sprint(err), tc.wantErr; got != want {
+				t.Fatalf("err = %q, want %q", got, want)
+			}
+
+			if err != nil {
+				var limitErr *DecodeLimitError
+				if !errors.As(err, &limitErr) {
+					t.Errorf("err is %T, want %T", err, limitErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// errNotLiveQuery is returned by Live for queries without a @live operation.
+var errNotLiveQuery = errors.New("query has no @live query operation")
+
+// LiveUpdate is a result snapshot of a live query.
+type LiveUpdate struct {
+	// Data is the latest data payload. Results with errors but no data keep
+	// the previous payload.
+	Data json.RawMessage
+
+	// Previous is the data payload of the previous update, null for the
+	// first.
+	Previous json.RawMessage
+
+	// Changed holds the dot-separated paths of the values of Data that
+	// differ from Previous, in order. Values of different types, including
+	// arrays of different lengths, are reported as a whole, so that the
+	// first update reports the empty path of the whole payload.
+	Changed []string
+
+	// Errors are the errors of the result.
+	Errors []Error
+}
+
+// LiveHandler is called with each update of a live query. If it returns an
+// error, the live query is stopped and Live returns the error.
+type LiveHandler func(update LiveUpdate) error
+
+// IsLiveQuery reports whether query has a query operation with the @live
+// directive, as served by servers pushing updated results of such queries.
+func IsLiveQuery(query string) bool {
+	doc, err := graphql.ParseQuery(query)
+	if err != nil {
+		return false
+	}
+
+	for _, op := range doc.Operations {
+		if op.Type != graphql.Query {
+			continue
+		}
+
+		for _, d := range op.Directives {
+			if d.Name == "live" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Live sends the live query with variables to the server using the
+// subscription machinery, see Subscribe, and calls handler with each result
+// snapshot pushed by the server, along with the previous one and what
+// changed. It returns an error without sending query unless it is a live
+// query, see IsLiveQuery.
+func (c *Client) Live(ctx context.Context, query string, variables map[string]interface{}, handler LiveHandler) error {
+	if !IsLiveQuery(query) {
+		return errNotLiveQuery
+	}
+
+	var (
+		prev json.RawMessage
+		old  interface{}
+	)
+
+	return c.Subscribe(ctx, query, variables, func(data json.RawMessage, errs []Error) error {
+		update := LiveUpdate{
+			Data:     prev,
+			Previous: prev,
+			Errors:   errs,
+		}
+
+		if len(data) > 0 && string(data) != "null" {
+			var v interface{}
+			if err := json.Unmarshal(data, &v); err != nil {
+				subscriptionMonitor(ctx).decodeFailure()
+				return fmt.Errorf("error decoding result: %v", err)
+			}
+
+			update.Data = data
+			update.Changed = diffJSON(old, v, "", nil)
+
+			prev, old = data, v
+		}
+
+		if update.Previous == nil {
+			update.Previous = json.RawMessage("null")
+		}
+
+		return handler(update)
+	})
+}
+
+// diffJSON appends the dot-separated paths, below path, of the values of b
+// that differ from a, both decoded JSON values, to changed.
+func diffJSON(a, b interface{}, path string, changed []string) []string {
+	switch b := b.(type) {
+	case map[string]interface{}:
+		a, ok := a.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(a)+len(b))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, ok := b[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			changed = diffJSON(a[k], b[k], joinPath(path, k), changed)
+		}
+
+		return changed
+	case []interface{}:
+		a, ok := a.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+
+		for i := range b {
+			changed = diffJSON(a[i], b[i], joinPath(path, strconv.Itoa(i)), changed)
+		}
+
+		return changed
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		changed = append(changed, path)
+	}
+
+	return changed
+}
@@ -0,0 +1,72 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIsLiveQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  bool
+	}{
+		{"query Foo @live { foo }", true},
+		{"query @live { foo }", true},
+		{"query Foo { foo }", false},
+		{"{ foo @live }", false},
+		{"subscription Foo @live { foo }", false},
+		{"query Foo @live {", false},
+	} {
+		if got, want := IsLiveQuery(tc.query), tc.want; got != want {
+			t.Errorf("IsLiveQuery(%q) = %v, want %v", tc.query, got, want)
+		}
+	}
+}
+
+func TestClient_Live(t *testing.T) {
+	transport := &scriptedTransport{connections: []scriptedConnection{{
+		events: []string{
+			`{"user":{"name":"a","friends":[1,2]}}`,
+			`{"user":{"name":"b","friends":[1,3]}}`,
+			`null`,
+			`{"user":{"name":"b","friends":[1,3,4],"age":3}}`,
+		},
+	}}}
+
+	c := NewClient("http://example.com/graphql", WithSubscriptionTransport(transport))
+
+	var updates []string
+
+	err := c.Live(context.Background(), "query User @live { user { name friends age } }", nil,
+		func(u LiveUpdate) error {
+			updates = append(updates, fmt.Sprintf("%s %s %q", u.Previous, u.Data, u.Changed))
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		`null {"user":{"name":"a","friends":[1,2]}} [""]`,
+		`{"user":{"name":"a","friends":[1,2]}} {"user":{"name":"b","friends":[1,3]}} ["user.friends.1" "user.name"]`,
+		`{"user":{"name":"b","friends":[1,3]}} {"user":{"name":"b","friends":[1,3]}} []`,
+		`{"user":{"name":"b","friends":[1,3]}} {"user":{"name":"b","friends":[1,3,4],"age":3}} ["user.age" "user.friends"]`,
+	}
+
+	if got, want := strings.Join(updates, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("updates =\n%s\nwant\n%s", got, want)
+	}
+
+	t.Run("NotLive", func(t *testing.T) {
+		err := c.Live(context.Background(), "query User { user { name } }", nil,
+			func(LiveUpdate) error { return nil },
+		)
+
+		if got, want := err, errNotLiveQuery; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
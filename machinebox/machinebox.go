@@ -0,0 +1,148 @@
+// Package machinebox mirrors the Client and Request API of
+// github.com/machinebox/graphql on top of graphqlclient, so that projects can
+// migrate to graphqlclient one call site at a time, by changing imports:
+//
+//	import graphql "github.com/TV4/graphqlclient-go/machinebox"
+//
+// Unlike machinebox/graphql, Run returns the errors of responses as a
+// *graphqlclient.ErrorResponse, holding all errors and the status code, and
+// multipart requests uploading files are not supported.
+package machinebox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// ErrFilesNotSupported is returned by Run for requests with files.
+var ErrFilesNotSupported = errors.New("graphql: multipart requests are not supported")
+
+// Client is a client for a GraphQL server.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+	client     *graphqlclient.Client
+
+	// Log is called with debug messages, if set.
+	Log func(s string)
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// NewClient returns a client for the GraphQL server at endpoint.
+func NewClient(endpoint string, opts ...ClientOption) *Client {
+	c := &Client{
+		endpoint:   endpoint,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	c.client = graphqlclient.NewClient(endpoint, graphqlclient.WithHTTPClient(c.httpClient))
+
+	return c
+}
+
+// WithHTTPClient makes the client send requests using httpclient.
+func WithHTTPClient(httpclient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpclient
+	}
+}
+
+// UseMultipartForm is accepted for compatibility, and has no effect.
+func UseMultipartForm() ClientOption {
+	return func(*Client) {}
+}
+
+// ImmediatelyCloseReqBody is accepted for compatibility, and has no effect.
+func ImmediatelyCloseReqBody() ClientOption {
+	return func(*Client) {}
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.Log != nil {
+		c.Log(fmt.Sprintf(format, args...))
+	}
+}
+
+// Run sends req to the server and decodes the data payload of the response
+// into resp, unless resp is nil.
+func (c *Client) Run(ctx context.Context, req *Request, resp interface{}) error {
+	if len(req.files) > 0 {
+		return ErrFilesNotSupported
+	}
+
+	c.logf(">> variables: %v", req.vars)
+	c.logf(">> query: %s", req.q)
+
+	return c.client.Query(ctx, req.q, req.vars, resp, func(r *http.Request) {
+		for key, values := range req.Header {
+			for _, v := range values {
+				r.Header.Add(key, v)
+			}
+		}
+	})
+}
+
+// Request is a GraphQL request.
+type Request struct {
+	q     string
+	vars  map[string]interface{}
+	files []File
+
+	// Header holds the headers sent with the request.
+	Header http.Header
+}
+
+// NewRequest returns a request for query q.
+func NewRequest(q string) *Request {
+	return &Request{
+		q:      q,
+		Header: make(http.Header),
+	}
+}
+
+// Var sets the variable key to value.
+func (req *Request) Var(key string, value interface{}) {
+	if req.vars == nil {
+		req.vars = make(map[string]interface{})
+	}
+	req.vars[key] = value
+}
+
+// Vars returns the variables of the request.
+func (req *Request) Vars() map[string]interface{} {
+	return req.vars
+}
+
+// Query returns the query of the request.
+func (req *Request) Query() string {
+	return req.q
+}
+
+// File adds a file to upload. Run returns ErrFilesNotSupported for requests
+// with files.
+func (req *Request) File(fieldname, filename string, r io.Reader) {
+	req.files = append(req.files, File{Field: fieldname, Name: filename, R: r})
+}
+
+// Files returns the files added to the request.
+func (req *Request) Files() []File {
+	return req.files
+}
+
+// File is a file to upload.
+type File struct {
+	Field string
+	Name  string
+	R     io.Reader
+}
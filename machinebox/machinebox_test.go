@@ -0,0 +1,100 @@
+package machinebox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestClient_Run(t *testing.T) {
+	var (
+		gotHeader string
+		gotBody   struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotHeader = r.Header.Get("Foo-Header")
+
+			if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if gotBody.Variables["id"] == "missing" {
+				w.Write([]byte(`{"errors":[{"message":"not found"}]}`))
+				return
+			}
+
+			w.Write([]byte(`{"data":{"user":{"name":"foo"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	var logs []string
+
+	c := NewClient(ts.URL, WithHTTPClient(http.DefaultClient))
+	c.Log = func(s string) { logs = append(logs, s) }
+
+	req := NewRequest("query ($id: ID!) { user(id: $id) { name } }")
+	req.Var("id", "1")
+	req.Header.Set("Foo-Header", "foo")
+
+	var resp struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	if err := c.Run(context.Background(), req, &resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := resp.User.Name, "foo"; got != want {
+		t.Errorf("resp.User.Name = %q, want %q", got, want)
+	}
+
+	if got, want := gotHeader, "foo"; got != want {
+		t.Errorf("Foo-Header = %q, want %q", got, want)
+	}
+
+	if got, want := gotBody.Query, req.Query(); got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+
+	if got, want := strings.Join(logs, "\n"), ">> variables: map[id:1]\n>> query: "+req.Query(); got != want {
+		t.Errorf("logs = %q, want %q", got, want)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		req := NewRequest("foo-query")
+		req.Var("id", "missing")
+
+		err := c.Run(context.Background(), req, nil)
+
+		errResp, ok := err.(*graphqlclient.ErrorResponse)
+		if !ok {
+			t.Fatalf("err = %v, want *graphqlclient.ErrorResponse", err)
+		}
+
+		if got, want := errResp.Errors[0].Message, "not found"; got != want {
+			t.Errorf("message = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		req := NewRequest("foo-query")
+		req.File("file", "foo.txt", strings.NewReader("foo"))
+
+		if got, want := c.Run(context.Background(), req, nil), ErrFilesNotSupported; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
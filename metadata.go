@@ -0,0 +1,52 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ResponseMetadata is the metadata of the response to a query, returned by
+// QueryWithMetadata, e.g. to read rate limit or Server-Timing headers.
+type ResponseMetadata struct {
+	// StatusCode and Header are those of the HTTP response. Queries sent
+	// in batches, see WithQueryBatching, have no headers.
+	StatusCode int
+	Header     http.Header
+
+	// Extensions is the undecoded "extensions" field of the response
+	// object, nil if there is none.
+	Extensions json.RawMessage
+
+	// Latency is the time the query took, including decoding the response.
+	Latency time.Duration
+}
+
+// QueryWithMetadata sends query and variables to the server as Query does,
+// and returns the metadata of the response along with the error, if any. The
+// metadata has zero fields if no response was received.
+func (c *Client) QueryWithMetadata(ctx context.Context, query string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) (*ResponseMetadata, error) {
+	meta := &ResponseMetadata{}
+
+	start := time.Now()
+	err := c.Query(contextWithResponseMetadata(ctx, meta), query, variables, data, reqOpts...)
+	meta.Latency = time.Since(start)
+
+	return meta, err
+}
+
+type responseMetadataKey struct{}
+
+// contextWithResponseMetadata returns a copy of ctx carrying meta, which
+// receives the metadata of the response to the request made with the
+// context.
+func contextWithResponseMetadata(ctx context.Context, meta *ResponseMetadata) context.Context {
+	return context.WithValue(ctx, responseMetadataKey{}, meta)
+}
+
+// responseMetadata returns the ResponseMetadata carried by ctx, if any.
+func responseMetadata(ctx context.Context) *ResponseMetadata {
+	meta, _ := ctx.Value(responseMetadataKey{}).(*ResponseMetadata)
+	return meta
+}
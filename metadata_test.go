@@ -0,0 +1,51 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryWithMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-RateLimit-Remaining", "99")
+			w.Write([]byte(`{"data":{"foo":"bar"},"extensions":{"cost":3}}`))
+		},
+	))
+	defer ts.Close()
+
+	var data struct {
+		Foo string `json:"foo"`
+	}
+
+	meta, err := NewClient(ts.URL).QueryWithMetadata(context.Background(), "{ foo }", nil, &data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data.Foo, "bar"; got != want {
+		t.Errorf("data.Foo = %q, want %q", got, want)
+	}
+
+	if got, want := meta.StatusCode, http.StatusOK; got != want {
+		t.Errorf("StatusCode = %d, want %d", got, want)
+	}
+
+	if got, want := meta.Header.Get("X-RateLimit-Remaining"), "99"; got != want {
+		t.Errorf("X-RateLimit-Remaining = %q, want %q", got, want)
+	}
+
+	if got, want := string(meta.Extensions), `{"cost":3}`; got != want {
+		t.Errorf("Extensions = %s, want %s", got, want)
+	}
+
+	if got, want := meta.Latency, 10*time.Millisecond; got < want {
+		t.Errorf("Latency = %v, want at least %v", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package graphqlclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SubscriptionMonitor tracks the health of a subscription, so that
+// subscriptions that look healthy but have silently gone stale can be
+// detected. Attach it with ContextWithSubscriptionMonitor and read its Stats
+// from any goroutine while the subscription runs. The zero value is ready to
+// use.
+type SubscriptionMonitor struct {
+	mu    sync.Mutex
+	stats SubscriptionStats
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// SubscriptionStats are the statistics of a subscription.
+type SubscriptionStats struct {
+	// Started is when the subscription was started, and LastEvent when its
+	// last event was received, zero if none was.
+	Started   time.Time
+	LastEvent time.Time
+
+	// Events is the number of events received from the server, including
+	// those dropped.
+	Events int64
+
+	// Reconnects is the number of reconnection attempts, see
+	// WithSubscriptionReconnect.
+	Reconnects int64
+
+	// DroppedEvents is the number of events dropped because the event
+	// buffer was full, see WithSubscriptionBuffer. Events not matching the
+	// subscription's filter are not counted.
+	DroppedEvents int64
+
+	// DecodeFailures is the number of events whose data could not be
+	// decoded, e.g. by SubscribeTyped.
+	DecodeFailures int64
+}
+
+// SinceLastEvent returns the time elapsed at now since the last event was
+// received, or since the subscription was started if none was.
+func (s SubscriptionStats) SinceLastEvent(now time.Time) time.Duration {
+	if s.LastEvent.IsZero() {
+		return now.Sub(s.Started)
+	}
+	return now.Sub(s.LastEvent)
+}
+
+// Stats returns the statistics of the subscription.
+func (m *SubscriptionMonitor) Stats() SubscriptionStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
+}
+
+type subscriptionMonitorKey struct{}
+
+// ContextWithSubscriptionMonitor returns a copy of ctx carrying m, which
+// tracks subscriptions made with the context. A monitor should only track
+// one subscription at a time.
+func ContextWithSubscriptionMonitor(ctx context.Context, m *SubscriptionMonitor) context.Context {
+	return context.WithValue(ctx, subscriptionMonitorKey{}, m)
+}
+
+// subscriptionMonitor returns the monitor of subscriptions made with ctx, nil
+// if none. The methods recording to monitors do nothing on nil.
+func subscriptionMonitor(ctx context.Context) *SubscriptionMonitor {
+	m, _ := ctx.Value(subscriptionMonitorKey{}).(*SubscriptionMonitor)
+	return m
+}
+
+// record applies fn to the statistics of m, unless m is nil.
+func (m *SubscriptionMonitor) record(fn func(s *SubscriptionStats)) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn(&m.stats)
+}
+
+// time returns the current time.
+func (m *SubscriptionMonitor) time() time.Time {
+	if m == nil || m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+func (m *SubscriptionMonitor) started() {
+	now := m.time()
+	m.record(func(s *SubscriptionStats) {
+		s.Started = now
+	})
+}
+
+func (m *SubscriptionMonitor) event() {
+	now := m.time()
+	m.record(func(s *SubscriptionStats) {
+		s.Events++
+		s.LastEvent = now
+	})
+}
+
+func (m *SubscriptionMonitor) reconnect() {
+	m.record(func(s *SubscriptionStats) {
+		s.Reconnects++
+	})
+}
+
+func (m *SubscriptionMonitor) dropped() {
+	m.record(func(s *SubscriptionStats) {
+		s.DroppedEvents++
+	})
+}
+
+func (m *SubscriptionMonitor) decodeFailure() {
+	m.record(func(s *SubscriptionStats) {
+		s.DecodeFailures++
+	})
+}
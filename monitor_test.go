@@ -0,0 +1,144 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionMonitor(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	newMonitor := func() *SubscriptionMonitor {
+		return &SubscriptionMonitor{now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}}
+	}
+
+	t.Run("Reconnects", func(t *testing.T) {
+		errLost := errors.New("connection lost")
+
+		p := ReconnectPolicy{}
+		p.sleep = func(context.Context, time.Duration) error { return nil }
+
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(&scriptedTransport{connections: []scriptedConnection{
+				{[]string{"1", "2"}, errLost},
+				{nil, errLost},
+				{[]string{"3"}, nil},
+			}}),
+			WithSubscriptionReconnect(p),
+		)
+
+		m := newMonitor()
+
+		err := c.Subscribe(ContextWithSubscriptionMonitor(context.Background(), m), "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s := m.Stats()
+
+		if got, want := s.Events, int64(3); got != want {
+			t.Errorf("s.Events = %d, want %d", got, want)
+		}
+
+		if got, want := s.Reconnects, int64(2); got != want {
+			t.Errorf("s.Reconnects = %d, want %d", got, want)
+		}
+
+		if got, want := s.LastEvent.Sub(s.Started), 3*time.Second; got != want {
+			t.Errorf("s.LastEvent - s.Started = %v, want %v", got, want)
+		}
+
+		if got, want := s.SinceLastEvent(s.LastEvent.Add(time.Minute)), time.Minute; got != want {
+			t.Errorf("s.SinceLastEvent = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("DroppedEvents", func(t *testing.T) {
+		var (
+			busy    = make(chan struct{})
+			release = make(chan struct{})
+		)
+
+		// The handler is busy with the first event until the others
+		// have been received.
+		transport := subscriptionTransportFunc(func(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+			handler(json.RawMessage("1"), nil)
+			<-busy
+
+			for i := 0; i < 3; i++ {
+				handler(json.RawMessage("2"), nil)
+			}
+
+			close(release)
+			return nil
+		})
+
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(transport),
+			WithSubscriptionBuffer(1, OverflowDropNewest),
+		)
+
+		m := newMonitor()
+
+		first := true
+		err := c.Subscribe(ContextWithSubscriptionMonitor(context.Background(), m), "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error {
+				if first {
+					first = false
+					close(busy)
+					<-release
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s := m.Stats()
+
+		if got, want := s.Events, int64(4); got != want {
+			t.Errorf("s.Events = %d, want %d", got, want)
+		}
+
+		if got, want := s.DroppedEvents, int64(2); got != want {
+			t.Errorf("s.DroppedEvents = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("DecodeFailures", func(t *testing.T) {
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(&scriptedTransport{connections: []scriptedConnection{
+				{events: []string{`"foo"`}},
+			}}),
+		)
+
+		m := newMonitor()
+
+		events, errc, stop := SubscribeTyped[int](ContextWithSubscriptionMonitor(context.Background(), m), c, "subscription { foo }", nil)
+		defer stop()
+
+		for range events {
+		}
+
+		if err := <-errc; err == nil {
+			t.Error("err = nil, want error")
+		}
+
+		if got, want := m.Stats().DecodeFailures, int64(1); got != want {
+			t.Errorf("DecodeFailures = %d, want %d", got, want)
+		}
+
+		if got, want := m.Stats().SinceLastEvent(m.Stats().Started.Add(time.Hour)), time.Hour-time.Second; got != want {
+			t.Errorf("SinceLastEvent = %v, want %v", got, want)
+		}
+	})
+}
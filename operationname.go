@@ -0,0 +1,64 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+type operationNameKey struct{}
+
+// ContextWithOperationName returns a copy of ctx carrying name, which clients
+// send in the "operationName" key of the request object of requests made with
+// the context, selecting the operation to execute in documents containing
+// more than one. The name is also set in the OperationName field of the
+// *ErrorResponse returned for the requests.
+func ContextWithOperationName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationNameKey{}, name)
+}
+
+// operationName returns the operation name of a request made with ctx.
+func operationName(ctx context.Context) string {
+	name, _ := ctx.Value(operationNameKey{}).(string)
+	return name
+}
+
+// withOperationName sets the OperationName of err to name if it is an
+// *ErrorResponse.
+func withOperationName(err error, name string) error {
+	var errResp *ErrorResponse
+	if name != "" && errors.As(err, &errResp) {
+		errResp.OperationName = name
+	}
+	return err
+}
+
+// writeOperationName inserts the "operationName" member with name into the
+// request object written to buf from start up to the value of "variables",
+// keeping its keys sorted.
+func writeOperationName(buf *bytes.Buffer, start int, name string) {
+	// The request object has at most one member before "variables", the
+	// query or the document ID.
+	at := start + 1
+
+	tok, _ := json.NewDecoder(bytes.NewReader(buf.Bytes()[at:])).Token()
+	if key, _ := tok.(string); key != "variables" && key < "operationName" {
+		at = buf.Len() - len(variablesKey) + 1
+	}
+
+	rest := getBuffer()
+	defer putBuffer(rest)
+
+	rest.Write(buf.Bytes()[at:])
+	buf.Truncate(at)
+
+	buf.WriteString(`"operationName":`)
+
+	// Encoding a string can't fail.
+	json.NewEncoder(buf).Encode(name)
+	buf.Truncate(buf.Len() - 1)
+
+	buf.WriteByte(',')
+	buf.Write(rest.Bytes())
+}
@@ -0,0 +1,95 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextWithOperationName(t *testing.T) {
+	var (
+		gotBody  string
+		gotQuery string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+			gotQuery = r.URL.RawQuery
+
+			w.Write([]byte(`{"errors":[{"message":"failed"}]}`))
+		},
+	))
+	defer ts.Close()
+
+	docs, err := NewTrustedDocuments(map[string]string{"1": "query GetBar { bar }"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	const query = "query GetFoo { foo } query GetBar { bar }"
+
+	ctx := ContextWithOperationName(context.Background(), "GetBar")
+
+	for _, tc := range []struct {
+		name      string
+		opts      []Option
+		query     string
+		wantBody  string
+		wantQuery string
+	}{
+		{
+			name:     "Query",
+			query:    query,
+			wantBody: `{"operationName":"GetBar","query":"query GetFoo { foo } query GetBar { bar }","variables":null}`,
+		},
+		{
+			name:     "Extensions",
+			opts:     []Option{WithExtensions(map[string]interface{}{"foo": 1})},
+			query:    query,
+			wantBody: `{"extensions":{"foo":1},"operationName":"GetBar","query":"query GetFoo { foo } query GetBar { bar }","variables":null}`,
+		},
+		{
+			name:     "TrustedDocument",
+			opts:     []Option{WithTrustedDocuments(docs), WithOmitEmptyVariables()},
+			query:    "query GetBar { bar }",
+			wantBody: `{"documentId":"1","operationName":"GetBar"}`,
+		},
+		{
+			name:      "GET",
+			opts:      []Option{WithGETQueries()},
+			query:     "{ bar }",
+			wantQuery: "operationName=GetBar&query=%7B+bar+%7D",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(ts.URL, tc.opts...)
+
+			err := c.Query(ctx, tc.query, nil, nil)
+
+			errResp, ok := err.(*ErrorResponse)
+			if !ok {
+				t.Fatalf("err = %v, want *ErrorResponse", err)
+			}
+
+			if got, want := errResp.OperationName, "GetBar"; got != want {
+				t.Errorf("OperationName = %q, want %q", got, want)
+			}
+
+			if got, want := gotBody, tc.wantBody; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+
+			if got, want := gotQuery, tc.wantQuery; got != want {
+				t.Errorf("query = %q, want %q", got, want)
+			}
+		})
+	}
+}
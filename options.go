@@ -0,0 +1,455 @@
+package graphqlclient
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// NewClient returns a new client configured by opts. Without options, the
+// client sends requests using http.DefaultClient.
+func NewClient(url string, opts ...Option) *Client {
+	c := &Client{
+		url:         url,
+		httpClient:  http.DefaultClient,
+		userAgent:   DefaultUserAgent(),
+		contentType: DefaultContentType,
+		accept:      DefaultAccept,
+		envelope:    defaultEnvelope,
+		drainPolicy: DefaultDrainPolicy,
+		stats:       &clientStats{},
+		routes:      &operationRoutes{},
+		lifecycle:   &clientLifecycle{},
+		prepared:    &preparedQueries{},
+
+		// Clients don't share connections, which may be authenticated
+		// by their connection params.
+		subscriptionTransport: &WebSocketTransport{},
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	if len(c.transportOpts) > 0 {
+		c.httpClient = tunedHTTPClient(c.httpClient, c.transportOpts)
+	}
+
+	c.jsonAccept = c.accept
+	c.wrappedTransport = c.buildTransport()
+
+	if len(c.codecs) > 0 {
+		c.accept = codecAccept(c.codecs, c.accept)
+	}
+
+	if c.noPOSTRedirects {
+		c.httpClient = withoutPOSTRedirects(c.httpClient)
+	}
+
+	return c
+}
+
+// tunedHTTPClient returns a copy of httpClient with a copy of its transport,
+// or of http.DefaultTransport, tuned by opts. httpClient is returned as is if
+// its transport is not an *http.Transport.
+func tunedHTTPClient(httpClient *http.Client, opts []func(*http.Transport)) *http.Client {
+	rt := httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return httpClient
+	}
+
+	t = t.Clone()
+	for _, o := range opts {
+		o(t)
+	}
+
+	hc := *httpClient
+	hc.Transport = t
+
+	return &hc
+}
+
+// WithHTTPClient makes the client send requests using httpClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRequestOptions adds reqOpts to the options applied to all requests.
+func WithRequestOptions(reqOpts ...func(*http.Request)) Option {
+	return func(c *Client) {
+		c.reqOpts = append(c.reqOpts, reqOpts...)
+	}
+}
+
+// WithHeader makes the client set the header key to value in all requests,
+// as a request option applied in order with those of WithRequestOptions.
+func WithHeader(key, value string) Option {
+	return WithRequestOptions(func(req *http.Request) {
+		req.Header.Set(key, value)
+	})
+}
+
+// WithTimeout makes the client give up on queries taking longer than d,
+// including the time spent waiting for a batch, see WithQueryBatching, and
+// reading the response, as if their context had the timeout. Unlike the
+// Timeout of an http.Client, it also bounds queries sent with WithTransport,
+// and shows in the timeout header, see WithTimeoutHeader.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// RequestOptionsFunc returns the options to apply to a request made with
+// ctx, e.g. setting headers from values that HTTP middleware stored in ctx.
+type RequestOptionsFunc func(ctx context.Context) []func(*http.Request)
+
+// WithContextRequestOptions registers fn to derive options from the context
+// of each request. They are applied after the options applied to all
+// requests and before those passed to Query, so that values carried by the
+// context can influence requests made far from where they were set:
+//
+//	graphqlclient.WithContextRequestOptions(func(ctx context.Context) []func(*http.Request) {
+//		locale, ok := ctx.Value(localeKey{}).(string)
+//		if !ok {
+//			return nil
+//		}
+//		return []func(*http.Request){func(req *http.Request) {
+//			req.Header.Set("Accept-Language", locale)
+//		}}
+//	})
+func WithContextRequestOptions(fn RequestOptionsFunc) Option {
+	return func(c *Client) {
+		c.ctxReqOpts = append(c.ctxReqOpts, fn)
+	}
+}
+
+// applyContextRequestOptions applies the options derived from the context of
+// req.
+func (c *Client) applyContextRequestOptions(req *http.Request) {
+	for _, fn := range c.ctxReqOpts {
+		for _, o := range fn(req.Context()) {
+			o(req)
+		}
+	}
+}
+
+// WithOmitEmptyVariables makes the client leave the "variables" key out of
+// request bodies if variables is nil or empty, instead of sending null or {},
+// for servers that reject those.
+func WithOmitEmptyVariables() Option {
+	return func(c *Client) {
+		c.omitEmptyVariables = true
+	}
+}
+
+// WithOmitNullVariables makes the client leave out variables whose values
+// are nil, or nil pointers, maps, slices or interfaces, instead of sending
+// them as null, so that the server uses their default values. Combined with
+// WithOmitEmptyVariables, the "variables" key is left out if all variables
+// are null.
+func WithOmitNullVariables() Option {
+	return func(c *Client) {
+		c.omitNullVariables = true
+	}
+}
+
+// withoutNullVariables returns variables without those whose values encode
+// as null. variables is returned as is if it has none.
+func withoutNullVariables(variables map[string]interface{}) map[string]interface{} {
+	var filtered map[string]interface{}
+
+	for name, v := range variables {
+		if !isNull(v) {
+			continue
+		}
+
+		if filtered == nil {
+			filtered = make(map[string]interface{}, len(variables))
+			for name, v := range variables {
+				filtered[name] = v
+			}
+		}
+
+		delete(filtered, name)
+	}
+
+	if filtered == nil {
+		return variables
+	}
+
+	return filtered
+}
+
+// isNull reports whether v is nil or a nil pointer, map, slice or interface.
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+// DrainPolicy is how much of the rest of a response body, left after its
+// response object has been decoded, is read and discarded before closing the
+// body. HTTP/1.x connections can only be reused for further requests once
+// their previous response has been read to its end, so draining trades
+// reading unwanted bytes, e.g. trailing whitespace or the tail of a response
+// abandoned because of an error, against opening new connections.
+type DrainPolicy int64
+
+const (
+	// DrainNone closes bodies without reading the rest of them.
+	DrainNone DrainPolicy = 0
+
+	// DrainFull reads bodies to their end, however long.
+	DrainFull DrainPolicy = -1
+
+	// DefaultDrainPolicy reads up to 64 bytes, enough for trailing
+	// whitespace.
+	DefaultDrainPolicy DrainPolicy = 64
+)
+
+// DrainUpTo returns the DrainPolicy reading up to n bytes. A non-positive n
+// means DrainNone.
+func DrainUpTo(n int64) DrainPolicy {
+	if n <= 0 {
+		return DrainNone
+	}
+	return DrainPolicy(n)
+}
+
+// drain reads and discards the rest of body according to p.
+func (p DrainPolicy) drain(body io.Reader) {
+	switch {
+	case p < 0:
+		io.Copy(ioutil.Discard, body)
+	case p > 0:
+		io.CopyN(ioutil.Discard, body, int64(p))
+	}
+}
+
+// WithDrainPolicy makes the client drain response bodies according to p
+// instead of DefaultDrainPolicy.
+func WithDrainPolicy(p DrainPolicy) Option {
+	return func(c *Client) {
+		c.drainPolicy = p
+	}
+}
+
+// DefaultContentType is the Content-Type header sent by clients created
+// without WithContentType.
+const DefaultContentType = "application/json; charset=utf-8"
+
+// WithContentType makes the client send contentType in the Content-Type
+// header of requests instead of DefaultContentType, e.g. application/json
+// for servers rejecting the charset parameter. The body is JSON regardless.
+func WithContentType(contentType string) Option {
+	return func(c *Client) {
+		c.contentType = contentType
+	}
+}
+
+// DefaultAccept is the Accept header sent by clients created without
+// WithAccept, preferring application/graphql-response+json responses, as
+// recommended by the GraphQL over HTTP specification, over application/json
+// responses for servers not supporting them.
+const DefaultAccept = "application/graphql-response+json, application/json;q=0.9"
+
+// WithAccept makes the client send accept in the Accept header of requests
+// instead of DefaultAccept, e.g. application/json for servers rejecting
+// other media types. An empty accept means no Accept header is sent.
+func WithAccept(accept string) Option {
+	return func(c *Client) {
+		c.accept = accept
+	}
+}
+
+// WithEnvelopeFields makes the client decode the data payload and the errors
+// of response objects from the fields named dataField and errorsField instead
+// of "data" and "errors", for servers not following the GraphQL
+// specification, e.g. WithEnvelopeFields("result", "problems"). Field names
+// are matched case-insensitively, and empty names keep the defaults. The
+// errors must still be error objects, or as accepted by
+// WithLenientResponses.
+func WithEnvelopeFields(dataField, errorsField string) Option {
+	return func(c *Client) {
+		if dataField != "" {
+			c.envelope.data = dataField
+		}
+		if errorsField != "" {
+			c.envelope.errors = errorsField
+		}
+	}
+}
+
+// WithLenientResponses makes the client tolerate servers that don't follow
+// the GraphQL specification for the "errors" field of responses, instead of
+// failing to decode their responses:
+//
+//   - null items of the "errors" array are ignored, so that an array of
+//     only null items doesn't make Query return an error
+//   - string items are taken as errors with that message
+//   - a single error object or string, not in an array, is taken as an array
+//     of that one item
+//
+// Fields other than "data" and "errors" are always ignored, and a null or
+// empty "errors" array is never reported as an error.
+func WithLenientResponses() Option {
+	return func(c *Client) {
+		c.lenient = true
+	}
+}
+
+// WithApolloClientAwareness makes the client send name and version in the
+// apollographql-client-name and apollographql-client-version headers of all
+// requests, which Apollo GraphOS uses to attribute operations to clients.
+// An empty version is not sent.
+func WithApolloClientAwareness(name, version string) Option {
+	return WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("apollographql-client-name", name)
+		if version != "" {
+			req.Header.Set("apollographql-client-version", version)
+		}
+	})
+}
+
+// WithStreamingRequests makes the client stream request bodies to the server
+// as they are encoded, instead of encoding them into memory first, so that
+// sending large variables doesn't require holding the whole body in memory.
+//
+// Streamed bodies are sent with chunked transfer encoding and are not encoded
+// canonically: the fields of structs are encoded in the order they are
+// declared in. Since a streamed body can't be sent again, requests are not
+// retried on redirects that require resending the body.
+func WithStreamingRequests() Option {
+	return func(c *Client) {
+		c.streamRequests = true
+	}
+}
+
+// DefaultGzipThreshold is a request body size, in bytes, below which
+// compressing the body typically costs more than it saves.
+const DefaultGzipThreshold = 1024
+
+// WithGzipRequests makes the client compress request bodies of at least
+// threshold bytes with gzip and send them with the Content-Encoding header set
+// to gzip. Small bodies are sent uncompressed, since compressing them costs
+// more time than is saved sending them; DefaultGzipThreshold is a reasonable
+// threshold. The server must accept compressed requests.
+//
+// The size of streamed request bodies is not known before they are sent, so
+// clients created with WithStreamingRequests compress all request bodies.
+func WithGzipRequests(threshold int) Option {
+	return func(c *Client) {
+		if threshold < 0 {
+			threshold = 0
+		}
+		c.gzipRequests = true
+		c.gzipThreshold = threshold
+	}
+}
+
+// WithResponseSpilling makes the client write response bodies larger than
+// threshold bytes to a temporary file in dir, or in the default directory for
+// temporary files if dir is empty, and decode them from the file, which is
+// removed once Query returns. This is meant for export-style queries with
+// responses of hundreds of megabytes: the body is read from the connection as
+// fast as the network allows while holding at most threshold bytes of it in
+// memory, at the cost of writing it to disk and reading it back.
+//
+// Since encoding/json reads each value it decodes in full, the data payload
+// itself is still held in memory while it is decoded.
+func WithResponseSpilling(dir string, threshold int64) Option {
+	return func(c *Client) {
+		c.spillResponses = true
+		c.spillDir = dir
+		c.spillThreshold = threshold
+	}
+}
+
+// WithDecodeLimits makes the client check responses against limits as they
+// are decoded. Query fails with a *DecodeLimitError when a response exceeds
+// a limit.
+func WithDecodeLimits(limits DecodeLimits) Option {
+	return func(c *Client) {
+		c.decodeLimits = &limits
+	}
+}
+
+// The following options tune the transport of the client. Since
+// http.DefaultTransport keeps only 2 idle connections per host, services
+// sending many concurrent requests to one server typically want to raise
+// MaxIdleConnsPerHost. The client's transport, or http.DefaultTransport if the
+// HTTP client has none, is copied and the copy is tuned; the HTTP client
+// passed to WithHTTPClient is left unchanged. The options have no effect if
+// the transport is not an *http.Transport.
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections kept
+// per host. See http.Transport.MaxIdleConnsPerHost.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return withTransport(func(t *http.Transport) {
+		t.MaxIdleConnsPerHost = n
+		if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+			t.MaxIdleConns = n
+		}
+	})
+}
+
+// WithIdleConnTimeout sets how long idle connections are kept. See
+// http.Transport.IdleConnTimeout.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return withTransport(func(t *http.Transport) {
+		t.IdleConnTimeout = d
+	})
+}
+
+// WithTLSHandshakeTimeout sets the maximum time waiting for a TLS handshake.
+// See http.Transport.TLSHandshakeTimeout.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return withTransport(func(t *http.Transport) {
+		t.TLSHandshakeTimeout = d
+	})
+}
+
+// WithForceAttemptHTTP2 sets whether HTTP/2 is attempted even with a custom
+// dialer or TLS config. See http.Transport.ForceAttemptHTTP2.
+func WithForceAttemptHTTP2(force bool) Option {
+	return withTransport(func(t *http.Transport) {
+		t.ForceAttemptHTTP2 = force
+	})
+}
+
+// WithTransportStats makes the client track its connections and the data
+// transferred on them, reported by Client.TransportStats. Connections are
+// dialed through a wrapper counting the bytes read and written.
+func WithTransportStats() Option {
+	return func(c *Client) {
+		c.transportStats = &transportStats{}
+		withTransport(c.transportStats.instrument)(c)
+	}
+}
+
+func withTransport(f func(*http.Transport)) Option {
+	return func(c *Client) {
+		c.transportOpts = append(c.transportOpts, f)
+	}
+}
@@ -0,0 +1,711 @@
+package graphqlclient
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":"` + r.Header.Get("Foo-Header") + `"}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("Foo-Header", "foo")
+	}))
+
+	var data string
+
+	if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data, "foo"; got != want {
+		t.Errorf("data = %q, want %q", got, want)
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":"` + r.Header.Get("Foo-Header") + `"}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithHeader("Foo-Header", "foo"), WithRequestOptions(func(req *http.Request) {
+		req.Header.Set("Foo-Header", req.Header.Get("Foo-Header")+"bar")
+	}))
+
+	var data string
+
+	if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data, "foobar"; got != want {
+		t.Errorf("data = %q, want %q", got, want)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		},
+	))
+	defer ts.Close()
+	defer close(release)
+
+	c := NewClient(ts.URL, WithTimeout(10*time.Millisecond))
+
+	err := c.Query(context.Background(), "foo-query", nil, nil)
+
+	if got, want := fmt.Sprint(err), context.DeadlineExceeded.Error(); !strings.Contains(got, want) {
+		t.Errorf("err = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestWithLenientResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		body       string
+		wantStrict string
+		wantErr    string
+	}{
+		{"NullItems", `{"errors":[null],"data":{}}`, "200 OK: ", "<nil>"},
+		{"NullItemsWithError", `{"errors":[null,{"message":"msg"}],"data":{}}`, "200 OK: ", "200 OK: msg"},
+		{"StringItems", `{"errors":["msg"],"data":{}}`, "error decoding response: json: cannot unmarshal string", "200 OK: msg"},
+		{"Object", `{"errors":{"message":"msg"},"data":{}}`, "error decoding response: json: cannot unmarshal object", "200 OK: msg"},
+		{"String", `{"errors":"msg","data":{}}`, "error decoding response: json: cannot unmarshal string", "200 OK: msg"},
+		{"NullErrors", `{"errors":null,"data":{},"extra":1}`, "<nil>", "<nil>"},
+		{"Number", `{"errors":1,"data":{}}`, "error decoding response: json: cannot unmarshal number", "error decoding response: json: cannot unmarshal number"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hc := WithHTTPClient(&http.Client{Transport: staticTransport(http.StatusOK, []byte(tc.body))})
+
+			for _, c := range []struct {
+				client *Client
+				want   string
+			}{
+				{NewClient("http://example.com", hc), tc.wantStrict},
+				{NewClient("http://example.com", hc, WithLenientResponses()), tc.wantErr},
+			} {
+				var data interface{}
+
+				err := c.client.Query(context.Background(), "foo-query", nil, &data)
+
+				if got, want := fmt.Sprint(err), c.want; !strings.HasPrefix(got, want) {
+					t.Errorf("err = %q (lenient: %v), want %q", got, c.client.lenient, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithApolloClientAwareness(t *testing.T) {
+	var header http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithApolloClientAwareness("foo-service", "1.2.3"))
+
+	if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := header.Get("apollographql-client-name"), "foo-service"; got != want {
+		t.Errorf("apollographql-client-name = %q, want %q", got, want)
+	}
+
+	if got, want := header.Get("apollographql-client-version"), "1.2.3"; got != want {
+		t.Errorf("apollographql-client-version = %q, want %q", got, want)
+	}
+}
+
+func TestWithContextRequestOptions(t *testing.T) {
+	var header http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	type tenantKey struct{}
+
+	c := NewClient(ts.URL,
+		WithRequestOptions(func(req *http.Request) {
+			req.Header.Set("X-Tenant", "default")
+			req.Header.Set("X-Source", "client")
+		}),
+		WithContextRequestOptions(func(ctx context.Context) []func(*http.Request) {
+			tenant, ok := ctx.Value(tenantKey{}).(string)
+			if !ok {
+				return nil
+			}
+			return []func(*http.Request){func(req *http.Request) {
+				req.Header.Set("X-Tenant", tenant)
+				req.Header.Set("X-Source", "context")
+			}}
+		}),
+	)
+
+	for _, tc := range []struct {
+		name       string
+		ctx        context.Context
+		reqOpts    []func(*http.Request)
+		wantTenant string
+		wantSource string
+	}{
+		{"NoValue", context.Background(), nil, "default", "client"},
+		{"Value", context.WithValue(context.Background(), tenantKey{}, "foo"), nil, "foo", "context"},
+		{
+			"QueryOptions",
+			context.WithValue(context.Background(), tenantKey{}, "foo"),
+			[]func(*http.Request){func(req *http.Request) { req.Header.Set("X-Source", "query") }},
+			"foo",
+			"query",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := c.Query(tc.ctx, "foo-query", nil, nil, tc.reqOpts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := header.Get("X-Tenant"), tc.wantTenant; got != want {
+				t.Errorf("X-Tenant = %q, want %q", got, want)
+			}
+
+			if got, want := header.Get("X-Source"), tc.wantSource; got != want {
+				t.Errorf("X-Source = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWithOmitEmptyVariables(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = strings.TrimSpace(string(b))
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	var nilPtr *int
+
+	for _, tc := range []struct {
+		name      string
+		opts      []Option
+		variables map[string]interface{}
+		want      string
+	}{
+		{"Default", nil, nil, `{"query":"foo-query","variables":null}`},
+		{"Nil", []Option{WithOmitEmptyVariables()}, nil, `{"query":"foo-query"}`},
+		{"Empty", []Option{WithOmitEmptyVariables()}, map[string]interface{}{}, `{"query":"foo-query"}`},
+		{"Variables", []Option{WithOmitEmptyVariables()}, map[string]interface{}{"a": 1}, `{"query":"foo-query","variables":{"a":1}}`},
+		{"Streaming", []Option{WithOmitEmptyVariables(), WithStreamingRequests()}, nil, `{"query":"foo-query"}`},
+		{
+			"NullVariables",
+			[]Option{WithOmitNullVariables()},
+			map[string]interface{}{"a": 1, "b": nil, "c": nilPtr, "d": []int(nil)},
+			`{"query":"foo-query","variables":{"a":1}}`,
+		},
+		{
+			"AllNullVariables",
+			[]Option{WithOmitNullVariables(), WithOmitEmptyVariables()},
+			map[string]interface{}{"b": nil},
+			`{"query":"foo-query"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := NewClient(ts.URL, tc.opts...).Query(context.Background(), "foo-query", tc.variables, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := gotBody, tc.want; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("TrustedDocuments", func(t *testing.T) {
+		docs, err := NewTrustedDocuments(map[string]string{"a1": "{ foo }"}, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		c := NewClient(ts.URL, WithTrustedDocuments(docs), WithOmitEmptyVariables())
+
+		if err := c.Query(context.Background(), "{ foo }", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, `{"documentId":"a1"}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+	})
+}
+
+func TestWithDrainPolicy(t *testing.T) {
+	response := `{"data":{}}` + strings.Repeat(" ", 100000)
+
+	// bytesRead returns the number of bytes of the response read by a
+	// client created with opts.
+	bytesRead := func(t *testing.T, opts ...Option) int {
+		body := strings.NewReader(response)
+
+		transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(body),
+				Request:    req,
+			}, nil
+		})
+
+		opts = append(opts, WithHTTPClient(&http.Client{Transport: transport}))
+
+		if err := NewClient("http://example.com", opts...).Query(context.Background(), "foo-query", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return len(response) - body.Len()
+	}
+
+	// The decoder reads ahead of the response object.
+	decoded := bytesRead(t, WithDrainPolicy(DrainNone))
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{"Default", nil, decoded + 64},
+		{"UpTo", []Option{WithDrainPolicy(DrainUpTo(1000))}, decoded + 1000},
+		{"Full", []Option{WithDrainPolicy(DrainFull)}, len(response)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := bytesRead(t, tc.opts...), tc.want; got != want {
+				t.Errorf("bytes read = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestWithContentType(t *testing.T) {
+	var header http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name            string
+		opts            []Option
+		wantContentType string
+		wantAccept      string
+	}{
+		{"Default", nil, "application/json; charset=utf-8", DefaultAccept},
+		{"ContentType", []Option{WithContentType("application/json")}, "application/json", DefaultAccept},
+		{"Accept", []Option{WithAccept("application/json")}, "application/json; charset=utf-8", "application/json"},
+		{"NoAccept", []Option{WithAccept("")}, "application/json; charset=utf-8", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := NewClient(ts.URL, tc.opts...).Query(context.Background(), "foo-query", nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := header.Get("Content-Type"), tc.wantContentType; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+
+			if got, want := header.Get("Accept"), tc.wantAccept; got != want {
+				t.Errorf("Accept = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWithEnvelopeFields(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("fail") != "" {
+				w.Write([]byte(`{"Result":null,"problems":[{"message":"oops"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"foo":"ignored"},"Result":{"foo":"bar"}}`))
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithEnvelopeFields("result", "problems"))
+
+	var data struct {
+		Foo string `json:"foo"`
+	}
+
+	if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data.Foo, "bar"; got != want {
+		t.Errorf("data.Foo = %q, want %q", got, want)
+	}
+
+	err := c.Query(context.Background(), "foo-query", nil, &data, func(req *http.Request) {
+		req.URL.RawQuery = "fail=1"
+	})
+
+	if got, want := fmt.Sprint(err), "200 OK: oops"; got != want {
+		t.Errorf("err = %q, want %q", got, want)
+	}
+}
+
+func TestWithStreamingRequests(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			gotContentLength int64
+			gotBody          struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+		)
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				gotContentLength = r.ContentLength
+
+				if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithStreamingRequests())
+
+		big := strings.Repeat("x", 1<<20)
+
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		if err := c.Query(context.Background(), "foo-query", map[string]interface{}{"big": big}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+
+		if got, want := gotContentLength, int64(-1); got != want {
+			t.Errorf("r.ContentLength = %d, want %d", got, want)
+		}
+
+		if got, want := gotBody.Query, "foo-query"; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+
+		if got, want := gotBody.Variables["big"], big; got != want {
+			t.Errorf("len(variables.big) = %d, want %d", len(got.(string)), len(want))
+		}
+	})
+
+	t.Run("EncodingError", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{}}`))
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithStreamingRequests())
+
+		err := c.Query(context.Background(), "foo-query", map[string]interface{}{"ch": make(chan int)}, nil)
+
+		if got, want := err.Error(), "error encoding variables: json: unsupported type: chan int"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestWithGzipRequests(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         []Option
+		variables    map[string]interface{}
+		wantEncoding string
+	}{
+		{"BelowThreshold", []Option{WithGzipRequests(DefaultGzipThreshold)}, map[string]interface{}{"foo": "bar"}, ""},
+		{"AboveThreshold", []Option{WithGzipRequests(DefaultGzipThreshold)}, map[string]interface{}{"foo": strings.Repeat("x", 2048)}, "gzip"},
+		{"ZeroThreshold", []Option{WithGzipRequests(0)}, map[string]interface{}{"foo": "bar"}, "gzip"},
+		{"Streaming", []Option{WithGzipRequests(DefaultGzipThreshold), WithStreamingRequests()}, map[string]interface{}{"foo": "bar"}, "gzip"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				gotEncoding string
+				gotBody     struct {
+					Query     string                 `json:"query"`
+					Variables map[string]interface{} `json:"variables"`
+				}
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					gotEncoding = r.Header.Get("Content-Encoding")
+
+					body := r.Body
+					if gotEncoding == "gzip" {
+						zr, err := gzip.NewReader(r.Body)
+						if err != nil {
+							t.Errorf("unexpected error: %v", err)
+							return
+						}
+						body = zr
+					}
+
+					if err := json.NewDecoder(body).Decode(&gotBody); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+
+					w.Write([]byte(`{"data":{}}`))
+				},
+			))
+			defer ts.Close()
+
+			c := NewClient(ts.URL, tc.opts...)
+
+			for n := 0; n < 2; n++ {
+				if err := c.Query(context.Background(), "foo-query", tc.variables, nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if got, want := gotEncoding, tc.wantEncoding; got != want {
+					t.Errorf("Content-Encoding = %q, want %q", got, want)
+				}
+
+				if got, want := gotBody.Variables["foo"], tc.variables["foo"]; got != want {
+					t.Errorf("variables.foo = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWithGzipRequests(b *testing.B) {
+	c := NewClient("http://example.com",
+		WithGzipRequests(0),
+		WithHTTPClient(&http.Client{Transport: staticTransport(http.StatusOK, []byte(`{"data":{}}`))}),
+	)
+
+	variables := map[string]interface{}{"foo": strings.Repeat("x", 4096)}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if err := c.Query(context.Background(), "foo-query", variables, nil); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	t.Run("DefaultTransport", func(t *testing.T) {
+		c := NewClient("http://example.com",
+			WithMaxIdleConnsPerHost(200),
+			WithIdleConnTimeout(time.Minute),
+			WithTLSHandshakeTimeout(3*time.Second),
+			WithForceAttemptHTTP2(false),
+		)
+
+		tr, ok := c.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("transport is %T, want %T", c.httpClient.Transport, &http.Transport{})
+		}
+
+		if tr == http.DefaultTransport {
+			t.Fatal("http.DefaultTransport was modified")
+		}
+
+		if got, want := tr.MaxIdleConnsPerHost, 200; got != want {
+			t.Errorf("MaxIdleConnsPerHost = %d, want %d", got, want)
+		}
+
+		if got, want := tr.MaxIdleConns, 200; got != want {
+			t.Errorf("MaxIdleConns = %d, want %d", got, want)
+		}
+
+		if got, want := tr.IdleConnTimeout, time.Minute; got != want {
+			t.Errorf("IdleConnTimeout = %v, want %v", got, want)
+		}
+
+		if got, want := tr.TLSHandshakeTimeout, 3*time.Second; got != want {
+			t.Errorf("TLSHandshakeTimeout = %v, want %v", got, want)
+		}
+
+		if got, want := tr.ForceAttemptHTTP2, false; got != want {
+			t.Errorf("ForceAttemptHTTP2 = %v, want %v", got, want)
+		}
+
+		if got, want := http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost, 0; got != want {
+			t.Errorf("http.DefaultTransport.MaxIdleConnsPerHost = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("HTTPClient", func(t *testing.T) {
+		base := &http.Transport{IdleConnTimeout: time.Second}
+		hc := &http.Client{Transport: base, Timeout: 5 * time.Second}
+
+		c := NewClient("http://example.com", WithMaxIdleConnsPerHost(10), WithHTTPClient(hc))
+
+		if c.httpClient == hc || hc.Transport != base {
+			t.Fatal("HTTP client was modified")
+		}
+
+		if got, want := c.httpClient.Timeout, 5*time.Second; got != want {
+			t.Errorf("Timeout = %v, want %v", got, want)
+		}
+
+		tr := c.httpClient.Transport.(*http.Transport)
+
+		if got, want := tr.MaxIdleConnsPerHost, 10; got != want {
+			t.Errorf("MaxIdleConnsPerHost = %d, want %d", got, want)
+		}
+
+		if got, want := tr.IdleConnTimeout, time.Second; got != want {
+			t.Errorf("IdleConnTimeout = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("CustomTransport", func(t *testing.T) {
+		hc := &http.Client{Transport: roundTripFunc(nil)}
+
+		c := NewClient("http://example.com", WithHTTPClient(hc), WithIdleConnTimeout(time.Second))
+
+		if c.httpClient != hc {
+			t.Error("HTTP client was replaced")
+		}
+	})
+}
+
+// dirListing records the names of the files in dir when decoded.
+type dirListing struct {
+	dir   string
+	files []string
+}
+
+func (l *dirListing) UnmarshalJSON(b []byte) error {
+	files, err := ioutil.ReadDir(l.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		l.files = append(l.files, f.Name())
+	}
+
+	return nil
+}
+
+func TestWithResponseSpilling(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Variables struct {
+					Size int `json:"size"`
+				} `json:"variables"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			w.Write([]byte(`{"data":"` + strings.Repeat("x", body.Variables.Size) + `"}`))
+		},
+	))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name      string
+		size      int
+		wantFiles int
+	}{
+		{"Small", 10, 0},
+		{"Large", 4096, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			c := NewClient(ts.URL, WithResponseSpilling(dir, 1024))
+
+			data := &dirListing{dir: dir}
+
+			if err := c.Query(context.Background(), "foo-query", map[string]interface{}{"size": tc.size}, data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := len(data.files), tc.wantFiles; got != want {
+				t.Errorf("files while decoding = %d, want %d", got, want)
+			}
+
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := len(files), 0; got != want {
+				t.Errorf("files after Query = %d, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("Data", func(t *testing.T) {
+		c := NewClient(ts.URL, WithResponseSpilling(t.TempDir(), 16))
+
+		var data string
+
+		if err := c.Query(context.Background(), "foo-query", map[string]interface{}{"size": 100}, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data, strings.Repeat("x", 100); got != want {
+			t.Errorf("data = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,907 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Pagination describes how a paginated query advances from one page to the
+// next.
+type Pagination interface {
+	// Start sets the variables needed to fetch the first page in vars,
+	// which hold the variables the query was given.
+	Start(vars map[string]interface{})
+
+	// Advance inspects the data of the page just fetched and reports
+	// whether there is another page. If there is, it sets the variables
+	// needed to fetch it in vars.
+	Advance(page json.RawMessage, vars map[string]interface{}) (more bool, err error)
+
+	// Nodes returns the nodes of the page.
+	Nodes(page json.RawMessage) ([]json.RawMessage, error)
+}
+
+// TotalCounter is implemented by Paginations able to tell the total number of
+// nodes over all pages from the data of a page.
+type TotalCounter interface {
+	// TotalCount returns the total number of nodes, and false if the page
+	// doesn't tell.
+	TotalCount(page json.RawMessage) (int, bool)
+}
+
+// CursorPagination is cursor-based pagination, by default Relay-style: the
+// connection's pageInfo { hasNextPage endCursor } tells whether there is
+// another page, and endCursor is passed in the after variable to fetch it.
+// The query must select pageInfo { hasNextPage endCursor } and either
+// edges { node } or nodes on the connection.
+//
+// Backends naming their pagination fields differently are supported by
+// setting the paths of the fields, e.g. for a connection with items and
+// nextToken fields:
+//
+//	&graphqlclient.CursorPagination{
+//		ConnectionPath: "listPosts",
+//		CursorPath:     "nextToken",
+//		NodesPath:      "items",
+//		CursorVariable: "nextToken",
+//	}
+type CursorPagination struct {
+	// ConnectionPath is the dot-separated path to the connection in the
+	// response data, e.g. "user.friends". The other paths are relative to
+	// it.
+	ConnectionPath string
+
+	// CursorPath is the path to the cursor of the next page. Defaults to
+	// "pageInfo.endCursor".
+	CursorPath string
+
+	// HasMorePath is the path to the boolean telling whether there is
+	// another page. If both it and CursorPath are empty, it defaults to
+	// "pageInfo.hasNextPage". If only CursorPath is set, there is another
+	// page when the cursor is neither null nor empty.
+	HasMorePath string
+
+	// NodesPath is the path to the list of nodes. If empty, the nodes are
+	// taken from nodes, or, if absent, from edges { node }.
+	NodesPath string
+
+	// TotalCountPath is the path to the total number of nodes. Defaults
+	// to "totalCount".
+	TotalCountPath string
+
+	// CursorVariable is the name of the variable the cursor is passed in.
+	// Defaults to "after".
+	CursorVariable string
+}
+
+// Cursor returns Relay-style cursor pagination of the connection at the given
+// dot-separated path.
+func Cursor(connectionPath string) *CursorPagination {
+	return &CursorPagination{ConnectionPath: connectionPath}
+}
+
+// Start implements Pagination. The first page is fetched after the cursor
+// given in the query's variables, if any.
+func (p *CursorPagination) Start(vars map[string]interface{}) {}
+
+// Advance implements Pagination.
+func (p *CursorPagination) Advance(page json.RawMessage, vars map[string]interface{}) (bool, error) {
+	conn, err := rawAtPath(page, p.ConnectionPath)
+	if err != nil {
+		return false, err
+	}
+
+	cursorPath, hasMorePath := p.CursorPath, p.HasMorePath
+	if cursorPath == "" {
+		cursorPath = "pageInfo.endCursor"
+		if hasMorePath == "" {
+			hasMorePath = "pageInfo.hasNextPage"
+		}
+	}
+
+	var cursor interface{}
+
+	err = lookupPath(conn, cursorPath, &cursor)
+	if err != nil && !errors.Is(err, ErrPathNotFound) {
+		return false, err
+	}
+
+	if hasMorePath != "" {
+		var more bool
+		if err := lookupPath(conn, hasMorePath, &more); err != nil {
+			return false, err
+		}
+
+		if !more {
+			return false, nil
+		}
+
+		if cursor == nil {
+			return false, fmt.Errorf("no cursor at %q in connection %q", cursorPath, p.ConnectionPath)
+		}
+	} else if cursor == nil || cursor == "" {
+		return false, nil
+	}
+
+	name := p.CursorVariable
+	if name == "" {
+		name = "after"
+	}
+
+	if prev, ok := vars[name]; ok && reflect.DeepEqual(prev, cursor) {
+		return false, fmt.Errorf("cursor %q did not advance", fmt.Sprint(prev))
+	}
+
+	vars[name] = cursor
+
+	return true, nil
+}
+
+// Nodes implements Pagination.
+func (p *CursorPagination) Nodes(page json.RawMessage) ([]json.RawMessage, error) {
+	if p.NodesPath != "" {
+		var nodes []json.RawMessage
+
+		if err := lookupPath(page, joinPath(p.ConnectionPath, p.NodesPath), &nodes); err != nil {
+			return nil, err
+		}
+
+		return nodes, nil
+	}
+
+	var conn struct {
+		Edges []struct {
+			Node json.RawMessage `json:"node"`
+		} `json:"edges"`
+		Nodes []json.RawMessage `json:"nodes"`
+	}
+
+	if err := lookupPath(page, p.ConnectionPath, &conn); err != nil {
+		return nil, err
+	}
+
+	if conn.Nodes != nil {
+		return conn.Nodes, nil
+	}
+
+	nodes := make([]json.RawMessage, len(conn.Edges))
+	for n, e := range conn.Edges {
+		nodes[n] = e.Node
+	}
+
+	return nodes, nil
+}
+
+// TotalCount implements TotalCounter.
+func (p *CursorPagination) TotalCount(page json.RawMessage) (int, bool) {
+	path := p.TotalCountPath
+	if path == "" {
+		path = "totalCount"
+	}
+	return totalCount(page, joinPath(p.ConnectionPath, path))
+}
+
+func totalCount(page json.RawMessage, path string) (int, bool) {
+	var n int
+	if err := lookupPath(page, path, &n); err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// OffsetPagination is pagination driven by offset and limit variables. Every
+// page is fetched with the offset advanced past the items of the previous
+// pages, until a page is the last one.
+type OffsetPagination struct {
+	// ItemsPath is the dot-separated path to the list of items in the
+	// response data, e.g. "search.results".
+	ItemsPath string
+
+	// Limit is the number of items requested per page. If 0, the limit
+	// variable given to the query, if any, is used as is.
+	Limit int
+
+	// OffsetVariable and LimitVariable are the names of the variables the
+	// offset and limit are passed in. They default to "offset" and
+	// "limit".
+	OffsetVariable string
+	LimitVariable  string
+
+	// IsLastPage reports whether page, containing items items, is the last
+	// one. If nil, a page is the last one when it contains fewer items
+	// than Limit. A page without items is always the last one.
+	IsLastPage func(page json.RawMessage, items int) bool
+
+	// TotalCountPath is the dot-separated path to the total number of
+	// items in the response data, if there is one.
+	TotalCountPath string
+}
+
+// Offset returns offset/limit pagination of the list at the given
+// dot-separated path, limit items at a time. isLastPage may be nil; see
+// OffsetPagination.
+func Offset(itemsPath string, limit int, isLastPage func(page json.RawMessage, items int) bool) *OffsetPagination {
+	return &OffsetPagination{
+		ItemsPath:  itemsPath,
+		Limit:      limit,
+		IsLastPage: isLastPage,
+	}
+}
+
+// Start implements Pagination. The first page is fetched at the offset given
+// in the query's variables, or 0.
+func (p *OffsetPagination) Start(vars map[string]interface{}) {
+	offsetVar, limitVar := p.variables()
+
+	if _, ok := vars[offsetVar]; !ok {
+		vars[offsetVar] = 0
+	}
+
+	if p.Limit > 0 {
+		vars[limitVar] = p.Limit
+	}
+}
+
+// Advance implements Pagination.
+func (p *OffsetPagination) Advance(page json.RawMessage, vars map[string]interface{}) (bool, error) {
+	items, err := p.Nodes(page)
+	if err != nil {
+		return false, err
+	}
+
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	if p.IsLastPage != nil {
+		if p.IsLastPage(page, len(items)) {
+			return false, nil
+		}
+	} else if len(items) < p.Limit {
+		return false, nil
+	}
+
+	offsetVar, _ := p.variables()
+
+	offset, err := intVariable(vars, offsetVar)
+	if err != nil {
+		return false, err
+	}
+
+	vars[offsetVar] = offset + len(items)
+
+	return true, nil
+}
+
+// Nodes implements Pagination.
+func (p *OffsetPagination) Nodes(page json.RawMessage) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+
+	if err := lookupPath(page, p.ItemsPath, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// TotalCount implements TotalCounter.
+func (p *OffsetPagination) TotalCount(page json.RawMessage) (int, bool) {
+	if p.TotalCountPath == "" {
+		return 0, false
+	}
+	return totalCount(page, p.TotalCountPath)
+}
+
+func (p *OffsetPagination) variables() (offset, limit string) {
+	offset, limit = p.OffsetVariable, p.LimitVariable
+	if offset == "" {
+		offset = "offset"
+	}
+	if limit == "" {
+		limit = "limit"
+	}
+	return offset, limit
+}
+
+// intVariable returns the integer value of the variable name in vars.
+func intVariable(vars map[string]interface{}, name string) (int, error) {
+	switch v := vars[name].(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case json.Number:
+		if n, err := strconv.Atoi(string(v)); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, fmt.Errorf("variable %q is not an integer: %v", name, vars[name])
+}
+
+// Paginator fetches the pages of a paginated query one at a time. Successive
+// calls to Next fetch the pages; the data of the current page can be read
+// with Page, Decode or Nodes. Iteration stops when there are no more pages,
+// on the first error or when the context is done. After Next returns false,
+// Err returns the error that stopped iteration, if any.
+//
+//	p := graphqlclient.NewPaginator(c, query, vars, graphqlclient.Cursor("user.friends"))
+//	for p.Next(ctx) {
+//		var page friendsPage
+//		if err := p.Decode(&page); err != nil {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Paginator struct {
+	// Prefetch is the number of pages fetched ahead of the current one,
+	// in the background while the caller processes it. If 0, pages are
+	// fetched when Next is called. It must be set before the first call
+	// to Next. When prefetching, Close must be called if iteration is
+	// abandoned before reaching the last page.
+	Prefetch int
+
+	// Throttle, if set, is waited on before fetching every page. A page
+	// rejected with 429 Too Many Requests is fetched again after waiting,
+	// up to MaxThrottledRetries times, instead of stopping iteration.
+	Throttle Throttler
+
+	// Checkpoints, if set, is where the position of the paginator is
+	// saved every time the caller moves on from a page, and loaded from
+	// on the first call to Next, so that an interrupted iteration can be
+	// resumed after the last page processed. It must be set before the
+	// first call to Next.
+	Checkpoints CheckpointStore
+
+	// OnProgress, if set, is called with the progress of the iteration
+	// every time a page has been fetched, before Next returns.
+	OnProgress func(Progress)
+
+	q          Querier
+	query      string
+	vars       map[string]interface{}
+	pagination Pagination
+	reqOpts    []func(*http.Request)
+
+	page     json.RawMessage
+	pageNum  int
+	items    int
+	nextVars map[string]interface{}
+	more     bool
+	started  bool
+	closed   bool
+	saved    int
+	err      error
+
+	results chan fetchResult
+	cancel  context.CancelFunc
+}
+
+type fetchResult struct {
+	page json.RawMessage
+	next map[string]interface{}
+	more bool
+	err  error
+}
+
+// NewPaginator returns a Paginator executing query with variables, which may
+// include the cursor or offset to start from, using q. reqOpts are passed on
+// to every call to q.Query.
+func NewPaginator(q Querier, query string, variables map[string]interface{}, pagination Pagination, reqOpts ...func(*http.Request)) *Paginator {
+	vars := make(map[string]interface{}, len(variables)+2)
+	for k, v := range variables {
+		vars[k] = v
+	}
+
+	pagination.Start(vars)
+
+	return &Paginator{
+		q:          q,
+		query:      query,
+		vars:       vars,
+		pagination: pagination,
+		reqOpts:    reqOpts,
+		more:       true,
+	}
+}
+
+// Paginate is a shorthand for NewPaginator using c.
+func (c *Client) Paginate(query string, variables map[string]interface{}, pagination Pagination, reqOpts ...func(*http.Request)) *Paginator {
+	return NewPaginator(c, query, variables, pagination, reqOpts...)
+}
+
+// Next fetches the next page and reports whether it succeeded.
+func (p *Paginator) Next(ctx context.Context) bool {
+	if p.err != nil || p.closed {
+		return false
+	}
+
+	if p.Checkpoints != nil && p.pageNum > p.saved {
+		if err := p.Checkpoints.SaveCheckpoint(ctx, &Checkpoint{
+			Page:      p.pageNum,
+			Items:     p.items,
+			Variables: p.nextVars,
+			Done:      !p.more,
+		}); err != nil {
+			p.err = fmt.Errorf("error saving checkpoint: %v", err)
+			return false
+		}
+
+		p.saved = p.pageNum
+	}
+
+	if !p.more {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+
+	if !p.started {
+		p.started = true
+
+		if p.Checkpoints != nil && !p.resume(ctx) {
+			return false
+		}
+	}
+
+	var r fetchResult
+
+	if p.Prefetch > 0 {
+		r = p.nextPrefetched(ctx)
+	} else {
+		r = p.fetch(ctx)
+	}
+
+	if r.err != nil {
+		p.err = r.err
+		return false
+	}
+
+	p.page = r.page
+	p.pageNum++
+	p.nextVars = r.next
+	p.more = r.more
+
+	if p.OnProgress != nil || p.Checkpoints != nil {
+		nodes, err := p.pagination.Nodes(r.page)
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		p.items += len(nodes)
+	}
+
+	if p.OnProgress != nil {
+		p.OnProgress(p.progress())
+	}
+
+	return true
+}
+
+// Progress is the progress of the iteration of a Paginator.
+type Progress struct {
+	// Pages and Items are the number of pages and items fetched,
+	// including those fetched before resuming from a checkpoint.
+	Pages int
+	Items int
+
+	// Total is the total number of items, or -1 if unknown. It is known
+	// if the Pagination implements TotalCounter, e.g. if a Relay
+	// connection selects totalCount.
+	Total int
+
+	// RemainingItems and RemainingPages are the estimated number of items
+	// and pages left to fetch, or -1 if unknown. RemainingPages is
+	// estimated from the average number of items per page so far.
+	RemainingItems int
+	RemainingPages int
+}
+
+func (p *Paginator) progress() Progress {
+	pr := Progress{
+		Pages:          p.pageNum,
+		Items:          p.items,
+		Total:          -1,
+		RemainingItems: -1,
+		RemainingPages: -1,
+	}
+
+	if tc, ok := p.pagination.(TotalCounter); ok {
+		if total, ok := tc.TotalCount(p.page); ok {
+			pr.Total = total
+		}
+	}
+
+	switch {
+	case !p.more:
+		pr.RemainingItems, pr.RemainingPages = 0, 0
+	case pr.Total >= 0:
+		pr.RemainingItems = pr.Total - pr.Items
+		if pr.RemainingItems < 0 {
+			pr.RemainingItems = 0
+		}
+
+		if pr.Items > 0 {
+			perPage := float64(pr.Items) / float64(pr.Pages)
+			pr.RemainingPages = int(math.Ceil(float64(pr.RemainingItems) / perPage))
+		}
+	}
+
+	return pr
+}
+
+// resume loads the checkpoint to resume from, if any, and reports whether
+// there are pages left to fetch.
+func (p *Paginator) resume(ctx context.Context) bool {
+	cp, err := p.Checkpoints.LoadCheckpoint(ctx)
+	if err != nil {
+		p.err = fmt.Errorf("error loading checkpoint: %v", err)
+		return false
+	}
+
+	if cp == nil {
+		return true
+	}
+
+	p.pageNum = cp.Page
+	p.items = cp.Items
+	p.saved = cp.Page
+
+	if cp.Done {
+		p.more = false
+		return false
+	}
+
+	p.vars = make(map[string]interface{}, len(cp.Variables))
+	for k, v := range cp.Variables {
+		p.vars[k] = v
+	}
+
+	return true
+}
+
+func (p *Paginator) nextPrefetched(ctx context.Context) fetchResult {
+	if p.results == nil {
+		prefetchCtx, cancel := context.WithCancel(ctx)
+
+		p.results = make(chan fetchResult, p.Prefetch-1)
+		p.cancel = cancel
+
+		go p.prefetch(prefetchCtx)
+	}
+
+	select {
+	case r := <-p.results:
+		return r
+	case <-ctx.Done():
+		return fetchResult{err: ctx.Err()}
+	}
+}
+
+// prefetch fetches pages until the last one, the first error or until ctx is
+// done, sending them to p.results.
+func (p *Paginator) prefetch(ctx context.Context) {
+	for {
+		r := p.fetch(ctx)
+
+		select {
+		case p.results <- r:
+		case <-ctx.Done():
+			return
+		}
+
+		if r.err != nil || !r.more {
+			return
+		}
+	}
+}
+
+// MaxThrottledRetries is the number of times a Paginator with a Throttle
+// fetches a page rejected with 429 Too Many Requests again.
+const MaxThrottledRetries = 5
+
+// fetch fetches the page given by p.vars and advances them to the next one.
+func (p *Paginator) fetch(ctx context.Context) fetchResult {
+	var page json.RawMessage
+
+	for retries := 0; ; retries++ {
+		if p.Throttle != nil {
+			if err := p.Throttle.Wait(ctx); err != nil {
+				return fetchResult{err: err}
+			}
+		}
+
+		err := p.q.Query(ctx, p.query, p.vars, &page, p.reqOpts...)
+		if err == nil {
+			break
+		}
+
+		var errResp *ErrorResponse
+		if p.Throttle == nil || retries == MaxThrottledRetries ||
+			!errors.As(err, &errResp) || errResp.StatusCode != http.StatusTooManyRequests {
+			return fetchResult{err: err}
+		}
+	}
+
+	more, err := p.pagination.Advance(page, p.vars)
+	if err != nil {
+		return fetchResult{err: fmt.Errorf("error advancing pagination: %v", err)}
+	}
+
+	r := fetchResult{page: page, more: more}
+
+	if p.Checkpoints != nil {
+		r.next = make(map[string]interface{}, len(p.vars))
+		for k, v := range p.vars {
+			r.next[k] = v
+		}
+	}
+
+	return r
+}
+
+// Close stops prefetching. Next returns false after Close is called. The
+// position of a paginator closed before moving on from the current page is
+// not saved, so a resumed iteration starts with that page.
+func (p *Paginator) Close() {
+	p.closed = true
+
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// Page returns the data of the current page.
+func (p *Paginator) Page() json.RawMessage {
+	return p.page
+}
+
+// PageNumber returns the number of the current page, starting at 1.
+func (p *Paginator) PageNumber() int {
+	return p.pageNum
+}
+
+// Decode unmarshals the data of the current page into v.
+func (p *Paginator) Decode(v interface{}) error {
+	if err := json.Unmarshal(p.page, v); err != nil {
+		return fmt.Errorf("error decoding data payload: %v", err)
+	}
+	return nil
+}
+
+// Nodes returns the nodes of the current page.
+func (p *Paginator) Nodes() ([]json.RawMessage, error) {
+	return p.pagination.Nodes(p.page)
+}
+
+// Err returns the error that stopped iteration, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// ForEachNode fetches all remaining pages and calls fn with every node on
+// them, in order. It stops at the first error, including one returned by fn,
+// and closes p.
+func (p *Paginator) ForEachNode(ctx context.Context, fn func(node json.RawMessage) error) error {
+	defer p.Close()
+
+	for p.Next(ctx) {
+		nodes, err := p.Nodes()
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.Err()
+}
+
+// ErrPathNotFound is the error, wrapped, when a value looked up by path is
+// missing or null.
+var ErrPathNotFound = errors.New("path not found")
+
+// lookupPath unmarshals the value at the dot-separated path in data into v.
+// Path elements are object keys, or indexes for arrays. An empty path refers
+// to data itself.
+func lookupPath(data json.RawMessage, path string, v interface{}) error {
+	raw, err := rawAtPath(data, path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("error decoding %q: %v", path, err)
+	}
+
+	return nil
+}
+
+// joinPath joins dot-separated paths.
+func joinPath(paths ...string) string {
+	var elems []string
+	for _, p := range paths {
+		if p != "" {
+			elems = append(elems, p)
+		}
+	}
+	return strings.Join(elems, ".")
+}
+
+func rawAtPath(data json.RawMessage, path string) (json.RawMessage, error) {
+	if path == "" {
+		return data, nil
+	}
+
+	raw := data
+
+	for _, key := range strings.Split(path, ".") {
+		if len(raw) > 0 && raw[0] == '[' {
+			n, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("%q: %q is not an array index", path, key)
+			}
+
+			var arr []json.RawMessage
+			if err := json.Unmarshal(raw, &arr); err != nil {
+				return nil, fmt.Errorf("error decoding %q: %v", path, err)
+			}
+
+			if n < 0 || n >= len(arr) {
+				return nil, fmt.Errorf("%q: %w", path, ErrPathNotFound)
+			}
+
+			raw = arr[n]
+			continue
+		}
+
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("error decoding %q: %v", path, err)
+		}
+
+		v, ok := obj[key]
+		if !ok || string(v) == "null" {
+			return nil, fmt.Errorf("%q: %w", path, ErrPathNotFound)
+		}
+
+		raw = v
+	}
+
+	return raw, nil
+}
+
+// All returns an iterator over the nodes on all remaining pages, fetching
+// them as needed. If fetching a page fails, the error is yielded once and
+// iteration stops. p is closed when iteration stops.
+//
+//	for node, err := range p.All(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (p *Paginator) All(ctx context.Context) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		defer p.Close()
+
+		for p.Next(ctx) {
+			nodes, err := p.Nodes()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, node := range nodes {
+				if !yield(node, nil) {
+					return
+				}
+			}
+		}
+
+		if err := p.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// NodeSeq returns an iterator over the nodes on all remaining pages of p,
+// each decoded into a T. Errors, including decoding errors, are yielded once
+// and stop iteration.
+//
+//	for user, err := range graphqlclient.NodeSeq[User](ctx, p) {
+//		...
+//	}
+func NodeSeq[T any](ctx context.Context, p *Paginator) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for node, err := range p.All(ctx) {
+			var v T
+
+			if err != nil {
+				yield(v, err)
+				return
+			}
+
+			if err := json.Unmarshal(node, &v); err != nil {
+				yield(v, fmt.Errorf("error decoding node: %v", err))
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Stream fetches the remaining pages of p in a new goroutine and sends their
+// nodes on the returned channel, which buffers up to size nodes. The channel
+// is closed when iteration stops, after which the error that stopped it, if
+// any, can be received from the returned error channel. Cancel ctx to stop
+// streaming before the last page.
+//
+//	nodes, errc := p.Stream(ctx, 100)
+//	for node := range nodes {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		...
+//	}
+func (p *Paginator) Stream(ctx context.Context, size int) (<-chan json.RawMessage, <-chan error) {
+	return stream(ctx, p.All(ctx), size)
+}
+
+// NodeStream is like Stream, with the nodes decoded into a T as for NodeSeq.
+func NodeStream[T any](ctx context.Context, p *Paginator, size int) (<-chan T, <-chan error) {
+	return stream(ctx, NodeSeq[T](ctx, p), size)
+}
+
+func stream[T any](ctx context.Context, seq iter.Seq2[T, error], size int) (<-chan T, <-chan error) {
+	nodes := make(chan T, size)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(nodes)
+
+		for node, err := range seq {
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case nodes <- node:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return nodes, errc
+}
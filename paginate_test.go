@@ -0,0 +1,782 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCursorServer returns a server serving the nodes in pages of a Relay
+// connection at user.friends, the after variable being the index of the
+// next page. It records the after variables it receives.
+func newCursorServer(t *testing.T, pages [][]string, afters *[]interface{}) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Variables map[string]interface{} `json:"variables"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			after := body.Variables["after"]
+			*afters = append(*afters, after)
+
+			n := 0
+			if s, ok := after.(string); ok {
+				n, _ = strconv.Atoi(s)
+			}
+
+			var edges []string
+			for _, node := range pages[n] {
+				edges = append(edges, fmt.Sprintf(`{"node":{"name":%q}}`, node))
+			}
+
+			fmt.Fprintf(w, `{"data":{"user":{"friends":{"edges":[%s],"pageInfo":{"hasNextPage":%t,"endCursor":"%d"}}}}}`,
+				strings.Join(edges, ","), n+1 < len(pages), n+1)
+		},
+	))
+}
+
+func TestPaginator(t *testing.T) {
+	t.Run("Pages", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}, {"d"}}, &afters)
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", map[string]interface{}{"first": 2}, Cursor("user.friends"))
+
+		var got []string
+
+		for p.Next(context.Background()) {
+			var page struct {
+				User struct {
+					Friends struct {
+						Edges []struct {
+							Node struct {
+								Name string `json:"name"`
+							} `json:"node"`
+						} `json:"edges"`
+					} `json:"friends"`
+				} `json:"user"`
+			}
+
+			if err := p.Decode(&page); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, e := range page.User.Friends.Edges {
+				got = append(got, fmt.Sprintf("%d:%s", p.PageNumber(), e.Node.Name))
+			}
+		}
+
+		if err := p.Err(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, ","), "1:a,1:b,2:c,3:d"; got != want {
+			t.Errorf("nodes = %q, want %q", got, want)
+		}
+
+		if got, want := fmt.Sprint(afters), "[<nil> 1 2]"; got != want {
+			t.Errorf("afters = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("ForEachNode", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {}, {"c"}}, &afters)
+		defer ts.Close()
+
+		p := NewPaginator(New(ts.URL, http.DefaultClient), "foo-query", nil, Cursor("user.friends"))
+
+		var got []string
+
+		err := p.ForEachNode(context.Background(), func(node json.RawMessage) error {
+			got = append(got, string(node))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, ","), `{"name":"a"},{"name":"b"},{"name":"c"}`; got != want {
+			t.Errorf("nodes = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("StopOnCallbackError", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a"}, {"b"}, {"c"}}, &afters)
+		defer ts.Close()
+
+		errStop := errors.New("stop")
+
+		err := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends")).ForEachNode(context.Background(),
+			func(node json.RawMessage) error {
+				if string(node) == `{"name":"b"}` {
+					return errStop
+				}
+				return nil
+			},
+		)
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+
+		if got, want := len(afters), 2; got != want {
+			t.Errorf("len(afters) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a"}, {"b"}}, &afters)
+		defer ts.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))
+
+		if !p.Next(ctx) {
+			t.Fatalf("unexpected error: %v", p.Err())
+		}
+
+		cancel()
+
+		if p.Next(ctx) {
+			t.Fatal("Next = true, want false")
+		}
+
+		if got, want := p.Err(), context.Canceled; got != want {
+			t.Errorf("p.Err() = %v, want %v", got, want)
+		}
+
+		if got, want := len(afters), 1; got != want {
+			t.Errorf("len(afters) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("CursorDidNotAdvance", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{"items":{"nodes":[1],"pageInfo":{"hasNextPage":true,"endCursor":"x"}}}}`))
+			},
+		))
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, &CursorPagination{ConnectionPath: "items", CursorVariable: "cursor"})
+
+		n := 0
+		for p.Next(context.Background()) {
+			n++
+		}
+
+		if got, want := n, 1; got != want {
+			t.Errorf("pages = %d, want %d", got, want)
+		}
+
+		if got, want := fmt.Sprint(p.Err()), `error advancing pagination: cursor "x" did not advance`; got != want {
+			t.Errorf("p.Err() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("MissingConnection", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{"user":null}}`))
+			},
+		))
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))
+
+		if p.Next(context.Background()) {
+			t.Fatal("Next = true, want false")
+		}
+
+		if got, want := fmt.Sprint(p.Err()), `error advancing pagination: "user.friends": path not found`; got != want {
+			t.Errorf("p.Err() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestCursorPagination_Paths(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		pagination *CursorPagination
+		pages      []string
+		wantNodes  string
+		wantVars   string
+	}{
+		{
+			name: "NextToken",
+			pagination: &CursorPagination{
+				ConnectionPath: "listPosts",
+				CursorPath:     "nextToken",
+				NodesPath:      "items",
+				CursorVariable: "nextToken",
+			},
+			pages: []string{
+				`{"listPosts":{"items":[1,2],"nextToken":"t1"}}`,
+				`{"listPosts":{"items":[3],"nextToken":"t2"}}`,
+				`{"listPosts":{"items":[],"nextToken":null}}`,
+			},
+			wantNodes: "1,2,3",
+			wantVars:  "<nil>,t1,t2",
+		},
+		{
+			name: "HasMore",
+			pagination: &CursorPagination{
+				ConnectionPath: "search",
+				CursorPath:     "meta.cursor",
+				HasMorePath:    "meta.hasMore",
+				NodesPath:      "results",
+				CursorVariable: "cursor",
+			},
+			pages: []string{
+				`{"search":{"results":[1],"meta":{"cursor":10,"hasMore":true}}}`,
+				`{"search":{"results":[2],"meta":{"cursor":20,"hasMore":false}}}`,
+			},
+			wantNodes: "1,2",
+			wantVars:  "<nil>,10",
+		},
+		{
+			name: "EmptyCursor",
+			pagination: &CursorPagination{
+				CursorPath:     "feed.next",
+				NodesPath:      "feed.entries",
+				CursorVariable: "cursor",
+			},
+			pages: []string{
+				`{"feed":{"entries":[1],"next":"a"}}`,
+				`{"feed":{"entries":[2],"next":""}}`,
+			},
+			wantNodes: "1,2",
+			wantVars:  "<nil>,a",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var vars []string
+
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					var body struct {
+						Variables map[string]interface{} `json:"variables"`
+					}
+
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+
+					vars = append(vars, fmt.Sprint(body.Variables[tc.pagination.CursorVariable]))
+
+					fmt.Fprintf(w, `{"data":%s}`, tc.pages[len(vars)-1])
+				},
+			))
+			defer ts.Close()
+
+			var nodes []string
+
+			err := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, tc.pagination).ForEachNode(context.Background(),
+				func(node json.RawMessage) error {
+					nodes = append(nodes, string(node))
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(nodes, ","), tc.wantNodes; got != want {
+				t.Errorf("nodes = %q, want %q", got, want)
+			}
+
+			if got, want := strings.Join(vars, ","), tc.wantVars; got != want {
+				t.Errorf("vars = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPaginator_OnProgress(t *testing.T) {
+	pages := []string{
+		`{"items":{"nodes":[1,2],"totalCount":5,"pageInfo":{"hasNextPage":true,"endCursor":"a"}}}`,
+		`{"items":{"nodes":[3,4],"totalCount":5,"pageInfo":{"hasNextPage":true,"endCursor":"b"}}}`,
+		`{"items":{"nodes":[5],"totalCount":5,"pageInfo":{"hasNextPage":false,"endCursor":"c"}}}`,
+	}
+
+	for _, tc := range []struct {
+		name       string
+		pagination Pagination
+		want       string
+	}{
+		{
+			name:       "TotalCount",
+			pagination: Cursor("items"),
+			want:       "{1 2 5 3 2},{2 4 5 1 1},{3 5 5 0 0}",
+		},
+		{
+			name:       "NoTotalCount",
+			pagination: &CursorPagination{ConnectionPath: "items", TotalCountPath: "total"},
+			want:       "{1 2 -1 -1 -1},{2 4 -1 -1 -1},{3 5 -1 0 0}",
+		},
+		{
+			name:       "Offset",
+			pagination: &OffsetPagination{ItemsPath: "items.nodes", Limit: 2, TotalCountPath: "items.totalCount"},
+			want:       "{1 2 5 3 2},{2 4 5 1 1},{3 5 5 0 0}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var n int
+
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, `{"data":%s}`, pages[n])
+					n++
+				},
+			))
+			defer ts.Close()
+
+			var progress []string
+
+			p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, tc.pagination)
+			p.OnProgress = func(pr Progress) {
+				progress = append(progress, fmt.Sprint(pr))
+			}
+
+			for p.Next(context.Background()) {
+			}
+
+			if err := p.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(progress, ","), tc.want; got != want {
+				t.Errorf("progress = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPaginator_Prefetch(t *testing.T) {
+	t.Run("Ordering", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}, {}, {"d"}, {"e", "f"}}, &afters)
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))
+		p.Prefetch = 2
+
+		var got []string
+
+		err := p.ForEachNode(context.Background(), func(node json.RawMessage) error {
+			got = append(got, fmt.Sprintf("%d:%s", p.PageNumber(), node))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, ","), `1:{"name":"a"},1:{"name":"b"},2:{"name":"c"},4:{"name":"d"},5:{"name":"e"},5:{"name":"f"}`; got != want {
+			t.Errorf("nodes = %q, want %q", got, want)
+		}
+
+		if got, want := fmt.Sprint(afters), "[<nil> 1 2 3 4]"; got != want {
+			t.Errorf("afters = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Depth", func(t *testing.T) {
+		requests := make(chan struct{}, 10)
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests <- struct{}{}
+				w.Write([]byte(`{"data":{"items":["x"]}}`))
+			},
+		))
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Offset("items", 1, nil))
+		p.Prefetch = 2
+		defer p.Close()
+
+		if !p.Next(context.Background()) {
+			t.Fatalf("unexpected error: %v", p.Err())
+		}
+
+		// The current page and the two after it are fetched without
+		// calling Next again.
+		for n := 0; n < 3; n++ {
+			select {
+			case <-requests:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("requests = %d, want 3", n)
+			}
+		}
+
+		select {
+		case <-requests:
+			t.Fatal("requests = 4, want 3")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a"}, {"b"}}, &afters)
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.missing"))
+		p.Prefetch = 3
+
+		if p.Next(context.Background()) {
+			t.Fatal("Next = true, want false")
+		}
+
+		if got, want := fmt.Sprint(p.Err()), `error advancing pagination: "user.missing": path not found`; got != want {
+			t.Errorf("p.Err() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a"}, {"b"}, {"c"}}, &afters)
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))
+		p.Prefetch = 1
+
+		if !p.Next(context.Background()) {
+			t.Fatalf("unexpected error: %v", p.Err())
+		}
+
+		p.Close()
+
+		if p.Next(context.Background()) {
+			t.Error("Next = true, want false")
+		}
+
+		if err := p.Err(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestPaginator_Stream(t *testing.T) {
+	t.Run("Nodes", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}, {"d"}}, &afters)
+		defer ts.Close()
+
+		nodes, errc := NodeStream[struct{ Name string }](context.Background(), New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends")), 1)
+
+		var got []string
+		for node := range nodes {
+			got = append(got, node.Name)
+		}
+
+		if err := <-errc; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(got, ","), "a,b,c,d"; got != want {
+			t.Errorf("nodes = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a"}, {"b"}}, &afters)
+		defer ts.Close()
+
+		nodes, errc := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.missing")).Stream(context.Background(), 10)
+
+		for range nodes {
+			t.Error("unexpected node")
+		}
+
+		if got, want := fmt.Sprint(<-errc), `error advancing pagination: "user.missing": path not found`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}}, &afters)
+		defer ts.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		nodes, errc := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends")).Stream(ctx, 0)
+
+		if got, want := string(<-nodes), `{"name":"a"}`; got != want {
+			t.Errorf("node = %q, want %q", got, want)
+		}
+
+		cancel()
+
+		for range nodes {
+		}
+
+		if got, want := <-errc, context.Canceled; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestOffsetPagination(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+
+	newServer := func(t *testing.T, vars *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Variables struct {
+						Offset int `json:"offset"`
+						Limit  int `json:"limit"`
+						Skip   int `json:"skip"`
+					} `json:"variables"`
+				}
+
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				v := body.Variables
+				*vars = append(*vars, fmt.Sprintf("%d+%d", v.Offset+v.Skip, v.Limit))
+
+				from, to := v.Offset+v.Skip, v.Offset+v.Skip+v.Limit
+				if from > len(items) {
+					from = len(items)
+				}
+				if to > len(items) {
+					to = len(items)
+				}
+
+				b, _ := json.Marshal(items[from:to])
+				fmt.Fprintf(w, `{"data":{"search":{"total":%d,"results":%s}}}`, len(items), b)
+			},
+		))
+	}
+
+	for _, tc := range []struct {
+		name       string
+		pagination *OffsetPagination
+		variables  map[string]interface{}
+		wantNodes  string
+		wantVars   string
+	}{
+		{
+			name:       "ShortPage",
+			pagination: Offset("search.results", 2, nil),
+			wantNodes:  `"a","b","c","d","e"`,
+			wantVars:   "0+2,2+2,4+2",
+		},
+		{
+			name:       "EmptyPage",
+			pagination: Offset("search.results", 5, nil),
+			wantNodes:  `"a","b","c","d","e"`,
+			wantVars:   "0+5,5+5",
+		},
+		{
+			name:       "StartOffset",
+			pagination: Offset("search.results", 2, nil),
+			variables:  map[string]interface{}{"offset": 1},
+			wantNodes:  `"b","c","d","e"`,
+			wantVars:   "1+2,3+2,5+2",
+		},
+		{
+			name: "IsLastPage",
+			pagination: Offset("search.results", 2, func(page json.RawMessage, items int) bool {
+				var data struct {
+					Search struct {
+						Total int `json:"total"`
+					} `json:"search"`
+				}
+				json.Unmarshal(page, &data)
+				return data.Search.Total <= 5
+			}),
+			wantNodes: `"a","b"`,
+			wantVars:  "0+2",
+		},
+		{
+			name: "Variables",
+			pagination: &OffsetPagination{
+				ItemsPath:      "search.results",
+				OffsetVariable: "skip",
+			},
+			variables: map[string]interface{}{"limit": 3},
+			wantNodes: `"a","b","c","d","e"`,
+			wantVars:  "0+3,3+3",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var vars []string
+
+			ts := newServer(t, &vars)
+			defer ts.Close()
+
+			if tc.pagination.IsLastPage == nil && tc.pagination.Limit == 0 {
+				tc.pagination.IsLastPage = func(page json.RawMessage, items int) bool { return items < 3 }
+			}
+
+			var nodes []string
+
+			err := New(ts.URL, http.DefaultClient).Paginate("foo-query", tc.variables, tc.pagination).ForEachNode(context.Background(),
+				func(node json.RawMessage) error {
+					nodes = append(nodes, string(node))
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(nodes, ","), tc.wantNodes; got != want {
+				t.Errorf("nodes = %q, want %q", got, want)
+			}
+
+			if got, want := strings.Join(vars, ","), tc.wantVars; got != want {
+				t.Errorf("vars = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("NonIntegerOffset", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{"search":{"results":["a"]}}}`))
+			},
+		))
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", map[string]interface{}{"offset": "0"}, Offset("search.results", 1, nil))
+
+		if p.Next(context.Background()) {
+			t.Fatal("Next = true, want false")
+		}
+
+		if got, want := fmt.Sprint(p.Err()), `error advancing pagination: variable "offset" is not an integer: 0`; got != want {
+			t.Errorf("p.Err() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestNodeSeq(t *testing.T) {
+	t.Run("All", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}}, &afters)
+		defer ts.Close()
+
+		var got []string
+
+		for user, err := range NodeSeq[struct{ Name string }](context.Background(), New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, user.Name)
+		}
+
+		if got, want := strings.Join(got, ","), "a,b,c"; got != want {
+			t.Errorf("nodes = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		var afters []interface{}
+
+		ts := newCursorServer(t, [][]string{{"a", "b"}, {"c"}}, &afters)
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))
+
+		for node, err := range p.All(context.Background()) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(node) == `{"name":"b"}` {
+				break
+			}
+		}
+
+		if got, want := len(afters), 1; got != want {
+			t.Errorf("len(afters) = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data":{"user":{"friends":{"nodes":[{"name":1}],"pageInfo":{"hasNextPage":false}}}}}`))
+			},
+		))
+		defer ts.Close()
+
+		var errs []string
+
+		for _, err := range NodeSeq[struct{ Name string }](context.Background(), New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Cursor("user.friends"))) {
+			errs = append(errs, fmt.Sprint(err))
+		}
+
+		if got, want := len(errs), 1; got != want {
+			t.Fatalf("len(errs) = %d, want %d", got, want)
+		}
+
+		if !strings.HasPrefix(errs[0], "error decoding node: ") {
+			t.Errorf("err = %q, want decoding error", errs[0])
+		}
+	})
+}
+
+func TestRawAtPath(t *testing.T) {
+	data := json.RawMessage(`{"a":{"b":[{"c":1},{"c":2}]},"n":null}`)
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{"", `{"a":{"b":[{"c":1},{"c":2}]},"n":null}`},
+		{"a.b.1.c", `2`},
+		{"a.b.2", `error: "a.b.2": path not found`},
+		{"a.b.x", `error: "a.b.x": "x" is not an array index`},
+		{"n.x", `error: "n.x": path not found`},
+		{"missing", `error: "missing": path not found`},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			raw, err := rawAtPath(data, tc.path)
+
+			got := string(raw)
+			if err != nil {
+				got = "error: " + err.Error()
+			}
+
+			if got != tc.want {
+				t.Errorf("rawAtPath(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+// absintheControlTopic is the Phoenix channel topic Absinthe subscriptions
+// are sent on.
+const absintheControlTopic = "__absinthe__:control"
+
+// PhoenixTransport is a SubscriptionTransport for Absinthe servers, which
+// serve subscriptions over Phoenix channels, speaking version 2 of the
+// Phoenix channel protocol. Each subscription is sent on its own connection.
+type PhoenixTransport struct {
+	// URL is the ws:// or wss:// URL of the Phoenix socket, e.g.
+	// ws://example.com/socket/websocket.
+	URL string
+
+	// Params are sent as query parameters when connecting, which Phoenix
+	// passes to the socket's connect callback, e.g. for authentication.
+	Params map[string]string
+
+	// HeartbeatInterval is the interval between the heartbeats Phoenix
+	// expects to keep the connection open. Defaults to 30 seconds.
+	HeartbeatInterval time.Duration
+}
+
+// phoenixMessage is a message of the Phoenix channel protocol, encoded as the
+// array [join_ref, ref, topic, event, payload].
+type phoenixMessage struct {
+	JoinRef *string
+	Ref     *string
+	Topic   string
+	Event   string
+	Payload json.RawMessage
+}
+
+func (m *phoenixMessage) UnmarshalJSON(b []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	if len(fields) != 5 {
+		return fmt.Errorf("message has %d fields, want 5", len(fields))
+	}
+
+	for i, v := range []interface{}{&m.JoinRef, &m.Ref, &m.Topic, &m.Event} {
+		if err := json.Unmarshal(fields[i], v); err != nil {
+			return err
+		}
+	}
+
+	m.Payload = fields[4]
+
+	return nil
+}
+
+// phoenixReply is the payload of a phx_reply message.
+type phoenixReply struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Subscribe implements SubscriptionTransport.
+func (t *PhoenixTransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return fmt.Errorf("error parsing URL: %v", err)
+	}
+
+	q := u.Query()
+	for k, v := range t.Params {
+		q.Set(k, v)
+	}
+	q.Set("vsn", "2.0.0")
+	u.RawQuery = q.Encode()
+
+	d := websocket.Dialer{Header: sub.Header}
+
+	conn, _, err := d.Dial(ctx, u.String())
+	if err != nil {
+		return fmt.Errorf("error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	s := &phoenixSession{conn: conn, joinRef: "1"}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Reads fail once the connection is closed when ctx is done.
+	stop := make(chan struct{})
+	defer close(stop)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		interval := t.HeartbeatInterval
+		if interval <= 0 {
+			interval = 30 * time.Second
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.send(nil, "phoenix", "heartbeat", struct{}{})
+			case <-ctx.Done():
+				// Phoenix removes the subscriptions of closed
+				// sockets.
+				conn.WriteClose(websocket.CloseNormalClosure, "")
+				conn.Close()
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	err = s.run(sub, handler)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// phoenixSession is a subscription on a Phoenix socket.
+type phoenixSession struct {
+	conn    *websocket.Conn
+	joinRef string
+	ref     int64
+}
+
+// send sends a message, with the next ref, and returns the ref.
+func (s *phoenixSession) send(joinRef *string, topic, event string, payload interface{}) (string, error) {
+	ref := strconv.FormatInt(atomic.AddInt64(&s.ref, 1), 10)
+
+	b, err := json.Marshal([]interface{}{joinRef, ref, topic, event, payload})
+	if err != nil {
+		return "", err
+	}
+
+	return ref, s.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// read reads the next message.
+func (s *phoenixSession) read() (*phoenixMessage, error) {
+	_, p, err := s.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg phoenixMessage
+	if err := json.Unmarshal(p, &msg); err != nil {
+		return nil, fmt.Errorf("error decoding message: %v", err)
+	}
+
+	return &msg, nil
+}
+
+// request sends a message on the control topic and returns the response of
+// its reply, failing unless the reply has status ok.
+func (s *phoenixSession) request(event string, payload interface{}) (json.RawMessage, error) {
+	ref, err := s.send(&s.joinRef, absintheControlTopic, event, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+
+		if msg.Event != "phx_reply" || msg.Ref == nil || *msg.Ref != ref {
+			continue
+		}
+
+		var reply phoenixReply
+		if err := json.Unmarshal(msg.Payload, &reply); err != nil {
+			return nil, fmt.Errorf("error decoding reply: %v", err)
+		}
+
+		if reply.Status != "ok" {
+			var resp struct {
+				Errors []Error `json:"errors"`
+			}
+
+			if json.Unmarshal(reply.Response, &resp) == nil && len(resp.Errors) > 0 {
+				return nil, &SubscriptionError{Errors: resp.Errors}
+			}
+
+			return nil, fmt.Errorf("%s failed with status %q: %s", event, reply.Status, reply.Response)
+		}
+
+		return reply.Response, nil
+	}
+}
+
+// errPhoenixChannelClosed is returned when the server closes the control
+// channel.
+var errPhoenixChannelClosed = errors.New("channel closed by server")
+
+func (s *phoenixSession) run(sub *Subscription, handler SubscriptionHandler) error {
+	if _, err := s.request("phx_join", struct{}{}); err != nil {
+		return fmt.Errorf("error joining channel: %v", err)
+	}
+
+	resp, err := s.request("doc", map[string]interface{}{
+		"query":     sub.Query,
+		"variables": sub.Variables,
+	})
+	if err != nil {
+		var subErr *SubscriptionError
+		if errors.As(err, &subErr) {
+			return subErr
+		}
+		return fmt.Errorf("error subscribing: %v", err)
+	}
+
+	var doc struct {
+		SubscriptionID string `json:"subscriptionId"`
+	}
+
+	if err := json.Unmarshal(resp, &doc); err != nil || doc.SubscriptionID == "" {
+		return fmt.Errorf("error subscribing: no subscription ID in reply: %s", resp)
+	}
+
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case msg.Topic == absintheControlTopic && (msg.Event == "phx_close" || msg.Event == "phx_error"):
+			return errPhoenixChannelClosed
+		case msg.Topic != doc.SubscriptionID || msg.Event != "subscription:data":
+			continue
+		}
+
+		var payload struct {
+			Result struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []Error         `json:"errors"`
+			} `json:"result"`
+		}
+
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return fmt.Errorf("error decoding event: %v", err)
+		}
+
+		if err := handler(payload.Result.Data, payload.Result.Errors); err != nil {
+			s.send(&s.joinRef, absintheControlTopic, "unsubscribe", map[string]string{
+				"subscriptionId": doc.SubscriptionID,
+			})
+			s.conn.WriteClose(websocket.CloseNormalClosure, "")
+			return err
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+// newPhoenixServer returns a server speaking the Phoenix channel protocol as
+// Absinthe does, replying to subscriptions to queries containing "invalid"
+// with errors, and otherwise pushing events until unsubscribed. The messages
+// received, other than heartbeats, are sent on msgs.
+func newPhoenixServer(t *testing.T, msgs chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			msgs <- "connect " + r.URL.RawQuery + " " + r.Header.Get("Authorization")
+
+			var mu sync.Mutex
+			write := func(v ...interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				b, _ := json.Marshal(v)
+				conn.WriteMessage(websocket.TextMessage, b)
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+
+			for {
+				_, p, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				var msg phoenixMessage
+				if err := json.Unmarshal(p, &msg); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+
+				if msg.Event == "heartbeat" {
+					continue
+				}
+
+				msgs <- msg.Event
+
+				switch msg.Event {
+				case "phx_join":
+					write(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", map[string]interface{}{"status": "ok", "response": map[string]interface{}{}})
+				case "doc":
+					if strings.Contains(string(msg.Payload), "invalid") {
+						write(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", map[string]interface{}{
+							"status":   "error",
+							"response": map[string]interface{}{"errors": []map[string]string{{"message": "invalid query"}}},
+						})
+						continue
+					}
+
+					id := "__absinthe__:doc:1"
+					write(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", map[string]interface{}{"status": "ok", "response": map[string]string{"subscriptionId": id}})
+
+					go func() {
+						for n := 1; ; n++ {
+							select {
+							case <-done:
+								return
+							case <-time.After(time.Millisecond):
+							}
+
+							write(nil, nil, id, "subscription:data", map[string]interface{}{
+								"subscriptionId": id,
+								"result":         map[string]interface{}{"data": map[string]int{"n": n}},
+							})
+						}
+					}()
+				case "unsubscribe":
+					write(msg.JoinRef, msg.Ref, msg.Topic, "phx_reply", map[string]interface{}{"status": "ok", "response": map[string]interface{}{}})
+				}
+			}
+		},
+	))
+}
+
+func TestPhoenixTransport(t *testing.T) {
+	msgs := make(chan string, 100)
+
+	ts := newPhoenixServer(t, msgs)
+	defer ts.Close()
+
+	transport := &PhoenixTransport{
+		URL:               "ws" + strings.TrimPrefix(ts.URL, "http") + "/socket/websocket",
+		Params:            map[string]string{"token": "foo"},
+		HeartbeatInterval: 5 * time.Millisecond,
+	}
+
+	c := NewClient(ts.URL,
+		WithSubscriptionTransport(transport),
+		WithRequestOptions(func(req *http.Request) {
+			req.Header.Set("Authorization", "bar")
+		}),
+	)
+
+	errStop := errors.New("stop")
+
+	var events []string
+
+	err := c.Subscribe(context.Background(), "subscription { n }", map[string]interface{}{"id": 1}, func(data json.RawMessage, errs []Error) error {
+		events = append(events, string(data))
+		if len(events) == 3 {
+			return errStop
+		}
+		return nil
+	})
+
+	if err != errStop {
+		t.Fatalf("err = %v, want %v", err, errStop)
+	}
+
+	if got, want := strings.Join(events, ","), `{"n":1},{"n":2},{"n":3}`; got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+
+	var received []string
+
+	for len(received) < 4 || received[len(received)-1] != "unsubscribe" {
+		select {
+		case msg := <-msgs:
+			received = append(received, msg)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out, received %q", received)
+		}
+	}
+
+	if got, want := strings.Join(received, ","), "connect token=foo&vsn=2.0.0 bar,phx_join,doc,unsubscribe"; got != want {
+		t.Errorf("received = %q, want %q", got, want)
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription { invalid }", nil, func(json.RawMessage, []Error) error {
+			return nil
+		})
+
+		if got, want := fmt.Sprint(err), "subscription error: invalid query"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+
+		var subErr *SubscriptionError
+		if !errors.As(err, &subErr) {
+			t.Errorf("err is %T, want %T", err, subErr)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := c.Subscribe(ctx, "subscription { n }", nil, func(json.RawMessage, []Error) error {
+			cancel()
+			return nil
+		})
+
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
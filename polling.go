@@ -0,0 +1,205 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PollingTransport is a SubscriptionTransport polling the server over plain
+// HTTP, for use where WebSockets are blocked, e.g. as the fallback set with
+// WithSubscriptionFallback. The subscription operation is sent as a regular
+// request, repeatedly, and the data payload of each response is handled as
+// an event unless it is null or the same as that of the previous response.
+// Servers supporting long-polling hold requests until there are new events.
+// Subscriptions are never completed by the server; a response with errors
+// and no data ends them with a *SubscriptionError.
+type PollingTransport struct {
+	// URL is the URL of the server. Defaults to the client's URL.
+	URL string
+
+	// HTTPClient sends the requests. Defaults to the HTTP client of the
+	// client, with its request options applied to the requests, whose
+	// responses are decoded within its decode limits.
+	HTTPClient *http.Client
+
+	// Interval is the delay between a response and the next request.
+	// Zero suits long-polling servers.
+	Interval time.Duration
+
+	// CursorPath, if set, is the dot-separated path, as for Result.Get,
+	// of a cursor in the data payload of responses, which is sent as the
+	// value of the variable CursorVariable in the next request, so that
+	// the server returns only newer events.
+	CursorPath     string
+	CursorVariable string
+
+	// sleep is replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Subscribe implements SubscriptionTransport.
+func (t *PollingTransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	url := t.URL
+	if url == "" {
+		url = sub.URL
+	}
+
+	httpClient := sub.httpClient(t.HTTPClient)
+
+	sleepFn := t.sleep
+	if sleepFn == nil {
+		sleepFn = sleep
+	}
+
+	variables := make(map[string]interface{}, len(sub.Variables)+1)
+	for k, v := range sub.Variables {
+		variables[k] = v
+	}
+
+	var prev json.RawMessage
+
+	for first := true; ; first = false {
+		if !first && t.Interval > 0 {
+			if err := sleepFn(ctx, t.Interval); err != nil {
+				return err
+			}
+		}
+
+		data, errs, err := t.poll(ctx, httpClient, url, sub, variables)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if data == nil {
+			if len(errs) > 0 {
+				return &SubscriptionError{Errors: errs}
+			}
+			continue
+		}
+
+		if bytes.Equal(data, prev) && len(errs) == 0 {
+			continue
+		}
+		prev = data
+
+		if t.CursorPath != "" && t.CursorVariable != "" {
+			var cursor interface{}
+			if err := lookupPath(data, t.CursorPath, &cursor); err == nil {
+				variables[t.CursorVariable] = cursor
+			} else if !errors.Is(err, ErrPathNotFound) {
+				return err
+			}
+		}
+
+		if err := handler(data, errs); err != nil {
+			return err
+		}
+	}
+}
+
+// poll sends a request for sub with variables, returning the data payload of
+// the response, nil if null, and its errors.
+func (t *PollingTransport) poll(ctx context.Context, httpClient *http.Client, url string, sub *Subscription, variables map[string]interface{}) (json.RawMessage, []Error, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     sub.Query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding variables: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req = req.WithContext(ctx)
+
+	sub.applyRequestOptions(req)
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", DefaultAccept)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error performing request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []Error         `json:"errors"`
+	}
+
+	var r io.Reader = io.LimitReader(resp.Body, 32<<20)
+	if sub.DecodeLimits != nil {
+		r = &limitReader{r: r, limits: *sub.DecodeLimits}
+	}
+
+	err = json.NewDecoder(r).Decode(&result)
+
+	var limitErr *DecodeLimitError
+	if errors.As(err, &limitErr) {
+		return nil, nil, limitErr
+	}
+
+	if resp.StatusCode/100 != 2 {
+		if err == nil && len(result.Errors) > 0 {
+			return nil, result.Errors, nil
+		}
+		return nil, nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	if string(result.Data) == "null" {
+		result.Data = nil
+	}
+
+	return result.Data, result.Errors, nil
+}
+
+// WithSubscriptionFallback makes Subscribe fall back to sending
+// subscriptions using t, e.g. a PollingTransport, when the client's
+// subscription transport fails before delivering any event, e.g. because
+// WebSockets are blocked by a proxy. Subscriptions ended by the server with
+// errors, by their handler or by their context are not retried.
+func WithSubscriptionFallback(t SubscriptionTransport) Option {
+	return func(c *Client) {
+		c.subscriptionFallback = t
+	}
+}
+
+// fallbackTransport is a SubscriptionTransport sending subscriptions using
+// primary, or fallback if primary fails before delivering any event.
+type fallbackTransport struct {
+	primary, fallback SubscriptionTransport
+}
+
+// Subscribe implements SubscriptionTransport.
+func (t *fallbackTransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	var received bool
+
+	err := t.primary.Subscribe(ctx, sub, func(data json.RawMessage, errs []Error) error {
+		received = true
+		return handler(data, errs)
+	})
+
+	var subErr *SubscriptionError
+
+	if err == nil || received || ctx.Err() != nil || errors.As(err, &subErr) {
+		return err
+	}
+
+	return t.fallback.Subscribe(ctx, sub, handler)
+}
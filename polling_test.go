@@ -0,0 +1,174 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollingTransport(t *testing.T) {
+	events := []string{"a", "b", "c"}
+
+	var cursors []string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				// No WebSockets.
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			var body struct {
+				Query     string `json:"query"`
+				Variables struct {
+					After *int `json:"after"`
+				} `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+
+			if strings.Contains(body.Query, "OnInvalid") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":[{"message":"invalid"}]}`))
+				return
+			}
+
+			n := 0
+			if body.Variables.After != nil {
+				n = *body.Variables.After + 1
+			}
+			cursors = append(cursors, fmt.Sprint(n))
+
+			if n >= len(events) {
+				// The same result again.
+				n = len(events) - 1
+			}
+
+			fmt.Fprintf(w, `{"data":{"event":{"cursor":%d,"name":%q}}}`, n, events[n])
+		},
+	))
+	defer ts.Close()
+
+	errStop := errors.New("stop")
+
+	subscribe := func(c *Client, query string) ([]string, error) {
+		var got []string
+
+		err := c.Subscribe(context.Background(), query, nil,
+			func(data json.RawMessage, errs []Error) error {
+				got = append(got, string(data))
+				if len(got) == len(events) {
+					return errStop
+				}
+				return nil
+			},
+		)
+
+		return got, err
+	}
+
+	want := `{"event":{"cursor":0,"name":"a"}},{"event":{"cursor":1,"name":"b"}},{"event":{"cursor":2,"name":"c"}}`
+
+	t.Run("Cursor", func(t *testing.T) {
+		cursors = nil
+
+		var sleeps []time.Duration
+
+		transport := &PollingTransport{
+			Interval:       time.Second,
+			CursorPath:     "event.cursor",
+			CursorVariable: "after",
+			sleep: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			},
+		}
+
+		got, err := subscribe(NewClient(ts.URL, WithSubscriptionTransport(transport)), "subscription OnEvent { event { cursor name } }")
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+
+		if got := strings.Join(got, ","); got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+
+		if got, want := strings.Join(cursors, ","), "0,1,2"; got != want {
+			t.Errorf("cursors = %s, want %s", got, want)
+		}
+
+		if got, want := fmt.Sprint(sleeps), "[1s 1s]"; got != want {
+			t.Errorf("sleeps = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		cursors = nil
+
+		c := NewClient(ts.URL, WithSubscriptionFallback(&PollingTransport{
+			CursorPath:     "event.cursor",
+			CursorVariable: "after",
+		}))
+
+		got, err := subscribe(c, "subscription OnEvent { event { cursor name } }")
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+
+		if got := strings.Join(got, ","); got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := subscribe(NewClient(ts.URL, WithSubscriptionTransport(&PollingTransport{})), "subscription OnInvalid { event }")
+
+		var subErr *SubscriptionError
+		if !errors.As(err, &subErr) {
+			t.Fatalf("err = %v, want %T", err, subErr)
+		}
+
+		if got, want := subErr.Error(), "subscription error: invalid"; got != want {
+			t.Errorf("subErr.Error() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		var gotHeader http.Header
+
+		c := NewClient(ts.URL,
+			WithSubscriptionTransport(&PollingTransport{}),
+			WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				gotHeader = r.Header
+				return http.DefaultTransport.RoundTrip(r)
+			})}),
+			WithRequestOptions(func(r *http.Request) {
+				r.Header.Set("X-Client", "1")
+			}),
+			WithDecodeLimits(DecodeLimits{MaxDepth: 2}),
+		)
+
+		_, err := subscribe(c, "subscription OnEvent { event { cursor name } }")
+
+		var limitErr *DecodeLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("err = %v, want %T", err, limitErr)
+		}
+
+		if got, want := gotHeader.Get("X-Client"), "1"; got != want {
+			t.Errorf("X-Client = %q, want %q", got, want)
+		}
+
+		if got, want := gotHeader.Get("User-Agent"), DefaultUserAgent(); got != want {
+			t.Errorf("User-Agent = %q, want %q", got, want)
+		}
+	})
+}
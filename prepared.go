@@ -0,0 +1,170 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// PreparedOp is an operation prepared by Prepare, to be executed repeatedly.
+// The work that only depends on the query is done once: parsing it,
+// minifying it, hashing it and encoding the part of the request body before
+// the variables. A PreparedOp is safe for concurrent use.
+type PreparedOp struct {
+	client *Client
+	name   string
+	hash   string
+	op     operation
+}
+
+// Prepare parses query and returns a PreparedOp executing it with c. The
+// query is sent with ignored tokens, i.e. whitespace, commas and comments,
+// removed, or by ID if it is one of the client's trusted documents. An error
+// is returned if a different query with the same operation name was
+// prepared with c, or a client derived from it, before.
+func (c *Client) Prepare(query string) (*PreparedOp, error) {
+	doc, err := graphql.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing query: %v", err)
+	}
+
+	minified, err := graphql.Minify(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing query: %v", err)
+	}
+
+	var name string
+	if len(doc.Operations) == 1 {
+		name = doc.Operations[0].Name
+	}
+
+	sum := sha256.Sum256([]byte(minified))
+
+	op := operation{query: minified}
+
+	if id, ok := c.trustedDocumentID(minified); ok {
+		op.prefix = c.documents.prefix(id)
+		op.id = id
+	} else {
+		var buf bytes.Buffer
+		writeRequestPrefix(&buf, minified)
+		op.prefix = buf.Bytes()
+
+		key := name
+		if key == "" {
+			key = hex.EncodeToString(sum[:])
+		}
+		if err := c.prepared.add(key, query, minified); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PreparedOp{
+		client: c,
+		name:   name,
+		hash:   hex.EncodeToString(sum[:]),
+		op:     op,
+	}, nil
+}
+
+// Operations returns the operations registered with the client, e.g. to be
+// validated against the schema of the server, see
+// graphqltest.AssertValidClientOperations: the queries of its trusted
+// documents, see WithTrustedDocuments, minified and keyed by "document " and
+// their ID, and the other queries prepared with Prepare, keyed by "prepared "
+// and their operation name, or hash if they have none. Documents loaded by
+// operation name, see NewNamedTrustedDocuments, have no query to return.
+func (c *Client) Operations() map[string]string {
+	ops := c.prepared.snapshot("prepared ")
+
+	if c.documents != nil {
+		for id, query := range c.documents.queries {
+			ops["document "+id] = query
+		}
+	}
+
+	return ops
+}
+
+// preparedQueries records prepared queries, by operation name or hash.
+type preparedQueries struct {
+	mu      sync.Mutex
+	queries map[string]preparedQuery
+}
+
+// preparedQuery is a prepared query as passed to Prepare and minified.
+type preparedQuery struct {
+	query    string
+	minified string
+}
+
+// add records query under key, unless a different query is recorded under
+// it, in which case an error is returned. Queries only differing in ignored
+// tokens are the same.
+func (p *preparedQueries) add(key, query, minified string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if prev, ok := p.queries[key]; ok {
+		if prev.minified != minified {
+			return fmt.Errorf("error preparing query: a different operation named %q was prepared", key)
+		}
+		return nil
+	}
+
+	if p.queries == nil {
+		p.queries = make(map[string]preparedQuery)
+	}
+	p.queries[key] = preparedQuery{query: query, minified: minified}
+
+	return nil
+}
+
+// snapshot returns the queries keyed by prefix and their key.
+func (p *preparedQueries) snapshot(prefix string) map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	queries := make(map[string]string, len(p.queries))
+	for key, q := range p.queries {
+		queries[prefix+key] = q.query
+	}
+
+	return queries
+}
+
+func (c *Client) trustedDocumentID(query string) (string, bool) {
+	if c.documents == nil {
+		return "", false
+	}
+	return c.documents.ID(query)
+}
+
+// Name returns the name of the operation, or an empty string if it is
+// anonymous or the query has more than one operation.
+func (p *PreparedOp) Name() string {
+	return p.name
+}
+
+// Text returns the minified query sent to the server.
+func (p *PreparedOp) Text() string {
+	return p.op.query
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the minified query, as used to
+// identify persisted queries.
+func (p *PreparedOp) Hash() string {
+	return p.hash
+}
+
+// Query executes the operation with the given variables, as Client.Query
+// does.
+func (p *PreparedOp) Query(ctx context.Context, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	return p.client.query(ctx, p.op, variables, data, reqOpts)
+}
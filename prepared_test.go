@@ -0,0 +1,175 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Prepare(t *testing.T) {
+	var gotBody string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+
+			w.Write([]byte(`{"data":{"user":{"name":"foo"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Buffered", nil},
+		{"Streaming", []Option{WithStreamingRequests()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(ts.URL, tc.opts...)
+
+			op, err := c.Prepare(`
+				# Fetches a user.
+				query GetUser($id: ID!) {
+					user(id: $id) { name }
+				}
+			`)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := op.Name(), "GetUser"; got != want {
+				t.Errorf("op.Name() = %q, want %q", got, want)
+			}
+
+			if got, want := op.Text(), "query GetUser($id:ID!){user(id:$id){name}}"; got != want {
+				t.Errorf("op.Text() = %q, want %q", got, want)
+			}
+
+			if got, want := op.Hash(), "b538b6ac54b9d52923ea8c90c5855d5dc576de5758f124924d614af27619eb5c"; got != want {
+				t.Errorf("op.Hash() = %q, want %q", got, want)
+			}
+
+			for _, id := range []string{"1", "2"} {
+				var data struct {
+					User struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				}
+
+				if err := op.Query(context.Background(), map[string]interface{}{"id": id}, &data); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if got, want := data.User.Name, "foo"; got != want {
+					t.Errorf("data.User.Name = %q, want %q", got, want)
+				}
+
+				var body struct {
+					Query     string            `json:"query"`
+					Variables map[string]string `json:"variables"`
+				}
+
+				if err := json.Unmarshal([]byte(gotBody), &body); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if got, want := body.Query, op.Text(); got != want {
+					t.Errorf("query = %q, want %q", got, want)
+				}
+
+				if got, want := body.Variables["id"], id; got != want {
+					t.Errorf("variables.id = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("CanonicalRequestBody", func(t *testing.T) {
+		op, err := NewClient(ts.URL).Prepare("{ a }")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := op.Query(context.Background(), map[string]interface{}{"b": 1, "a": "<"}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotBody, `{"query":"{a}","variables":{"a":"\u003c","b":1}}`; got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("SameName", func(t *testing.T) {
+		c := NewClient(ts.URL)
+
+		if _, err := c.Prepare("query GetUser { user { id } }"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := c.With().Prepare("query GetUser {\n\tuser { id }\n}"); err != nil {
+			t.Errorf("unexpected error preparing the same query: %v", err)
+		}
+
+		_, err := c.Prepare("query GetUser { user { name } }")
+
+		if got, want := fmt.Sprint(err), `error preparing query: a different operation named "GetUser" was prepared`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+
+		if got, want := c.Operations()["prepared GetUser"], "query GetUser { user { id } }"; got != want {
+			t.Errorf("Operations()[\"prepared GetUser\"] = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		_, err := NewClient(ts.URL).Prepare("query {")
+
+		if err == nil {
+			t.Fatal("err = nil, want error")
+		}
+	})
+}
+
+func BenchmarkPreparedOp_Query(b *testing.B) {
+	c := NewClient("http://example.com",
+		WithHTTPClient(&http.Client{Transport: staticTransport(http.StatusOK, []byte(`{"data":{}}`))}),
+	)
+
+	query := "query GetUser($id: ID!) {\n  user(id: $id) {\n    name\n  }\n}"
+	variables := map[string]interface{}{"id": "1"}
+
+	b.Run("Query", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if err := c.Query(context.Background(), query, variables, nil); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+
+	b.Run("Prepared", func(b *testing.B) {
+		op, err := c.Prepare(query)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if err := op.Query(context.Background(), variables, nil); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
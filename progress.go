@@ -0,0 +1,90 @@
+package graphqlclient
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProgressInterval is the interval between progress reports used by
+// WithRequestProgress if none is given.
+const DefaultProgressInterval = time.Second
+
+// RequestProgress is the progress of a request, as reported to the function
+// passed to WithRequestProgress.
+type RequestProgress struct {
+	// Elapsed is the time since the request was sent.
+	Elapsed time.Duration
+
+	// BytesSent is the number of bytes of the request body sent so far,
+	// out of BytesTotal, which is -1 if unknown, e.g. for streamed bodies.
+	BytesSent  int64
+	BytesTotal int64
+
+	// BytesRead is the number of bytes of the response body read so far,
+	// which is zero while awaiting the response.
+	BytesRead int64
+}
+
+// WithRequestProgress makes the client call fn with the progress of each
+// request every interval until the request is done, e.g. so that
+// command-line tools can show activity while awaiting long-running queries,
+// or the progress of sending requests with large variables. Requests whose
+// progress stalls can be aborted by canceling their context from fn.
+// A non-positive interval means DefaultProgressInterval. Requests done within
+// interval are not reported. fn is called from a separate goroutine, but
+// never concurrently for the same request.
+func WithRequestProgress(interval time.Duration, fn func(RequestProgress)) Option {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	return func(c *Client) {
+		c.progressInterval = interval
+		c.progress = fn
+	}
+}
+
+// progressTracker reports the progress of a request until stopped.
+type progressTracker struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// startProgress starts reporting progress to fn every interval, with the
+// number of bytes of the request body of size total sent so far loaded from
+// bytesSent, and of the response body read so far from bytesRead.
+func startProgress(interval time.Duration, fn func(RequestProgress), bytesSent *int64, total int64, bytesRead *int64) *progressTracker {
+	p := &progressTracker{stop: make(chan struct{})}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fn(RequestProgress{
+					Elapsed:    time.Since(start),
+					BytesSent:  atomic.LoadInt64(bytesSent),
+					BytesTotal: total,
+					BytesRead:  atomic.LoadInt64(bytesRead),
+				})
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// done stops reporting progress, after any ongoing report.
+func (p *progressTracker) done() {
+	close(p.stop)
+	p.wg.Wait()
+}
@@ -0,0 +1,148 @@
+package graphqlclient
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRequestProgress(t *testing.T) {
+	body := `{"data":{"foo":"` + strings.Repeat("x", 1000) + `"}}`
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+
+			w.Write([]byte(body[:100]))
+			w.(http.Flusher).Flush()
+
+			time.Sleep(50 * time.Millisecond)
+
+			w.Write([]byte(body[100:]))
+		},
+	))
+	defer ts.Close()
+
+	var (
+		mu      sync.Mutex
+		reports []RequestProgress
+	)
+
+	c := NewClient(ts.URL, WithRequestProgress(10*time.Millisecond, func(p RequestProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reports = append(reports, p)
+	}))
+
+	var data struct {
+		Foo string `json:"foo"`
+	}
+
+	if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+
+	if len(reports) < 4 {
+		mu.Unlock()
+		t.Fatalf("len(reports) = %d, want at least 4", len(reports))
+	}
+
+	if got, want := reports[0].BytesRead, int64(0); got != want {
+		t.Errorf("reports[0].BytesRead = %d, want %d", got, want)
+	}
+
+	var sawPartialBody bool
+
+	for i := 1; i < len(reports); i++ {
+		if reports[i].BytesRead == 100 {
+			sawPartialBody = true
+		}
+
+		if reports[i].Elapsed < reports[i-1].Elapsed {
+			t.Errorf("reports[%d].Elapsed = %v, want at least %v", i, reports[i].Elapsed, reports[i-1].Elapsed)
+		}
+	}
+
+	if !sawPartialBody {
+		t.Errorf("no report with BytesRead = 100 in %+v", reports)
+	}
+
+	n := len(reports)
+	mu.Unlock()
+
+	time.Sleep(30 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if got, want := len(reports), n; got != want {
+		t.Errorf("len(reports) after done = %d, want %d", got, want)
+	}
+}
+
+func TestWithRequestProgress_Upload(t *testing.T) {
+	// The transport reads the request body slowly, in halves.
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		half := make([]byte, req.ContentLength/2)
+
+		if _, err := io.ReadFull(req.Body, half); err != nil {
+			return nil, err
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		if _, err := io.Copy(ioutil.Discard, req.Body); err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data":{}}`)),
+		}, nil
+	})
+
+	var (
+		mu      sync.Mutex
+		reports []RequestProgress
+	)
+
+	c := NewClient("http://example.com",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRequestProgress(10*time.Millisecond, func(p RequestProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			reports = append(reports, p)
+		}),
+	)
+
+	variables := map[string]interface{}{"s": strings.Repeat("x", 10000)}
+
+	if err := c.Query(context.Background(), "foo-query", variables, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(reports) == 0 {
+		t.Fatalf("no reports")
+	}
+
+	if got, want := reports[0].BytesTotal, int64(10042); got != want {
+		t.Errorf("reports[0].BytesTotal = %d, want %d", got, want)
+	}
+
+	if got, want := reports[0].BytesSent, reports[0].BytesTotal/2; got != want {
+		t.Errorf("reports[0].BytesSent = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Op is an operation executed by QueryAll. Its data payload is decoded into
+// Data as by Query.
+type Op struct {
+	Query     string
+	Variables map[string]interface{}
+	Data      interface{}
+	ReqOpts   []func(*http.Request)
+}
+
+// QueryAll executes the independent operations ops concurrently, at most
+// maxConcurrency at a time, or all at once if maxConcurrency is not positive.
+// It returns once all operations have completed, with the error of each
+// operation at its index in errs, or nil if all operations succeeded.
+// Operations not yet started when ctx is done fail with ctx.Err().
+func (c *Client) QueryAll(ctx context.Context, ops []Op, maxConcurrency int) (errs []error) {
+	if maxConcurrency <= 0 || maxConcurrency > len(ops) {
+		maxConcurrency = len(ops)
+	}
+
+	opErrs := make([]error, len(ops))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for n := 0; n < maxConcurrency; n++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					opErrs[i] = err
+					continue
+				}
+
+				op := ops[i]
+				opErrs[i] = c.Query(ctx, op.Query, op.Variables, op.Data, op.ReqOpts...)
+			}
+		}()
+	}
+
+	for i := range ops {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+
+	for _, err := range opErrs {
+		if err != nil {
+			return opErrs
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_QueryAll(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			time.Sleep(5 * time.Millisecond)
+
+			var body struct {
+				Variables struct {
+					N int `json:"n"`
+				} `json:"variables"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if body.Variables.N == 3 {
+				w.Write([]byte(`{"errors":[{"message":"foo-error"}]}`))
+				return
+			}
+
+			fmt.Fprintf(w, `{"data":%d}`, body.Variables.N*10)
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+
+	t.Run("Success", func(t *testing.T) {
+		data := make([]int, 8)
+		ops := make([]Op, len(data))
+
+		for i := range ops {
+			ops[i] = Op{
+				Query:     "foo-query",
+				Variables: map[string]interface{}{"n": i},
+				Data:      &data[i],
+			}
+		}
+
+		maxInFlight = 0
+		errs := c.QueryAll(context.Background(), ops, 2)
+
+		for i, err := range errs {
+			if got, want := fmt.Sprint(err), "<nil>"; i != 3 && got != want {
+				t.Errorf("errs[%d] = %q, want %q", i, got, want)
+			}
+		}
+
+		if got, want := fmt.Sprint(errs[3]), "200 OK: foo-error"; got != want {
+			t.Errorf("errs[3] = %q, want %q", got, want)
+		}
+
+		if got, want := fmt.Sprint(data), "[0 10 20 0 40 50 60 70]"; got != want {
+			t.Errorf("data = %s, want %s", got, want)
+		}
+
+		if got, want := maxInFlight, 2; got != want {
+			t.Errorf("maxInFlight = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("NoErrors", func(t *testing.T) {
+		var data int
+
+		if errs := c.QueryAll(context.Background(), []Op{{Query: "foo-query", Data: &data}}, 0); errs != nil {
+			t.Errorf("errs = %v, want nil", errs)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		errs := c.QueryAll(ctx, []Op{{Query: "foo-query"}, {Query: "foo-query"}}, 1)
+
+		for i, err := range errs {
+			if got, want := err, context.Canceled; got != want {
+				t.Errorf("errs[%d] = %v, want %v", i, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Throttler is implemented by rate limiters pacing requests. Wait blocks
+// until a request may be sent or ctx is done.
+type Throttler interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimiter is an http.RoundTripper keeping track of the rate limit or cost
+// budget the server reports in response headers, and a Throttler pacing
+// requests to stay within it. Use it as the Transport of the *http.Client
+// passed to New, and call Wait before sending requests, or set it as the
+// Throttle of a Paginator.
+//
+// Once the remaining budget drops below Threshold of the limit, Wait spreads
+// requests out evenly over the time left until the budget is reset, and when
+// it is exhausted, waits for the reset. A Retry-After header, typically sent
+// with 429 Too Many Requests, holds back all requests until the time given.
+type RateLimiter struct {
+	// LimitHeader, RemainingHeader and ResetHeader are the names of the
+	// headers holding the budget per window, the budget remaining in the
+	// current window and when the window is reset, either in seconds from
+	// now or as a Unix timestamp. If RemainingHeader is empty, the
+	// X-RateLimit-* headers are used, or, if absent, the RateLimit-*
+	// headers.
+	LimitHeader     string
+	RemainingHeader string
+	ResetHeader     string
+
+	// Threshold is the fraction of the limit below which requests are
+	// paced. Defaults to 0.1.
+	Threshold float64
+
+	transport http.RoundTripper
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu         sync.Mutex
+	limit      int
+	remaining  int
+	reset      time.Time
+	retryAfter time.Time
+}
+
+// NewRateLimiter returns a RateLimiter sending requests using transport, or
+// http.DefaultTransport if nil.
+func NewRateLimiter(transport http.RoundTripper) *RateLimiter {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &RateLimiter{
+		transport: transport,
+		remaining: -1,
+		now:       time.Now,
+		sleep:     sleep,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (l *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := l.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	l.update(resp)
+
+	return resp, nil
+}
+
+// Wait implements Throttler.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	now := l.now()
+
+	var d time.Duration
+
+	switch {
+	case l.retryAfter.After(now):
+		d = l.retryAfter.Sub(now)
+	case l.remaining < 0 || !l.reset.After(now):
+		// Budget unknown, or the window has been reset.
+	case l.remaining == 0:
+		d = l.reset.Sub(now)
+	case float64(l.remaining) < l.threshold()*float64(l.limit):
+		d = l.reset.Sub(now) / time.Duration(l.remaining)
+		l.remaining--
+	default:
+		l.remaining--
+	}
+
+	l.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	return l.sleep(ctx, d)
+}
+
+func (l *RateLimiter) threshold() float64 {
+	if l.Threshold > 0 {
+		return l.Threshold
+	}
+	return 0.1
+}
+
+func (l *RateLimiter) update(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			l.retryAfter = now.Add(time.Duration(secs) * time.Second)
+		} else if t, err := http.ParseTime(v); err == nil {
+			l.retryAfter = t
+		}
+	}
+
+	limitHeader, remainingHeader, resetHeader := l.LimitHeader, l.RemainingHeader, l.ResetHeader
+	if remainingHeader == "" {
+		limitHeader, remainingHeader, resetHeader = "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"
+		if resp.Header.Get(remainingHeader) == "" {
+			limitHeader, remainingHeader, resetHeader = "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"
+		}
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get(remainingHeader))
+	if err != nil {
+		return
+	}
+
+	l.remaining = remaining
+
+	if limit, err := strconv.Atoi(resp.Header.Get(limitHeader)); err == nil {
+		l.limit = limit
+	}
+
+	if reset, err := strconv.ParseInt(resp.Header.Get(resetHeader), 10, 64); err == nil {
+		// Values this large can only be Unix timestamps.
+		if reset > 1e9 {
+			l.reset = time.Unix(reset, 0)
+		} else {
+			l.reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
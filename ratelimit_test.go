@@ -0,0 +1,196 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	for _, tc := range []struct {
+		name    string
+		limiter func(l *RateLimiter)
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "NoHeaders",
+			headers: map[string]string{},
+			want:    "[]",
+		},
+		{
+			name:    "AboveThreshold",
+			headers: map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "50", "X-RateLimit-Reset": "10"},
+			want:    "[]",
+		},
+		{
+			name:    "BelowThreshold",
+			headers: map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "5", "X-RateLimit-Reset": "10"},
+			want:    "[2s]",
+		},
+		{
+			name:    "Exhausted",
+			headers: map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1600000030"},
+			want:    "[30s]",
+		},
+		{
+			name:    "RateLimitHeaders",
+			headers: map[string]string{"RateLimit-Limit": "10", "RateLimit-Remaining": "0", "RateLimit-Reset": "5"},
+			want:    "[5s]",
+		},
+		{
+			name: "CustomHeaders",
+			limiter: func(l *RateLimiter) {
+				l.LimitHeader = "X-Cost-Limit"
+				l.RemainingHeader = "X-Cost-Remaining"
+				l.ResetHeader = "X-Cost-Reset"
+				l.Threshold = 0.5
+			},
+			headers: map[string]string{"X-Cost-Limit": "1000", "X-Cost-Remaining": "400", "X-Cost-Reset": "60"},
+			want:    "[150ms]",
+		},
+		{
+			name:    "RetryAfter",
+			headers: map[string]string{"Retry-After": "7"},
+			want:    "[7s]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					for k, v := range tc.headers {
+						w.Header().Set(k, v)
+					}
+					w.Write([]byte(`{"data":{}}`))
+				},
+			))
+			defer ts.Close()
+
+			var sleeps []time.Duration
+
+			l := NewRateLimiter(nil)
+			l.now = func() time.Time { return now }
+			l.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}
+
+			if tc.limiter != nil {
+				tc.limiter(l)
+			}
+
+			c := New(ts.URL, &http.Client{Transport: l})
+
+			for n := 0; n < 2; n++ {
+				if err := l.Wait(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				var data interface{}
+				if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if got, want := fmt.Sprint(sleeps), tc.want; got != want {
+				t.Errorf("sleeps = %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		l := NewRateLimiter(nil)
+		l.retryAfter = time.Now().Add(time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if got, want := l.Wait(ctx), context.Canceled; got != want {
+			t.Errorf("l.Wait(ctx) = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestPaginator_Throttle(t *testing.T) {
+	var requests int
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			switch requests {
+			case 2, 3:
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"errors":[{"message":"slow down"}]}`))
+			case 1:
+				w.Write([]byte(`{"data":{"items":["a"]}}`))
+			default:
+				w.Write([]byte(`{"data":{"items":[]}}`))
+			}
+		},
+	))
+	defer ts.Close()
+
+	var sleeps []time.Duration
+
+	l := NewRateLimiter(nil)
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d.Round(time.Second))
+		return nil
+	}
+
+	p := New(ts.URL, &http.Client{Transport: l}).Paginate("foo-query", nil, Offset("items", 1, nil))
+	p.Throttle = l
+
+	var nodes []string
+
+	err := p.ForEachNode(context.Background(), func(node json.RawMessage) error {
+		nodes = append(nodes, string(node))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := strings.Join(nodes, ","), `"a"`; got != want {
+		t.Errorf("nodes = %q, want %q", got, want)
+	}
+
+	if got, want := requests, 4; got != want {
+		t.Errorf("requests = %d, want %d", got, want)
+	}
+
+	if got, want := fmt.Sprint(sleeps), "[1s 1s]"; got != want {
+		t.Errorf("sleeps = %s, want %s", got, want)
+	}
+
+	t.Run("GiveUp", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"errors":[{"message":"slow down"}]}`))
+			},
+		))
+		defer ts.Close()
+
+		p := New(ts.URL, http.DefaultClient).Paginate("foo-query", nil, Offset("items", 1, nil))
+		p.Throttle = NewRateLimiter(nil)
+
+		if p.Next(context.Background()) {
+			t.Fatal("Next = true, want false")
+		}
+
+		if got, want := fmt.Sprint(p.Err()), "429 Too Many Requests: slow down"; got != want {
+			t.Errorf("p.Err() = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Defaults of ReconnectPolicy.
+const (
+	DefaultMinReconnectBackoff = 500 * time.Millisecond
+	DefaultMaxReconnectBackoff = 30 * time.Second
+)
+
+// ReconnectPolicy configures the reconnection of subscriptions whose
+// connection is lost, see WithSubscriptionReconnect.
+type ReconnectPolicy struct {
+	// MinBackoff is the delay before the first reconnection attempt, which
+	// doubles with each failed attempt up to MaxBackoff. They default to
+	// DefaultMinReconnectBackoff and DefaultMaxReconnectBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of consecutive attempts after which
+	// Subscribe gives up and returns the last error. Zero means no limit.
+	MaxAttempts int
+
+	// OnReconnect, if set, is called before each reconnection attempt.
+	OnReconnect func(ReconnectEvent)
+
+	// sleep is replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// ReconnectEvent describes a reconnection attempt of a subscription.
+type ReconnectEvent struct {
+	// Attempt is the number of the attempt since the subscription last
+	// received an event, starting at 1.
+	Attempt int
+
+	// Delay is the backoff before the attempt.
+	Delay time.Duration
+
+	// Err is the error that ended the previous connection.
+	Err error
+}
+
+// WithSubscriptionReconnect makes Subscribe reconnect and re-issue the
+// subscription, with exponential backoff, when its connection is lost.
+// Subscriptions completed or terminated with errors by the server, stopped
+// by the handler or whose ctx is done are not reconnected. Events may be
+// missed while the subscription is disconnected.
+func WithSubscriptionReconnect(p ReconnectPolicy) Option {
+	return func(c *Client) {
+		c.reconnect = &p
+	}
+}
+
+// subscribe sends sub using t until it ends without losing its connection.
+func (p *ReconnectPolicy) subscribe(ctx context.Context, t SubscriptionTransport, sub *Subscription, handler SubscriptionHandler) error {
+	sleepFn := p.sleep
+	if sleepFn == nil {
+		sleepFn = sleep
+	}
+
+	var attempt int
+
+	for {
+		var (
+			received   bool
+			handlerErr error
+		)
+
+		err := t.Subscribe(ctx, sub, func(data json.RawMessage, errs []Error) error {
+			received = true
+			handlerErr = handler(data, errs)
+			return handlerErr
+		})
+
+		var subErr *SubscriptionError
+
+		switch {
+		case err == nil, ctx.Err() != nil, err == handlerErr, errors.As(err, &subErr):
+			return err
+		}
+
+		if received {
+			attempt = 0
+		}
+
+		attempt++
+
+		if p.MaxAttempts > 0 && attempt > p.MaxAttempts {
+			return err
+		}
+
+		d := p.backoff(attempt)
+
+		subscriptionMonitor(ctx).reconnect()
+
+		if p.OnReconnect != nil {
+			p.OnReconnect(ReconnectEvent{Attempt: attempt, Delay: d, Err: err})
+		}
+
+		if err := sleepFn(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the delay before attempt.
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = DefaultMinReconnectBackoff
+	}
+	if max <= 0 {
+		max = DefaultMaxReconnectBackoff
+	}
+
+	d := min
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return d
+}
@@ -0,0 +1,169 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedTransport is a SubscriptionTransport sending the events of a
+// script per connection, then ending the connection with its error.
+type scriptedTransport struct {
+	connections []scriptedConnection
+	n           int
+}
+
+type scriptedConnection struct {
+	events []string
+	err    error
+}
+
+func (t *scriptedTransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	conn := t.connections[t.n]
+	t.n++
+
+	for _, e := range conn.events {
+		if err := handler(json.RawMessage(e), nil); err != nil {
+			return err
+		}
+	}
+
+	return conn.err
+}
+
+func TestWithSubscriptionReconnect(t *testing.T) {
+	errLost := errors.New("connection lost")
+
+	for _, tc := range []struct {
+		name           string
+		connections    []scriptedConnection
+		maxAttempts    int
+		handlerErr     error
+		wantEvents     string
+		wantErr        string
+		wantReconnects string
+	}{
+		{
+			name: "Reconnect",
+			connections: []scriptedConnection{
+				{[]string{"1"}, errLost},
+				{nil, errLost},
+				{nil, errLost},
+				{[]string{"2"}, errLost},
+				{[]string{"3"}, nil},
+			},
+			wantEvents:     "1,2,3",
+			wantErr:        "<nil>",
+			wantReconnects: "1 500ms,2 1s,3 2s,1 500ms",
+		},
+		{
+			name: "MaxAttempts",
+			connections: []scriptedConnection{
+				{nil, errLost},
+				{nil, errLost},
+				{nil, errLost},
+			},
+			maxAttempts:    2,
+			wantErr:        "connection lost",
+			wantReconnects: "1 500ms,2 1s",
+		},
+		{
+			name: "SubscriptionError",
+			connections: []scriptedConnection{
+				{[]string{"1"}, &SubscriptionError{Errors: []Error{{Message: "invalid"}}}},
+			},
+			wantEvents: "1",
+			wantErr:    "subscription error: invalid",
+		},
+		{
+			name: "HandlerError",
+			connections: []scriptedConnection{
+				{[]string{"1"}, nil},
+			},
+			handlerErr: errLost,
+			wantEvents: "1",
+			wantErr:    "connection lost",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var events, reconnects, sleeps []string
+
+			p := ReconnectPolicy{
+				MaxAttempts: tc.maxAttempts,
+				OnReconnect: func(e ReconnectEvent) {
+					if e.Err != errLost {
+						t.Errorf("e.Err = %v, want %v", e.Err, errLost)
+					}
+					reconnects = append(reconnects, fmt.Sprintf("%d %v", e.Attempt, e.Delay))
+				},
+			}
+			p.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d.String())
+				return nil
+			}
+
+			c := NewClient("http://example.com/graphql",
+				WithSubscriptionTransport(&scriptedTransport{connections: tc.connections}),
+				WithSubscriptionReconnect(p),
+			)
+
+			err := c.Subscribe(context.Background(), "subscription { foo }", nil,
+				func(data json.RawMessage, errs []Error) error {
+					events = append(events, string(data))
+					return tc.handlerErr
+				},
+			)
+
+			if got, want := fmt.Sprint(err), tc.wantErr; got != want {
+				t.Errorf("err = %q, want %q", got, want)
+			}
+
+			if got, want := strings.Join(events, ","), tc.wantEvents; got != want {
+				t.Errorf("events = %q, want %q", got, want)
+			}
+
+			if got, want := strings.Join(reconnects, ","), tc.wantReconnects; got != want {
+				t.Errorf("reconnects = %q, want %q", got, want)
+			}
+
+			if got, want := len(sleeps), len(reconnects); got != want {
+				t.Errorf("len(sleeps) = %d, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(&scriptedTransport{connections: []scriptedConnection{{nil, context.Canceled}}}),
+			WithSubscriptionReconnect(ReconnectPolicy{}),
+		)
+
+		err := c.Subscribe(ctx, "subscription { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestReconnectPolicy_Backoff(t *testing.T) {
+	p := ReconnectPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	var got []string
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, p.backoff(attempt).String())
+	}
+
+	if got, want := strings.Join(got, ","), "1s,2s,4s,5s,5s"; got != want {
+		t.Errorf("backoffs = %q, want %q", got, want)
+	}
+}
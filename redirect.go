@@ -0,0 +1,65 @@
+package graphqlclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// WithRedirects controls whether the client follows redirects of operations
+// sent as POST requests. By default they are followed as by http.Client:
+// 307 and 308 redirects are followed by sending the request again, but 301,
+// 302 and 303 redirects, e.g. from HTTP to HTTPS by a misconfigured ingress,
+// by sending a GET request without the operation, which servers typically
+// reject with a confusing error. If follow is false, Query instead returns a
+// *RedirectError. Redirects of GET requests, see WithGETQueries, are always
+// followed.
+func WithRedirects(follow bool) Option {
+	return func(c *Client) {
+		c.noPOSTRedirects = !follow
+	}
+}
+
+// RedirectError is returned for responses redirecting requests that are not
+// followed, see WithRedirects.
+type RedirectError struct {
+	StatusCode int
+
+	// Location is the URL the request was redirected to.
+	Location string
+}
+
+// Error returns a string representation of the error.
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("%d %s: redirected to %q", e.StatusCode, http.StatusText(e.StatusCode), e.Location)
+}
+
+// errTooManyRedirects is the error of requests redirected more than 10 times,
+// the limit of http.Client by default.
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// withoutPOSTRedirects returns a copy of httpClient not following redirects
+// of POST requests.
+func withoutPOSTRedirects(httpClient *http.Client) *http.Client {
+	hc := *httpClient
+
+	checkRedirect := hc.CheckRedirect
+
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if via[0].Method == http.MethodPost {
+			return http.ErrUseLastResponse
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		if len(via) >= 10 {
+			return errTooManyRedirects
+		}
+
+		return nil
+	}
+
+	return &hc
+}
@@ -0,0 +1,72 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRedirects(t *testing.T) {
+	var gotMethod string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+				return
+			}
+
+			gotMethod = r.Method
+
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	t.Run("Follow", func(t *testing.T) {
+		err := NewClient(ts.URL+"/old").Query(context.Background(), "{ foo }", nil, nil)
+
+		if got, want := fmt.Sprint(err), "405 Method Not Allowed: method not allowed\n"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+
+		if got, want := gotMethod, http.MethodGet; got != want {
+			t.Errorf("method = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("DontFollow", func(t *testing.T) {
+		gotMethod = ""
+
+		err := NewClient(ts.URL+"/old", WithRedirects(false)).Query(context.Background(), "{ foo }", nil, nil)
+
+		var redirectErr *RedirectError
+		if !errors.As(err, &redirectErr) {
+			t.Fatalf("err = %v, want %T", err, redirectErr)
+		}
+
+		if got, want := redirectErr.Error(), `301 Moved Permanently: redirected to "/new"`; got != want {
+			t.Errorf("redirectErr.Error() = %q, want %q", got, want)
+		}
+
+		if got, want := gotMethod, ""; got != want {
+			t.Errorf("method = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		err := NewClient(ts.URL+"/old", WithRedirects(false), WithGETQueries()).Query(context.Background(), "{ foo }", nil, nil)
+
+		if got, want := fmt.Sprint(err), "405 Method Not Allowed: method not allowed\n"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
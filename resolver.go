@@ -0,0 +1,266 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver discovers the URLs of the servers of a GraphQL endpoint, e.g. from
+// DNS SRV records or a service catalog.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// ResolverFunc is a func implementing Resolver.
+type ResolverFunc func(ctx context.Context) ([]string, error)
+
+// Resolve implements Resolver.
+func (f ResolverFunc) Resolve(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// errNoEndpoints is returned when a resolver finds no endpoints.
+var errNoEndpoints = errors.New("no endpoints found")
+
+// WithResolver makes the client send requests to the endpoints discovered by
+// r, in turn, instead of to the URL passed to NewClient. The endpoints are
+// resolved before the first request, and again once refresh has passed
+// since, by one request while the others keep using the previously resolved
+// endpoints. If resolving fails, the previously resolved endpoints are used
+// until refresh has passed again; requests fail only if there are none.
+func WithResolver(r Resolver, refresh time.Duration) Option {
+	return func(c *Client) {
+		c.resolver = &endpointResolver{
+			resolver: r,
+			refresh:  refresh,
+			now:      time.Now,
+		}
+	}
+}
+
+// endpointResolver picks endpoints resolved by a Resolver in turn.
+type endpointResolver struct {
+	resolver Resolver
+	refresh  time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	endpoints []string
+	resolved  time.Time
+	next      int
+
+	// resolving is closed when the resolution in progress, if any, is
+	// done, and err is the error of the last one.
+	resolving chan struct{}
+	err       error
+}
+
+// endpoint returns the endpoint to send the next request to.
+func (r *endpointResolver) endpoint(ctx context.Context) (string, error) {
+	r.mu.Lock()
+
+	switch {
+	case r.resolving == nil && (r.resolved.IsZero() || r.now().Sub(r.resolved) >= r.refresh):
+		r.resolve(ctx)
+	case r.resolving != nil && len(r.endpoints) == 0:
+		// There are no endpoints to use meanwhile.
+		resolving := r.resolving
+		r.mu.Unlock()
+
+		select {
+		case <-resolving:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		r.mu.Lock()
+	}
+
+	defer r.mu.Unlock()
+
+	if len(r.endpoints) == 0 {
+		return "", fmt.Errorf("error resolving endpoints: %v", r.err)
+	}
+
+	endpoint := r.endpoints[r.next%len(r.endpoints)]
+	r.next++
+
+	return endpoint, nil
+}
+
+// resolve resolves the endpoints with r.mu held, releasing it while the
+// resolver is called. If resolving fails with endpoints left to use, they
+// are used until refresh has passed again; otherwise the next request
+// resolves again.
+func (r *endpointResolver) resolve(ctx context.Context) {
+	resolving := make(chan struct{})
+	r.resolving = resolving
+	r.mu.Unlock()
+
+	endpoints, err := r.resolver.Resolve(ctx)
+	if err == nil && len(endpoints) == 0 {
+		err = errNoEndpoints
+	}
+
+	r.mu.Lock()
+
+	if err == nil {
+		r.endpoints = endpoints
+	}
+	if err == nil || len(r.endpoints) > 0 {
+		r.resolved = r.now()
+	}
+
+	r.err = err
+	r.resolving = nil
+	close(resolving)
+}
+
+// SRVResolver resolves endpoints from DNS SRV records. The records of the
+// highest priority, i.e. with the lowest priority number, are used.
+type SRVResolver struct {
+	// Service, Proto and Name are looked up as _Service._Proto.Name, e.g.
+	// _graphql._tcp.example.com. If Service and Proto are empty, Name is
+	// looked up directly.
+	Service string
+	Proto   string
+	Name    string
+
+	// Scheme and Path complete the URLs of endpoints. Scheme defaults to
+	// https.
+	Scheme string
+	Path   string
+
+	// Resolver is used for lookups. Defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// Resolve implements Resolver.
+func (r *SRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Priority < records[j].Priority
+	})
+
+	var endpoints []string
+
+	for _, rec := range records {
+		if rec.Priority != records[0].Priority {
+			break
+		}
+
+		host := strings.TrimSuffix(rec.Target, ".")
+		endpoints = append(endpoints, endpointURL(r.Scheme, host, int(rec.Port), r.Path))
+	}
+
+	return endpoints, nil
+}
+
+// ConsulResolver resolves endpoints from the healthy instances of a service
+// in a Consul catalog.
+type ConsulResolver struct {
+	// Address is the URL of the Consul HTTP API. Defaults to
+	// http://127.0.0.1:8500.
+	Address string
+
+	// Service is the name of the service, and Tag, if set, the tag
+	// instances must have.
+	Service string
+	Tag     string
+
+	// Scheme and Path complete the URLs of endpoints. Scheme defaults to
+	// https.
+	Scheme string
+	Path   string
+
+	// HTTPClient is used for requests to Consul. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Resolve implements Resolver.
+func (r *ConsulResolver) Resolve(ctx context.Context) ([]string, error) {
+	address := r.Address
+	if address == "" {
+		address = "http://127.0.0.1:8500"
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	query := url.Values{"passing": {"true"}}
+	if r.Tag != "" {
+		query.Set("tag", r.Tag)
+	}
+
+	u := strings.TrimSuffix(address, "/") + "/v1/health/service/" + url.PathEscape(r.Service) + "?" + query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error performing request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from Consul: %s", resp.Status)
+	}
+
+	var entries []struct {
+		Node struct {
+			Address string
+		}
+		Service struct {
+			Address string
+			Port    int
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	endpoints := make([]string, len(entries))
+
+	for i, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		endpoints[i] = endpointURL(r.Scheme, host, e.Service.Port, r.Path)
+	}
+
+	return endpoints, nil
+}
+
+func endpointURL(scheme, host string, port int, path string) string {
+	if scheme == "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port)) + path
+}
@@ -0,0 +1,220 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithResolver(t *testing.T) {
+	var servers []string
+
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"data":%q}`, name)
+			},
+		))
+		defer ts.Close()
+
+		servers = append(servers, ts.URL)
+	}
+
+	var (
+		resolves   int
+		endpoints  = servers[:2]
+		resolveErr error
+	)
+
+	c := NewClient("http://example.com", WithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+		resolves++
+		return endpoints, resolveErr
+	}), time.Minute))
+
+	now := time.Unix(1600000000, 0)
+	c.resolver.now = func() time.Time { return now }
+
+	query := func(n int) string {
+		var got []string
+
+		for i := 0; i < n; i++ {
+			var data string
+			if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, data)
+		}
+
+		return strings.Join(got, ",")
+	}
+
+	if got, want := query(3), "a,b,a"; got != want {
+		t.Errorf("servers = %q, want %q", got, want)
+	}
+
+	if got, want := resolves, 1; got != want {
+		t.Errorf("resolves = %d, want %d", got, want)
+	}
+
+	now = now.Add(time.Minute)
+	endpoints = servers[2:]
+
+	if got, want := query(2), "c,c"; got != want {
+		t.Errorf("servers after refresh = %q, want %q", got, want)
+	}
+
+	now = now.Add(time.Minute)
+	resolveErr = errors.New("lookup failed")
+
+	if got, want := query(1), "c"; got != want {
+		t.Errorf("servers after failed refresh = %q, want %q", got, want)
+	}
+
+	if got, want := query(2), "c,c"; got != want {
+		t.Errorf("servers after failed refresh = %q, want %q", got, want)
+	}
+
+	if got, want := resolves, 3; got != want {
+		t.Errorf("resolves after failed refresh = %d, want %d", got, want)
+	}
+
+	t.Run("Refreshing", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			resolves int
+			blocked  = make(chan struct{})
+			release  = make(chan struct{})
+		)
+
+		c := NewClient("http://example.com", WithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+			mu.Lock()
+			resolves++
+			n := resolves
+			mu.Unlock()
+
+			if n == 2 {
+				close(blocked)
+				<-release
+				return servers[1:2], nil
+			}
+			return servers[:1], nil
+		}), time.Minute))
+
+		var nowMu sync.Mutex
+		now := time.Unix(1600000000, 0)
+		c.resolver.now = func() time.Time {
+			nowMu.Lock()
+			defer nowMu.Unlock()
+			return now
+		}
+
+		query := func() string {
+			var data string
+			if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			return data
+		}
+
+		if got, want := query(), "a"; got != want {
+			t.Errorf("server = %q, want %q", got, want)
+		}
+
+		nowMu.Lock()
+		now = now.Add(time.Minute)
+		nowMu.Unlock()
+
+		done := make(chan string)
+		go func() { done <- query() }()
+
+		<-blocked
+
+		// The refresh in progress neither blocks nor is repeated by other
+		// requests.
+		if got, want := query(), "a"; got != want {
+			t.Errorf("server while refreshing = %q, want %q", got, want)
+		}
+
+		close(release)
+
+		if got, want := <-done, "b"; got != want {
+			t.Errorf("server of refreshing request = %q, want %q", got, want)
+		}
+
+		if got, want := query(), "b"; got != want {
+			t.Errorf("server after refresh = %q, want %q", got, want)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if got, want := resolves, 2; got != want {
+			t.Errorf("resolves = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("NoEndpoints", func(t *testing.T) {
+		c := NewClient("http://example.com", WithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+			return nil, nil
+		}), time.Minute))
+
+		err := c.Query(context.Background(), "foo-query", nil, nil)
+
+		if got, want := fmt.Sprint(err), "error resolving endpoints: no endpoints found"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestConsulResolver(t *testing.T) {
+	var gotURL string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotURL = r.URL.String()
+			w.Write([]byte(`[
+				{"Node":{"Address":"10.0.0.1"},"Service":{"Address":"","Port":8080}},
+				{"Node":{"Address":"10.0.0.2"},"Service":{"Address":"10.1.0.2","Port":8081}}
+			]`))
+		},
+	))
+	defer ts.Close()
+
+	r := &ConsulResolver{Address: ts.URL, Service: "graphql", Tag: "v2", Scheme: "http", Path: "/graphql"}
+
+	endpoints, err := r.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := gotURL, "/v1/health/service/graphql?passing=true&tag=v2"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	if got, want := strings.Join(endpoints, ","), "http://10.0.0.1:8080/graphql,http://10.1.0.2:8081/graphql"; got != want {
+		t.Errorf("endpoints = %q, want %q", got, want)
+	}
+}
+
+func TestEndpointURL(t *testing.T) {
+	for _, tc := range []struct {
+		scheme, host string
+		port         int
+		path, want   string
+	}{
+		{"", "example.com", 443, "/graphql", "https://example.com:443/graphql"},
+		{"http", "::1", 8080, "", "http://[::1]:8080"},
+	} {
+		if got := endpointURL(tc.scheme, tc.host, tc.port, tc.path); got != tc.want {
+			t.Errorf("endpointURL = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package graphqlclient
+
+import "encoding/json"
+
+// Result is a data payload kept undecoded, so that parts of it can be decoded
+// on demand. Passed as the data argument of Query, it lets different consumers
+// of one large response each decode only the subtree they need:
+//
+//	var res graphqlclient.Result
+//	if err := c.Query(ctx, query, variables, &res); err != nil {
+//		return err
+//	}
+//
+//	var friends []Friend
+//	if err := res.Get("user.friends.nodes", &friends); err != nil {
+//		return err
+//	}
+type Result struct {
+	data json.RawMessage
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	r.data = append(r.data[:0], b...)
+	return nil
+}
+
+// Raw returns the undecoded data payload.
+func (r *Result) Raw() json.RawMessage {
+	return r.data
+}
+
+// Get decodes the value at the dot-separated path in the data payload into v.
+// Path elements are object keys, or indexes for arrays; an empty path refers
+// to the whole payload. Only the objects and arrays along the path are
+// decoded to find the value. If the value is missing or null, the error wraps
+// ErrPathNotFound.
+func (r *Result) Get(path string, v interface{}) error {
+	if r.data == nil {
+		return ErrPathNotFound
+	}
+	return lookupPath(r.data, path, v)
+}
+
+// Has reports whether the data payload has a value that is not null at path.
+func (r *Result) Has(path string) bool {
+	if r.data == nil {
+		return false
+	}
+	_, err := rawAtPath(r.data, path)
+	return err == nil
+}
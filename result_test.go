@@ -0,0 +1,91 @@
+package graphqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"user":{"name":"foo","friends":{"nodes":[{"name":"a"},{"name":"b"}]},"manager":null}}}`))
+		},
+	))
+	defer ts.Close()
+
+	var res Result
+
+	if err := NewClient(ts.URL).Query(context.Background(), "foo-query", nil, &res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		for _, tc := range []struct {
+			path string
+			want string
+		}{
+			{"user.name", "foo"},
+			{"user.friends.nodes.1.name", "b"},
+		} {
+			var got string
+
+			if err := res.Get(tc.path, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("res.Get(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		}
+
+		var friends []struct {
+			Name string `json:"name"`
+		}
+
+		if err := res.Get("user.friends.nodes", &friends); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := fmt.Sprint(friends), "[{a} {b}]"; got != want {
+			t.Errorf("friends = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, path := range []string{"user.manager", "user.age", "user.friends.nodes.2"} {
+			var v interface{}
+
+			if err := res.Get(path, &v); !errors.Is(err, ErrPathNotFound) {
+				t.Errorf("res.Get(%q) = %v, want %v", path, err, ErrPathNotFound)
+			}
+
+			if res.Has(path) {
+				t.Errorf("res.Has(%q) = true, want false", path)
+			}
+		}
+
+		if !res.Has("user.name") {
+			t.Error(`res.Has("user.name") = false, want true`)
+		}
+	})
+
+	t.Run("DecodeError", func(t *testing.T) {
+		var n int
+
+		if got, want := fmt.Sprint(res.Get("user.name", &n)), `error decoding "user.name": json: cannot unmarshal string into Go value of type int`; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var res Result
+
+		if got, want := res.Get("", new(interface{})), ErrPathNotFound; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package graphqlclient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// WithEndpoint makes the client send requests to url instead of the URL it
+// was created with, e.g. for clients derived with Client.With.
+func WithEndpoint(url string) Option {
+	return func(c *Client) {
+		c.url = url
+	}
+}
+
+// WithWriteEndpoint makes the client send mutations to url instead of its
+// URL, which then only serves queries, e.g. so that queries can be served by
+// read replicas behind a CDN while mutations go to the primary gateway.
+// Operations are routed by their parsed type; documents containing any
+// mutation, or that can't be parsed, are sent to url. The client's resolver,
+// if any, only resolves the endpoints of queries. See ContextWithEndpoint for
+// overriding the routing of single requests.
+func WithWriteEndpoint(url string) Option {
+	return func(c *Client) {
+		c.writeURL = url
+	}
+}
+
+// Endpoint is the endpoint a request is sent to by a client created with
+// WithWriteEndpoint.
+type Endpoint int
+
+const (
+	// AutoEndpoint routes requests by their operation type.
+	AutoEndpoint Endpoint = iota
+
+	// ReadEndpoint sends requests to the client's URL.
+	ReadEndpoint
+
+	// WriteEndpoint sends requests to the write endpoint, e.g. to read
+	// the results of a mutation without replication lag.
+	WriteEndpoint
+)
+
+type endpointKey struct{}
+
+// ContextWithEndpoint returns a copy of ctx carrying e, which clients created
+// with WithWriteEndpoint send requests made with the context to.
+func ContextWithEndpoint(ctx context.Context, e Endpoint) context.Context {
+	return context.WithValue(ctx, endpointKey{}, e)
+}
+
+// operationRoutes caches whether queries, as passed to Query, contain
+// mutations, for up to maxCachedOperations queries.
+type operationRoutes struct {
+	writes sync.Map
+	cached int32
+}
+
+// isWrite reports whether a request made with ctx sending query is sent to
+// the write endpoint.
+func (c *Client) isWrite(ctx context.Context, query string) bool {
+	switch e, _ := ctx.Value(endpointKey{}).(Endpoint); e {
+	case ReadEndpoint:
+		return false
+	case WriteEndpoint:
+		return true
+	}
+
+	return c.isMutation(query)
+}
+
+// isMutation reports whether query contains a mutation, or can't be parsed.
+func (c *Client) isMutation(query string) bool {
+	if write, ok := c.routes.writes.Load(query); ok {
+		return write.(bool)
+	}
+
+	write := true
+
+	if doc, err := graphql.ParseQuery(query); err == nil {
+		write = false
+		for _, op := range doc.Operations {
+			if op.Type == graphql.Mutation {
+				write = true
+			}
+		}
+	}
+
+	if atomic.AddInt32(&c.routes.cached, 1) <= maxCachedOperations {
+		c.routes.writes.Store(query, write)
+	}
+
+	return write
+}
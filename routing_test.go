@@ -0,0 +1,74 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithWriteEndpoint(t *testing.T) {
+	var got string
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				got = name
+				w.Write([]byte(`{"data":{}}`))
+			},
+		))
+	}
+
+	read := newServer("read")
+	defer read.Close()
+
+	write := newServer("write")
+	defer write.Close()
+
+	c := NewClient(read.URL, WithWriteEndpoint(write.URL))
+
+	for _, tc := range []struct {
+		name  string
+		ctx   context.Context
+		query string
+		want  string
+	}{
+		{"Query", context.Background(), "query GetUser { user { name } }", "read"},
+		{"Shorthand", context.Background(), "{ user { name } }", "read"},
+		{"Mutation", context.Background(), "mutation { deleteUser(id: 1) }", "write"},
+		{"MixedDocument", context.Background(), "query A { a } mutation B { b }", "write"},
+		{"Invalid", context.Background(), "foo-query", "write"},
+		{"ContextWrite", ContextWithEndpoint(context.Background(), WriteEndpoint), "{ user { name } }", "write"},
+		{"ContextRead", ContextWithEndpoint(context.Background(), ReadEndpoint), "mutation { deleteUser(id: 1) }", "read"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The route is cached after the first request.
+			for n := 0; n < 2; n++ {
+				got = ""
+
+				if err := c.Query(tc.ctx, tc.query, nil, nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if got != tc.want {
+					t.Errorf("endpoint = %q, want %q", got, tc.want)
+				}
+			}
+		})
+	}
+
+	t.Run("Prepared", func(t *testing.T) {
+		op, err := c.Prepare("mutation { deleteUser(id: 1) }")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := op.Query(context.Background(), nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := got, "write"; got != want {
+			t.Errorf("endpoint = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+// Package shopify provides conveniences for using graphqlclient with the
+// Shopify Admin GraphQL API.
+package shopify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Endpoint returns the URL of the Admin GraphQL API of shop, e.g.
+// "example.myshopify.com", in the given API version, e.g. "2024-01".
+func Endpoint(shop, version string) string {
+	return "https://" + shop + "/admin/api/" + version + "/graphql.json"
+}
+
+// NewClient returns a client for the Admin GraphQL API at url, see Endpoint,
+// authenticating with accessToken. The client paces requests with a
+// CostLimiter. opts are applied after the options set up by NewClient;
+// replacing the HTTP client with graphqlclient.WithHTTPClient disables
+// pacing.
+func NewClient(url, accessToken string, opts ...graphqlclient.Option) *graphqlclient.Client {
+	return graphqlclient.NewClient(url, append([]graphqlclient.Option{
+		graphqlclient.WithHTTPClient(&http.Client{
+			Transport: NewCostLimiter(nil),
+		}),
+		graphqlclient.WithRequestOptions(func(req *http.Request) {
+			req.Header.Set("X-Shopify-Access-Token", accessToken)
+		}),
+	}, opts...)...)
+}
+
+// Cost is the cost of a query, as reported by the API in extensions.cost.
+type Cost struct {
+	RequestedQueryCost float64        `json:"requestedQueryCost"`
+	ActualQueryCost    float64        `json:"actualQueryCost"`
+	ThrottleStatus     ThrottleStatus `json:"throttleStatus"`
+}
+
+// ThrottleStatus is the state of the leaky bucket the API limits query costs
+// with: up to MaximumAvailable points may be spent, and spent points are
+// restored at RestoreRate points per second.
+type ThrottleStatus struct {
+	MaximumAvailable   float64 `json:"maximumAvailable"`
+	CurrentlyAvailable float64 `json:"currentlyAvailable"`
+	RestoreRate        float64 `json:"restoreRate"`
+}
+
+// CostLimiter is an http.RoundTripper pacing requests to stay within the
+// query cost budget of the API. It reads the cost the API reports in
+// extensions.cost of each response, and before sending a request, waits
+// until the points estimated to be available cover the requested cost of the
+// previous query.
+type CostLimiter struct {
+	transport http.RoundTripper
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu      sync.Mutex
+	status  ThrottleStatus
+	updated time.Time
+	cost    float64
+}
+
+// NewCostLimiter returns a CostLimiter sending requests using transport, or
+// http.DefaultTransport if nil.
+func NewCostLimiter(transport http.RoundTripper) *CostLimiter {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &CostLimiter{
+		transport: transport,
+		now:       time.Now,
+		sleep:     sleep,
+	}
+}
+
+// Available returns the estimated number of points currently available, and
+// whether the API has reported its throttle status yet.
+func (l *CostLimiter) Available() (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.updated.IsZero() {
+		return 0, false
+	}
+
+	return l.available(l.now()), true
+}
+
+func (l *CostLimiter) available(now time.Time) float64 {
+	restored := now.Sub(l.updated).Seconds() * l.status.RestoreRate
+	return math.Min(l.status.MaximumAvailable, l.status.CurrentlyAvailable+restored)
+}
+
+// Wait blocks until the estimated available points cover the requested cost
+// of the previous query, or ctx is done.
+func (l *CostLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	var d time.Duration
+
+	if !l.updated.IsZero() && l.status.RestoreRate > 0 {
+		if missing := l.cost - l.available(l.now()); missing > 0 {
+			d = time.Duration(missing / l.status.RestoreRate * float64(time.Second))
+		}
+	}
+
+	l.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	return l.sleep(ctx, d)
+}
+
+// RoundTrip implements http.RoundTripper. It waits as Wait does before sending
+// req.
+func (l *CostLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := l.Wait(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := l.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var r struct {
+		Extensions struct {
+			Cost *Cost `json:"cost"`
+		} `json:"extensions"`
+	}
+
+	if json.Unmarshal(body, &r) == nil && r.Extensions.Cost != nil {
+		l.update(r.Extensions.Cost)
+	}
+
+	return resp, nil
+}
+
+func (l *CostLimiter) update(cost *Cost) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.status = cost.ThrottleStatus
+	l.updated = l.now()
+	l.cost = cost.RequestedQueryCost
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
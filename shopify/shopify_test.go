@@ -0,0 +1,96 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+func TestCostLimiter(t *testing.T) {
+	var (
+		gotToken  string
+		available = 1000.0
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotToken = r.Header.Get("X-Shopify-Access-Token")
+
+			fmt.Fprintf(w, `{"data":{"shop":{"name":"foo"}},"extensions":{"cost":{"requestedQueryCost":100,"actualQueryCost":80,"throttleStatus":{"maximumAvailable":1000,"currentlyAvailable":%v,"restoreRate":50}}}}`, available)
+		},
+	))
+	defer ts.Close()
+
+	now := time.Unix(1600000000, 0)
+
+	var sleeps []time.Duration
+
+	l := NewCostLimiter(nil)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	c := NewClient(ts.URL, "foo-token", graphqlclient.WithHTTPClient(&http.Client{Transport: l}))
+
+	if _, ok := l.Available(); ok {
+		t.Error("ok = true, want false")
+	}
+
+	var data struct {
+		Shop struct {
+			Name string `json:"name"`
+		} `json:"shop"`
+	}
+
+	for _, a := range []float64{920, 40, 500} {
+		available = a
+
+		if err := c.Query(context.Background(), "foo-query", nil, &data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := data.Shop.Name, "foo"; got != want {
+		t.Errorf("data.Shop.Name = %q, want %q", got, want)
+	}
+
+	if got, want := gotToken, "foo-token"; got != want {
+		t.Errorf("X-Shopify-Access-Token = %q, want %q", got, want)
+	}
+
+	// Only the third request needs 60 more points than are available,
+	// restored in 1.2 seconds.
+	if got, want := fmt.Sprint(sleeps), "[1.2s]"; got != want {
+		t.Errorf("sleeps = %s, want %s", got, want)
+	}
+
+	now = now.Add(2 * time.Second)
+
+	got, ok := l.Available()
+	if !ok {
+		t.Fatal("ok = false, want true")
+	}
+
+	if want := 600.0; got != want {
+		t.Errorf("l.Available() = %v, want %v", got, want)
+	}
+
+	now = now.Add(time.Minute)
+
+	if got, _ := l.Available(); got != 1000 {
+		t.Errorf("l.Available() = %v, want %v", got, 1000)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	if got, want := Endpoint("foo.myshopify.com", "2024-01"), "https://foo.myshopify.com/admin/api/2024-01/graphql.json"; got != want {
+		t.Errorf("Endpoint = %q, want %q", got, want)
+	}
+}
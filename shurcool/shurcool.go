@@ -0,0 +1,322 @@
+// Package shurcool mirrors the API of github.com/shurcooL/graphql on top of
+// graphqlclient, so that its users can migrate by changing imports:
+//
+//	import graphql "github.com/TV4/graphqlclient-go/shurcool"
+//
+// As with shurcooL/graphql, queries are constructed from the types of Go
+// structs, with fields named by the lower camel case form of their names or
+// by their graphql tags, which may hold arguments, aliases and inline
+// fragments:
+//
+//	var q struct {
+//		User struct {
+//			Name  graphql.String
+//			Admin struct {
+//				Level graphql.Int
+//			} `graphql:"... on Admin"`
+//		} `graphql:"user(id: $id)"`
+//	}
+//
+//	err := client.Query(ctx, &q, map[string]interface{}{"id": graphql.ID("1")})
+//
+// Unlike shurcooL/graphql, the errors of responses are returned as a
+// *graphqlclient.ErrorResponse, holding all errors and the status code.
+package shurcool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+)
+
+// Scalar types, mapping to the GraphQL scalars of the same names when used as
+// variables.
+type (
+	Boolean bool
+	Float   float64
+	ID      string
+	Int     int32
+	String  string
+)
+
+// Client is a client for a GraphQL server.
+type Client struct {
+	client *graphqlclient.Client
+}
+
+// NewClient returns a client for the GraphQL server at url, sending requests
+// using httpClient, or http.DefaultClient if nil.
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		client: graphqlclient.NewClient(url, graphqlclient.WithHTTPClient(httpClient)),
+	}
+}
+
+// Query executes the query constructed from the type of q, a pointer to a
+// struct, with variables, and decodes the data payload into q.
+func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	return c.do(ctx, "query", q, variables)
+}
+
+// Mutate executes the mutation constructed from the type of m, a pointer to
+// a struct, with variables, and decodes the data payload into m.
+func (c *Client) Mutate(ctx context.Context, m interface{}, variables map[string]interface{}) error {
+	return c.do(ctx, "mutation", m, variables)
+}
+
+func (c *Client) do(ctx context.Context, opType string, v interface{}, variables map[string]interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%T is not a non-nil pointer", v)
+	}
+
+	query := constructOperation(opType, rv.Type().Elem(), variables)
+
+	var data json.RawMessage
+	if err := c.client.Query(ctx, query, variables, &data); err != nil {
+		return err
+	}
+
+	if err := decode(data, rv.Elem()); err != nil {
+		return fmt.Errorf("error decoding data payload: %v", err)
+	}
+
+	return nil
+}
+
+// constructOperation returns the operation of type opType selecting the
+// fields of t, with definitions of variables.
+func constructOperation(opType string, t reflect.Type, variables map[string]interface{}) string {
+	var b strings.Builder
+
+	b.WriteString(opType)
+
+	if len(variables) > 0 {
+		names := make([]string, 0, len(variables))
+		for name := range variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteByte('(')
+		for i, name := range names {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString("$" + name + ":" + variableType(reflect.TypeOf(variables[name])))
+		}
+		b.WriteByte(')')
+	}
+
+	writeSelectionSet(&b, t)
+
+	return b.String()
+}
+
+// variableType returns the GraphQL type of variables of Go type t. Pointers
+// are nullable, and other types are not.
+func variableType(t reflect.Type) string {
+	if t == nil {
+		return "String"
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return strings.TrimSuffix(variableType(t.Elem()), "!")
+	case reflect.Slice, reflect.Array:
+		return "[" + variableType(t.Elem()) + "]!"
+	}
+
+	if name := t.Name(); name != "" && t.PkgPath() != "" {
+		return name + "!"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "Boolean!"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int!"
+	case reflect.Float32, reflect.Float64:
+		return "Float!"
+	default:
+		return "String!"
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// isScalar reports whether values of t are decoded as scalars, without a
+// selection set.
+func isScalar(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+// elemType returns t without pointers, slices and arrays.
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func writeSelectionSet(b *strings.Builder, t reflect.Type) {
+	b.WriteByte('{')
+	writeFields(b, t, true)
+	b.WriteByte('}')
+}
+
+func writeFields(b *strings.Builder, t reflect.Type, first bool) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag, hasTag := f.Tag.Lookup("graphql")
+		ft := elemType(f.Type)
+
+		// Embedded structs without tags are flattened.
+		if f.Anonymous && !hasTag && ft.Kind() == reflect.Struct {
+			first = writeFields(b, ft, first)
+			continue
+		}
+
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+
+		if hasTag {
+			b.WriteString(tag)
+		} else {
+			b.WriteString(lowerCamelCase(f.Name))
+		}
+
+		if !isScalar(ft) {
+			writeSelectionSet(b, ft)
+		}
+	}
+
+	return first
+}
+
+// lowerCamelCase returns name with its leading initialism or word in lower
+// case, e.g. "userID" for "UserID" and "htmlBody" for "HTMLBody".
+func lowerCamelCase(name string) string {
+	r := []rune(name)
+
+	for i := range r {
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+
+		// Keep the last capital of an initialism followed by a word.
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+
+		r[i] = unicode.ToLower(r[i])
+	}
+
+	return string(r)
+}
+
+// responseKey returns the key of the field selected by tag in responses.
+func responseKey(tag string) string {
+	if i := strings.IndexAny(tag, "(@{"); i >= 0 {
+		tag = tag[:i]
+	}
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.TrimSpace(tag)
+}
+
+// decode decodes data into v, matching object keys to fields as selected by
+// writeFields.
+func decode(data json.RawMessage, v reflect.Value) error {
+	t := v.Type()
+
+	switch {
+	case string(data) == "null":
+		v.Set(reflect.Zero(t))
+		return nil
+	case t.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decode(data, v.Elem())
+	case t.Kind() == reflect.Slice && !isScalar(elemType(t)):
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := decode(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		v.Set(s)
+		return nil
+	case isScalar(t):
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag, hasTag := f.Tag.Lookup("graphql")
+
+		// Embedded structs and inline fragments select fields of the
+		// enclosing object.
+		if f.Anonymous && !hasTag && elemType(f.Type).Kind() == reflect.Struct || strings.HasPrefix(tag, "...") {
+			if err := decode(data, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := lowerCamelCase(f.Name)
+		if hasTag {
+			key = responseKey(tag)
+		}
+
+		raw, ok := obj[key]
+		if !ok {
+			continue
+		}
+
+		if err := decode(raw, v.Field(i)); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+
+	return nil
+}
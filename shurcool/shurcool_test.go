@@ -0,0 +1,151 @@
+package shurcool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_Query(t *testing.T) {
+	var gotBody struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			w.Write([]byte(`{"data":{"user":{"name":"foo","userID":"1","level":3,"friends":[{"name":"a"},{"name":"b"}],"best":null},"first":{"createdAt":"2020-01-02T03:04:05Z"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	type named struct {
+		Name String
+	}
+
+	var q struct {
+		User struct {
+			named
+			UserID ID
+			Admin  struct {
+				Level Int
+			} `graphql:"... on Admin"`
+			Friends []named `graphql:"friends(first: $first)"`
+			Best    *named
+		} `graphql:"user(id: $id)"`
+		First struct {
+			CreatedAt time.Time
+		} `graphql:"first: event"`
+	}
+
+	err := NewClient(ts.URL, nil).Query(context.Background(), &q, map[string]interface{}{
+		"id":    ID("1"),
+		"first": Int(2),
+		"after": (*String)(nil),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := gotBody.Query, `query($after:String,$first:Int!,$id:ID!){user(id: $id){name,userID,... on Admin{level},friends(first: $first){name},best{name}},first: event{createdAt}}`; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+
+	if got, want := q.User.Name, String("foo"); got != want {
+		t.Errorf("q.User.Name = %q, want %q", got, want)
+	}
+
+	if got, want := q.User.UserID, ID("1"); got != want {
+		t.Errorf("q.User.UserID = %q, want %q", got, want)
+	}
+
+	if got, want := q.User.Admin.Level, Int(3); got != want {
+		t.Errorf("q.User.Admin.Level = %d, want %d", got, want)
+	}
+
+	if got, want := q.User.Friends, []named{{"a"}, {"b"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("q.User.Friends = %v, want %v", got, want)
+	}
+
+	if q.User.Best != nil {
+		t.Errorf("q.User.Best = %v, want nil", q.User.Best)
+	}
+
+	if got, want := q.First.CreatedAt, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("q.First.CreatedAt = %v, want %v", got, want)
+	}
+}
+
+func TestClient_Mutate(t *testing.T) {
+	var gotQuery string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Query string `json:"query"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotQuery = body.Query
+
+			w.Write([]byte(`{"data":{"addStar":{"starCount":10}}}`))
+		},
+	))
+	defer ts.Close()
+
+	type AddStarInput struct {
+		StarrableID ID `json:"starrableId"`
+	}
+
+	var m struct {
+		AddStar struct {
+			StarCount Int
+		} `graphql:"addStar(input: $input)"`
+	}
+
+	err := NewClient(ts.URL, nil).Mutate(context.Background(), &m, map[string]interface{}{
+		"input": AddStarInput{StarrableID: "foo"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := gotQuery, `mutation($input:AddStarInput!){addStar(input: $input){starCount}}`; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+
+	if got, want := m.AddStar.StarCount, Int(10); got != want {
+		t.Errorf("m.AddStar.StarCount = %d, want %d", got, want)
+	}
+
+	t.Run("NotPointer", func(t *testing.T) {
+		if err := NewClient(ts.URL, nil).Mutate(context.Background(), m, nil); err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+}
+
+func TestLowerCamelCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Name":     "name",
+		"ID":       "id",
+		"UserID":   "userID",
+		"HTMLBody": "htmlBody",
+		"a":        "a",
+	} {
+		if got := lowerCamelCase(name); got != want {
+			t.Errorf("lowerCamelCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/TV4/graphqlclient-go/internal/graphql"
+)
+
+// QueryFromFS sends the query in the file at name in fsys, e.g. an embed.FS
+// of .graphql files, as Query does. The file must contain exactly one
+// operation, and may contain fragments.
+func (c *Client) QueryFromFS(ctx context.Context, fsys fs.FS, name string, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("error reading query: %v", err)
+	}
+
+	return c.querySource(ctx, name, string(b), variables, data, reqOpts)
+}
+
+// QueryFromReader sends the query read from r as QueryFromFS does.
+func (c *Client) QueryFromReader(ctx context.Context, r io.Reader, variables map[string]interface{}, data interface{}, reqOpts ...func(*http.Request)) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading query: %v", err)
+	}
+
+	return c.querySource(ctx, "", string(b), variables, data, reqOpts)
+}
+
+// querySource sends the query src read from the source with name, after
+// checking that it contains exactly one operation.
+func (c *Client) querySource(ctx context.Context, name, src string, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	doc, err := graphql.ParseQuerySource(name, src)
+	if err != nil {
+		return fmt.Errorf("error parsing query: %v", err)
+	}
+
+	if n := len(doc.Operations); n != 1 {
+		if name == "" {
+			name = "query"
+		}
+		return fmt.Errorf("%s has %d operations, want 1", name, n)
+	}
+
+	return c.Query(ctx, src, variables, data, reqOpts...)
+}
@@ -0,0 +1,106 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestClient_QueryFromFS(t *testing.T) {
+	var gotQuery string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Query string `json:"query"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotQuery = body.Query
+
+			w.Write([]byte(`{"data":{"user":{"name":"foo"}}}`))
+		},
+	))
+	defer ts.Close()
+
+	getUser := "query GetUser($id: ID!) {\n  user(id: $id) { ...UserFields }\n}\n\nfragment UserFields on User { name }\n"
+
+	fsys := fstest.MapFS{
+		"queries/get_user.graphql": {Data: []byte(getUser)},
+		"queries/two.graphql":      {Data: []byte("query A { a } query B { b }")},
+		"queries/fragment.graphql": {Data: []byte("fragment F on User { name }")},
+		"queries/invalid.graphql":  {Data: []byte("query {\n  user(")},
+	}
+
+	c := NewClient(ts.URL)
+
+	for _, tc := range []struct {
+		name    string
+		wantErr string
+	}{
+		{"queries/get_user.graphql", ""},
+		{"queries/two.graphql", "queries/two.graphql has 2 operations, want 1"},
+		{"queries/fragment.graphql", "queries/fragment.graphql has 0 operations, want 1"},
+		{"queries/invalid.graphql", "error parsing query: queries/invalid.graphql:2:8: "},
+		{"queries/missing.graphql", "error reading query: open queries/missing.graphql: file does not exist"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotQuery = ""
+
+			var data struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+			}
+
+			err := c.QueryFromFS(context.Background(), fsys, tc.name, map[string]interface{}{"id": "1"}, &data)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Errorf("err = %v, want %q", err, tc.wantErr)
+				}
+
+				if gotQuery != "" {
+					t.Errorf("query sent: %q", gotQuery)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := gotQuery, getUser; got != want {
+				t.Errorf("query = %q, want %q", got, want)
+			}
+
+			if got, want := data.User.Name, "foo"; got != want {
+				t.Errorf("data.User.Name = %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("Reader", func(t *testing.T) {
+		if err := c.QueryFromReader(context.Background(), strings.NewReader(getUser), nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotQuery, getUser; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+
+		err := c.QueryFromReader(context.Background(), strings.NewReader("{ a } { b }"), nil, nil)
+
+		if got, want := fmt.Sprint(err), "query has 2 operations, want 1"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package graphqlclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SSETransport is a SubscriptionTransport speaking the graphql-sse protocol
+// in its distinct connections mode, for servers exposing subscriptions over
+// Server-Sent Events, see
+// https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md. Each
+// subscription is a POST request whose response streams its events, so it
+// works where only plain HTTP is allowed.
+type SSETransport struct {
+	// URL is the URL of the server. Defaults to the client's URL.
+	URL string
+
+	// HTTPClient sends the requests. Defaults to the HTTP client of the
+	// client, with its request options applied to the requests.
+	HTTPClient *http.Client
+}
+
+// Subscribe implements SubscriptionTransport.
+func (t *SSETransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	url := t.URL
+	if url == "" {
+		url = sub.URL
+	}
+
+	httpClient := sub.httpClient(t.HTTPClient)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     sub.Query,
+		"variables": sub.Variables,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding subscription: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req = req.WithContext(ctx)
+
+	sub.applyRequestOptions(req)
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("error performing request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if resp.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+		// Servers reject invalid operations with a regular GraphQL
+		// response.
+		var result struct {
+			Errors []Error `json:"errors"`
+		}
+
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err == nil && len(result.Errors) > 0 {
+			return &SubscriptionError{Errors: result.Errors}
+		}
+
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	err = readSSE(resp.Body, func(event string, data []byte) (bool, error) {
+		switch event {
+		case "next":
+			var result struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []Error         `json:"errors"`
+			}
+
+			if err := json.Unmarshal(data, &result); err != nil {
+				return false, fmt.Errorf("error decoding event: %v", err)
+			}
+
+			return true, handler(result.Data, result.Errors)
+		case "complete":
+			return false, nil
+		}
+
+		return true, nil
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// errSSEClosed is returned by readSSE when the stream ends before fn stops
+// reading.
+var errSSEClosed = errors.New("event stream closed before completing")
+
+// readSSE reads Server-Sent Events from r, calling fn with the type and data
+// of each event until fn returns false or an error.
+func readSSE(r io.Reader, fn func(event string, data []byte) (bool, error)) error {
+	br := bufio.NewReader(r)
+
+	var (
+		event string
+		data  bytes.Buffer
+	)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return errSSEClosed
+			}
+			return fmt.Errorf("error reading event stream: %v", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if event == "" && data.Len() == 0 {
+				continue
+			}
+
+			more, err := fn(event, data.Bytes())
+			if err != nil || !more {
+				return err
+			}
+
+			event = ""
+			data.Reset()
+
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
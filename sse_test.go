@@ -0,0 +1,161 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSETransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if got, want := r.Header.Get("Accept"), "text/event-stream"; got != want {
+				t.Errorf("Accept = %q, want %q", got, want)
+			}
+
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+
+			switch {
+			case strings.Contains(body.Query, "OnInvalid"):
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":[{"message":"invalid"}]}`))
+				return
+			case strings.Contains(body.Query, "OnDenied"):
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/event-stream")
+
+			fmt.Fprintf(w, ": comment\n\nevent: next\ndata: {\"data\":{\"id\":%q}}\n\n", fmt.Sprint(body.Variables["id"]))
+			fmt.Fprint(w, "event: next\r\ndata: {\"data\":null,\r\ndata: \"errors\":[{\"message\":\"partial\"}]}\r\n\r\n")
+			w.(http.Flusher).Flush()
+
+			switch {
+			case strings.Contains(body.Query, "OnEndless"):
+				<-r.Context().Done()
+			case strings.Contains(body.Query, "OnClosed"):
+			default:
+				fmt.Fprint(w, "event: complete\ndata:\n\n")
+			}
+		},
+	))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithSubscriptionTransport(&SSETransport{}))
+
+	t.Run("Events", func(t *testing.T) {
+		var events []string
+
+		err := c.Subscribe(context.Background(), "subscription OnFoo($id: ID!) { foo(id: $id) }", map[string]interface{}{"id": "123"},
+			func(data json.RawMessage, errs []Error) error {
+				events = append(events, fmt.Sprintf("%s %v", data, errs))
+				return nil
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(events, ","), `{"id":"123"} [],null [{partial [] [] map[]}]`; got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription OnInvalid { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		var subErr *SubscriptionError
+		if !errors.As(err, &subErr) {
+			t.Fatalf("err = %v, want %T", err, subErr)
+		}
+
+		if got, want := subErr.Error(), "subscription error: invalid"; got != want {
+			t.Errorf("subErr.Error() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HTTPError", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription OnDenied { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := fmt.Sprint(err), "unexpected response: 403 Forbidden"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HandlerError", func(t *testing.T) {
+		errStop := errors.New("stop")
+
+		err := c.Subscribe(context.Background(), "subscription OnEndless { foo }", nil,
+			func(json.RawMessage, []Error) error { return errStop },
+		)
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := c.Subscribe(ctx, "subscription OnEndless { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := err, context.DeadlineExceeded; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription OnClosed { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+
+		if got, want := err, errSSEClosed; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		var gotHeader http.Header
+
+		c := NewClient(ts.URL,
+			WithSubscriptionTransport(&SSETransport{}),
+			WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				gotHeader = r.Header
+				return http.DefaultTransport.RoundTrip(r)
+			})}),
+			WithRequestOptions(func(r *http.Request) {
+				r.Header.Set("X-Client", "1")
+			}),
+		)
+
+		err := c.Subscribe(context.Background(), "subscription OnFoo { foo }", nil,
+			func(json.RawMessage, []Error) error { return nil },
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := gotHeader.Get("X-Client"), "1"; got != want {
+			t.Errorf("X-Client = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package graphqlclient
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds cumulative counters of the requests sent by a client, e.g. to
+// be exposed by an admin endpoint. Requests that failed before being sent,
+// e.g. because their variables couldn't be encoded, are not counted.
+type Stats struct {
+	// Requests is the number of requests sent.
+	Requests int64
+
+	// TransportErrors is the number of requests that got no response,
+	// e.g. because the connection failed or the context was done.
+	TransportErrors int64
+
+	// HTTPErrors is the number of responses with a non-2xx status code.
+	HTTPErrors int64
+
+	// GraphQLErrors is the number of 2xx responses with errors.
+	GraphQLErrors int64
+
+	// DecodeErrors is the number of 2xx responses that couldn't be
+	// decoded, including those exceeding DecodeLimits.
+	DecodeErrors int64
+
+	// Retries is the number of requests the client sent again itself:
+	// queries sent with their whole document after the server didn't know
+	// their automatic persisted query, see WithAutomaticPersistedQueries.
+	// Retried requests are counted in Requests too. Queries sent again by
+	// callers, e.g. by a Paginator with a Throttle, are not retries.
+	Retries int64
+
+	// PersistedQueryHits is the number of automatic persisted queries sent
+	// by their hash only that the server knew. The client caches nothing
+	// else: responses are never cached, so there is no other cache to hit.
+	PersistedQueryHits int64
+
+	// BytesSent and BytesReceived are the numbers of bytes of request and
+	// response bodies sent and read, as compressed if compressed.
+	BytesSent     int64
+	BytesReceived int64
+
+	// TotalLatency is the sum of the durations of the requests, from
+	// sending them to having decoded their responses.
+	TotalLatency time.Duration
+}
+
+// Errors returns the number of requests that failed.
+func (s Stats) Errors() int64 {
+	return s.TransportErrors + s.HTTPErrors + s.GraphQLErrors + s.DecodeErrors
+}
+
+// AverageLatency returns the average duration of the requests, or 0 if no
+// requests have been sent.
+func (s Stats) AverageLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+// Stats returns the counters of the requests sent by the client since it
+// was created, or since ResetStats was last called.
+func (c *Client) Stats() Stats {
+	return c.stats.snapshot(false)
+}
+
+// ResetStats resets the counters of the client, returning their values
+// before the reset.
+func (c *Client) ResetStats() Stats {
+	return c.stats.snapshot(true)
+}
+
+// clientStats holds the counters of Stats.
+type clientStats struct {
+	requests        int64
+	transportErrors int64
+	httpErrors      int64
+	graphQLErrors   int64
+	decodeErrors    int64
+	retries         int64
+	apqHits         int64
+	bytesSent       int64
+	bytesReceived   int64
+	totalLatency    int64
+}
+
+// record counts a request that took d and sent and received the given
+// numbers of bytes. It got a response if gotResp is true, and failed with err
+// if not nil.
+func (s *clientStats) record(d time.Duration, sent, received int64, gotResp bool, err error) {
+	atomic.AddInt64(&s.requests, 1)
+	atomic.AddInt64(&s.bytesSent, sent)
+	atomic.AddInt64(&s.bytesReceived, received)
+	atomic.AddInt64(&s.totalLatency, int64(d))
+
+	if err == nil {
+		return
+	}
+
+	var errResp *ErrorResponse
+
+	switch {
+	case !gotResp:
+		atomic.AddInt64(&s.transportErrors, 1)
+	case errors.As(err, &errResp) && errResp.StatusCode/100 != 2:
+		atomic.AddInt64(&s.httpErrors, 1)
+	case errResp != nil:
+		atomic.AddInt64(&s.graphQLErrors, 1)
+	default:
+		atomic.AddInt64(&s.decodeErrors, 1)
+	}
+}
+
+// snapshot returns the counters, resetting them if reset is true. Counters
+// reset while requests are recorded may be inconsistent with each other.
+func (s *clientStats) snapshot(reset bool) Stats {
+	load := atomic.LoadInt64
+	if reset {
+		load = func(addr *int64) int64 { return atomic.SwapInt64(addr, 0) }
+	}
+
+	return Stats{
+		Requests:           load(&s.requests),
+		TransportErrors:    load(&s.transportErrors),
+		HTTPErrors:         load(&s.httpErrors),
+		GraphQLErrors:      load(&s.graphQLErrors),
+		DecodeErrors:       load(&s.decodeErrors),
+		Retries:            load(&s.retries),
+		PersistedQueryHits: load(&s.apqHits),
+		BytesSent:          load(&s.bytesSent),
+		BytesReceived:      load(&s.bytesReceived),
+		TotalLatency:       time.Duration(load(&s.totalLatency)),
+	}
+}
+
+// countingReader counts the bytes read from r in n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+// countingReadCloser counts the bytes read from its ReadCloser in n.
+type countingReadCloser struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,91 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Stats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("status") {
+			case "500":
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"errors":[{"message":"oops"}]}`))
+			case "errors":
+				w.Write([]byte(`{"errors":[{"message":"invalid"}]}`))
+			case "invalid":
+				w.Write([]byte(`{"data":`))
+			default:
+				w.Write([]byte(`{"data":{}}`))
+			}
+		},
+	))
+	defer ts.Close()
+
+	query := func(c *Client, status string) {
+		c.Query(context.Background(), "{ foo }", nil, nil, func(req *http.Request) {
+			req.URL.RawQuery = "status=" + status
+		})
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Buffered", nil},
+		{"Streaming", []Option{WithStreamingRequests()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(ts.URL, tc.opts...)
+
+			for _, status := range []string{"200", "200", "500", "errors", "invalid"} {
+				query(c, status)
+			}
+
+			unreachable := NewClient("http://127.0.0.1:0", tc.opts...)
+			query(unreachable, "200")
+
+			stats := c.Stats()
+
+			body := int64(len(`{"query":"{ foo }","variables":null}`))
+			if tc.name == "Streaming" {
+				// Streamed variables are followed by a newline.
+				body++
+			}
+
+			for _, f := range []struct {
+				name      string
+				got, want int64
+			}{
+				{"Requests", stats.Requests, 5},
+				{"TransportErrors", stats.TransportErrors, 0},
+				{"HTTPErrors", stats.HTTPErrors, 1},
+				{"GraphQLErrors", stats.GraphQLErrors, 1},
+				{"DecodeErrors", stats.DecodeErrors, 1},
+				{"Errors()", stats.Errors(), 3},
+				{"BytesSent", stats.BytesSent, 5 * body},
+				{"BytesReceived", stats.BytesReceived, 2*11 + 31 + 34 + 8},
+				{"unreachable TransportErrors", unreachable.Stats().TransportErrors, 1},
+			} {
+				if f.got != f.want {
+					t.Errorf("%s = %d, want %d", f.name, f.got, f.want)
+				}
+			}
+
+			if stats.AverageLatency() <= 0 || stats.AverageLatency() > stats.TotalLatency {
+				t.Errorf("stats.AverageLatency() = %v, want in (0, %v]", stats.AverageLatency(), stats.TotalLatency)
+			}
+
+			if got, want := c.ResetStats(), stats; got != want {
+				t.Errorf("c.ResetStats() = %+v, want %+v", got, want)
+			}
+
+			if got, want := c.Stats(), (Stats{}); got != want {
+				t.Errorf("c.Stats() after reset = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// SubscriptionHandler is called with the result of each event of a
+// subscription: its data payload, which may be null, and its errors. If the
+// handler returns an error, the subscription is stopped and Subscribe returns
+// the error.
+type SubscriptionHandler func(data json.RawMessage, errs []Error) error
+
+// Subscription is a subscription operation, as passed to a
+// SubscriptionTransport.
+type Subscription struct {
+	Query     string
+	Variables map[string]interface{}
+
+	// URL is the URL of the client.
+	URL string
+
+	// Header holds the headers set by the client's request options, to be
+	// sent when connecting to the server.
+	Header http.Header
+
+	// HTTPClient is the HTTP client of the client, and RequestOptions the
+	// options setting Header, which transports sending subscriptions as
+	// HTTP requests use by default. DecodeLimits, if set, are the limits
+	// of the client on the responses to such requests, see
+	// WithDecodeLimits.
+	HTTPClient     *http.Client
+	RequestOptions []func(*http.Request)
+	DecodeLimits   *DecodeLimits
+
+	// ConnectionParams, if set, returns the payload of the connection_init
+	// message sent when establishing a connection, see
+	// WithConnectionParams.
+	ConnectionParams ConnectionParamsFunc
+}
+
+// ConnectionParamsFunc returns the payload of the connection_init message of
+// a subscription connection, e.g. holding an auth token.
+type ConnectionParamsFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// WithConnectionParams makes WebSocket subscription transports send the
+// payload returned by fn in the connection_init message, for servers
+// authenticating connections by it rather than by headers. fn is called
+// with the context of the subscription establishing each connection, so
+// that reconnections get fresh params.
+func WithConnectionParams(fn ConnectionParamsFunc) Option {
+	return func(c *Client) {
+		c.connParams = fn
+	}
+}
+
+// SubscriptionTransport carries subscriptions to a server. Subscribe blocks
+// until the subscription is completed by the server, fails, ctx is done or
+// handler returns an error. A subscription completed by the server returns
+// nil, and one terminated by the server with errors returns a
+// *SubscriptionError.
+type SubscriptionTransport interface {
+	Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error
+}
+
+// SubscriptionError is returned by Subscribe when the server terminates a
+// subscription with errors, e.g. because the operation is invalid.
+type SubscriptionError struct {
+	Errors []Error
+}
+
+// Error returns a string representation of the error.
+func (e *SubscriptionError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Message
+	}
+	return "subscription error: " + strings.Join(msgs, "; ")
+}
+
+// WithSubscriptionTransport makes the client send subscriptions using t
+// instead of a WebSocketTransport, e.g. an SSETransport where WebSockets are
+// blocked.
+func WithSubscriptionTransport(t SubscriptionTransport) Option {
+	return func(c *Client) {
+		c.subscriptionTransport = t
+	}
+}
+
+// Subscribe sends the subscription query with variables to the server, over
+// WebSocket using the graphql-transport-ws protocol unless the client was
+// created with WithSubscriptionTransport, and calls handler with the result
+// of each event, until the subscription is completed by the server, fails,
+// ctx is done or handler returns an error; see SubscriptionTransport. The
+// client's request options are applied to a request for the client's URL,
+// and the headers they set are sent when connecting to the server. See
+// WithSubscriptionReconnect for reconnecting lost subscriptions, and
+// WithSubscriptionBuffer for handlers slower than the events and
+// ContextWithEventFilter for dropping events.
+func (c *Client) Subscribe(ctx context.Context, query string, variables map[string]interface{}, handler SubscriptionHandler) (err error) {
+	ctx, done, err := c.lifecycle.beginSubscription(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	// Subscriptions ended by Close return ErrClientClosed.
+	defer func() {
+		if err != nil && context.Cause(ctx) == ErrClientClosed {
+			err = ErrClientClosed
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req = req.WithContext(ctx)
+
+	var reqOpts []func(*http.Request)
+
+	if userAgent := c.userAgent; userAgent != "" {
+		reqOpts = append(reqOpts, func(req *http.Request) {
+			req.Header.Set("User-Agent", userAgent)
+		})
+	}
+
+	reqOpts = append(reqOpts, c.reqOpts...)
+	reqOpts = append(reqOpts, c.applyContextRequestOptions)
+
+	for _, o := range reqOpts {
+		o(req)
+	}
+
+	t := c.subscriptionTransport
+	if c.subscriptionFallback != nil {
+		t = &fallbackTransport{primary: t, fallback: c.subscriptionFallback}
+	}
+
+	sub := &Subscription{
+		Query:     query,
+		Variables: variables,
+		URL:       c.url,
+		Header:    req.Header,
+
+		HTTPClient:     c.httpClient,
+		RequestOptions: reqOpts,
+		DecodeLimits:   c.decodeLimits,
+
+		ConnectionParams: c.connParams,
+	}
+
+	filter := eventFilter(ctx)
+
+	monitor := subscriptionMonitor(ctx)
+	monitor.started()
+
+	send := func(ctx context.Context, handler SubscriptionHandler) error {
+		if filter != nil {
+			next := handler
+			handler = func(data json.RawMessage, errs []Error) error {
+				if !filter(data, errs) {
+					return nil
+				}
+				return next(data, errs)
+			}
+		}
+
+		if monitor != nil {
+			next := handler
+			handler = func(data json.RawMessage, errs []Error) error {
+				monitor.event()
+				return next(data, errs)
+			}
+		}
+
+		if c.reconnect != nil {
+			return c.reconnect.subscribe(ctx, t, sub, handler)
+		}
+		return t.Subscribe(ctx, sub, handler)
+	}
+
+	if c.eventBuffer != nil {
+		return c.eventBuffer.subscribe(ctx, send, handler)
+	}
+
+	return send(ctx, handler)
+}
+
+// SubscribeTyped is like Subscribe, with the data of each event decoded into
+// a T and sent on the returned channel, which is closed when the
+// subscription ends. The error that ended it, if any, can then be received
+// from the returned error channel. An event with errors ends the
+// subscription with a *SubscriptionError; use Subscribe to handle partial
+// results. Call stop to end the subscription early, without an error.
+//
+//	events, errc, stop := graphqlclient.SubscribeTyped[Message](ctx, c, query, nil)
+//	defer stop()
+//	for msg := range events {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		...
+//	}
+func SubscribeTyped[T any](ctx context.Context, c *Client, query string, variables map[string]interface{}) (events <-chan T, errc <-chan error, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		once    sync.Once
+		stopped = make(chan struct{})
+	)
+
+	stop = func() {
+		once.Do(func() {
+			close(stopped)
+			cancel()
+		})
+	}
+
+	eventc := make(chan T)
+	errch := make(chan error, 1)
+
+	go func() {
+		defer close(errch)
+		defer close(eventc)
+		defer cancel()
+
+		err := c.Subscribe(ctx, query, variables, func(data json.RawMessage, errs []Error) error {
+			if len(errs) > 0 {
+				return &SubscriptionError{Errors: errs}
+			}
+
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				subscriptionMonitor(ctx).decodeFailure()
+				return fmt.Errorf("error decoding event: %v", err)
+			}
+
+			select {
+			case eventc <- v:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		select {
+		case <-stopped:
+		default:
+			if err != nil {
+				errch <- err
+			}
+		}
+	}()
+
+	return eventc, errch, stop
+}
+
+// httpClient returns the HTTP client to send the requests of sub with if
+// httpClient is nil: that of the client, or http.DefaultClient.
+func (sub *Subscription) httpClient(httpClient *http.Client) *http.Client {
+	switch {
+	case httpClient != nil:
+		return httpClient
+	case sub.HTTPClient != nil:
+		return sub.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// applyRequestOptions applies the request options of sub to req, or sets
+// its headers if it has none.
+func (sub *Subscription) applyRequestOptions(req *http.Request) {
+	if len(sub.RequestOptions) == 0 {
+		for k, v := range sub.Header {
+			req.Header[k] = v
+		}
+		return
+	}
+
+	for _, o := range sub.RequestOptions {
+		o(req)
+	}
+}
@@ -0,0 +1,100 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSubscribeTyped(t *testing.T) {
+	type event struct {
+		Foo int `json:"foo"`
+	}
+
+	subscribe := func(ctx context.Context, conn scriptedConnection) (<-chan event, <-chan error, func()) {
+		c := NewClient("http://example.com/graphql",
+			WithSubscriptionTransport(&scriptedTransport{connections: []scriptedConnection{conn}}),
+		)
+
+		return SubscribeTyped[event](ctx, c, "subscription { foo }", nil)
+	}
+
+	collect := func(events <-chan event, errc <-chan error) (string, error) {
+		var got []int
+		for e := range events {
+			got = append(got, e.Foo)
+		}
+		return fmt.Sprint(got), <-errc
+	}
+
+	t.Run("Events", func(t *testing.T) {
+		events, errc, stop := subscribe(context.Background(), scriptedConnection{
+			events: []string{`{"foo":1}`, `{"foo":2}`},
+		})
+		defer stop()
+
+		got, err := collect(events, errc)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := "[1 2]"; got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("DecodeError", func(t *testing.T) {
+		events, errc, stop := subscribe(context.Background(), scriptedConnection{
+			events: []string{`{"foo":1}`, `{"foo":"bar"}`, `{"foo":3}`},
+		})
+		defer stop()
+
+		got, err := collect(events, errc)
+
+		if want := "[1]"; got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+
+		if err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		events, errc, stop := subscribe(context.Background(), scriptedConnection{
+			events: []string{`{"foo":1}`, `{"foo":2}`},
+		})
+
+		if got, want := (<-events).Foo, 1; got != want {
+			t.Errorf("Foo = %d, want %d", got, want)
+		}
+
+		stop()
+
+		for range events {
+		}
+
+		if err := <-errc; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, errc, stop := subscribe(ctx, scriptedConnection{
+			events: []string{`{"foo":1}`, `{"foo":2}`},
+		})
+		defer stop()
+
+		<-events
+		cancel()
+
+		for range events {
+		}
+
+		if got, want := <-errc, context.Canceled; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package graphqlclient
+
+import (
+	"context"
+	"strings"
+)
+
+// WithOperationTag makes the client tag all requests with tag, e.g. the team,
+// feature or ticket a query belongs to, so that server-side logs and APM
+// tools can attribute queries to their owners. The tag is written as a
+// GraphQL comment before the query, which servers ignore, or sent in a
+// header if set with WithOperationTagHeader. Queries sent as trusted
+// documents are only tagged by header. See ContextWithOperationTag for tags
+// of single requests.
+func WithOperationTag(tag string) Option {
+	return func(c *Client) {
+		c.tag = sanitizeTag(tag)
+	}
+}
+
+// WithOperationTagHeader makes the client send the tags of requests in the
+// header with name instead of in a comment before the query.
+func WithOperationTagHeader(name string) Option {
+	return func(c *Client) {
+		c.tagHeader = name
+	}
+}
+
+type operationTagKey struct{}
+
+// ContextWithOperationTag returns a copy of ctx carrying tag, with which
+// clients tag requests made with the context instead of the tag of
+// WithOperationTag.
+func ContextWithOperationTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, operationTagKey{}, sanitizeTag(tag))
+}
+
+// operationTag returns the tag of a request made with ctx.
+func (c *Client) operationTag(ctx context.Context) string {
+	if tag, ok := ctx.Value(operationTagKey{}).(string); ok {
+		return tag
+	}
+	return c.tag
+}
+
+// sanitizeTag returns tag on a single line, as it must be to be written in a
+// comment or header.
+func sanitizeTag(tag string) string {
+	return strings.Join(strings.Fields(tag), " ")
+}
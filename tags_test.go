@@ -0,0 +1,118 @@
+package graphqlclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithOperationTag(t *testing.T) {
+	var (
+		gotBody   string
+		gotHeader string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			gotBody = string(b)
+			gotHeader = r.Header.Get("X-Operation-Tag")
+
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	docs, err := NewTrustedDocuments(map[string]string{"a1": "{ bar }"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := ContextWithOperationTag(context.Background(), "team-b\nTICKET-2")
+
+	for _, tc := range []struct {
+		name       string
+		opts       []Option
+		ctx        context.Context
+		query      string
+		prepared   bool
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name:     "None",
+			ctx:      context.Background(),
+			query:    "{ foo }",
+			wantBody: `{"query":"{ foo }","variables":null}`,
+		},
+		{
+			name:     "Comment",
+			opts:     []Option{WithOperationTag("team-a")},
+			ctx:      context.Background(),
+			query:    "{ foo }",
+			wantBody: `{"query":"# team-a\n{ foo }","variables":null}`,
+		},
+		{
+			name:     "Context",
+			opts:     []Option{WithOperationTag("team-a")},
+			ctx:      ctx,
+			query:    "{ foo }",
+			wantBody: `{"query":"# team-b TICKET-2\n{ foo }","variables":null}`,
+		},
+		{
+			name:     "Prepared",
+			opts:     []Option{WithOperationTag("team-a")},
+			ctx:      context.Background(),
+			query:    "{ foo }",
+			prepared: true,
+			wantBody: `{"query":"# team-a\n{foo}","variables":null}`,
+		},
+		{
+			name:       "Header",
+			opts:       []Option{WithOperationTag("team-a"), WithOperationTagHeader("X-Operation-Tag")},
+			ctx:        context.Background(),
+			query:      "{ foo }",
+			wantBody:   `{"query":"{ foo }","variables":null}`,
+			wantHeader: "team-a",
+		},
+		{
+			name:     "TrustedDocument",
+			opts:     []Option{WithOperationTag("team-a"), WithTrustedDocuments(docs)},
+			ctx:      context.Background(),
+			query:    "{ bar }",
+			wantBody: `{"documentId":"a1","variables":null}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(ts.URL, tc.opts...)
+
+			if tc.prepared {
+				op, err := c.Prepare(tc.query)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				err = op.Query(tc.ctx, nil, nil)
+			} else {
+				err = c.Query(tc.ctx, tc.query, nil, nil)
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := gotBody, tc.wantBody; got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+
+			if got, want := gotHeader, tc.wantHeader; got != want {
+				t.Errorf("X-Operation-Tag = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Transport sends GraphQL requests and returns their responses, e.g. to
+// execute them in-process in tests or through a queue. Clients created with
+// WithTransport send queries with it instead of over HTTP.
+type Transport interface {
+	// RoundTrip sends req and returns its response. The errors of the
+	// operation are returned in the response; an error is only returned
+	// if the request as a whole failed.
+	RoundTrip(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Request is a GraphQL request sent by a Transport.
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	Extensions    map[string]interface{}
+
+	// Header holds the headers set by request options, which transports
+	// not sending requests over HTTP may ignore.
+	Header http.Header
+}
+
+// Response is the response to a GraphQL request returned by a Transport.
+type Response struct {
+	// Data is the undecoded data payload, nil if there is none.
+	Data json.RawMessage
+
+	Errors     []Error
+	Extensions json.RawMessage
+
+	// StatusCode and Header are those of the HTTP response, if any.
+	StatusCode int
+	Header     http.Header
+}
+
+// WithTransport makes the client send queries with t instead of over HTTP.
+// Queries are handed to t as passed to Query, with the headers set by the
+// client's request options, see WithRequestOptions, and the extensions of
+// the client and context, see WithExtensions: they are not sent as trusted
+// documents, automatic persisted queries or in batches. Mutations are sent
+// with t too; subscriptions are still sent with the SubscriptionTransport.
+// To wrap the HTTP transport of the client instead, see WithTransportWrapper.
+func WithTransport(t Transport) Option {
+	return func(c *Client) {
+		c.transport = t
+	}
+}
+
+// WithTransportWrapper makes the client send queries with the Transport
+// returned by wrap for next, the transport the client would send them with
+// otherwise, e.g. to log or retry requests: the one set by WithTransport, or
+// the HTTP transport of the client, see Client.HTTPTransport, which keeps
+// sending them as the client does. The first wrapper of a client wraps the
+// transports of the others.
+func WithTransportWrapper(wrap func(next Transport) Transport) Option {
+	return func(c *Client) {
+		c.transportWrappers = append(c.transportWrappers, wrap)
+	}
+}
+
+// buildTransport returns the transport of the client wrapped by its
+// transport wrappers, nil if queries are sent over HTTP as is.
+func (c *Client) buildTransport() Transport {
+	if len(c.transportWrappers) == 0 {
+		return c.transport
+	}
+
+	t := c.transport
+	if t == nil {
+		t = c.HTTPTransport()
+	}
+
+	for i := len(c.transportWrappers) - 1; i >= 0; i-- {
+		t = c.transportWrappers[i](t)
+	}
+
+	return t
+}
+
+// HTTPTransport returns the Transport sending requests over HTTP the way c
+// does without WithTransport and WithTransportWrapper, e.g. for transports
+// wrapping it: as trusted documents, automatic persisted queries or in
+// batches, with the stats, decode limits and response codecs of c. The data
+// payloads of responses decoded by codecs are returned encoded as JSON.
+func (c *Client) HTTPTransport() Transport {
+	return httpTransport{c}
+}
+
+// httpTransport is a Transport sending requests over HTTP with a client.
+type httpTransport struct {
+	c *Client
+}
+
+// RoundTrip implements Transport.
+func (t httpTransport) RoundTrip(ctx context.Context, req *Request) (*Response, error) {
+	return t.c.do(ctx, req, t.c.dispatchHTTP)
+}
+
+// Do sends req and returns its response, for callers needing the whole
+// exchange rather than the decoded data payload. The request is sent as by
+// Query, with the client's configuration, and failures are reported as by
+// Query, except for the errors of the operation: these are returned in the
+// response, along with its data payload, if any, whatever the status code.
+// The headers in the Header of req are set after the client's request
+// options are applied. Query instead decodes the data payload as the
+// response is read.
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	return c.do(ctx, req, c.query)
+}
+
+// do sends req with send, returning its response as Do does.
+func (c *Client) do(ctx context.Context, req *Request, send func(context.Context, operation, map[string]interface{}, interface{}, []func(*http.Request)) error) (*Response, error) {
+	op := operation{query: req.Query}
+	if c.documents != nil {
+		op = c.documents.operation(req.Query)
+	}
+
+	if len(req.Extensions) > 0 {
+		ctx = ContextWithExtensions(ctx, req.Extensions)
+	}
+
+	if req.OperationName != "" {
+		ctx = ContextWithOperationName(ctx, req.OperationName)
+	}
+
+	meta := &ResponseMetadata{}
+
+	var data json.RawMessage
+
+	err := send(contextWithResponseMetadata(ctx, meta), op, req.Variables, &data, c.headerOptions(ctx, req.Header))
+
+	resp := &Response{
+		Data:       data,
+		Extensions: meta.Extensions,
+		StatusCode: meta.StatusCode,
+		Header:     meta.Header,
+	}
+
+	var errResp *ErrorResponse
+
+	switch {
+	case err == nil:
+		return resp, nil
+	case errors.As(err, &errResp) && len(errResp.Errors) > 0:
+		resp.Errors = errResp.Errors
+		return resp, nil
+	}
+
+	return nil, err
+}
+
+// headerOptions returns the request options setting the headers in header
+// that differ from those set by the client for requests made with ctx, see
+// requestHeader, so that the requests of queries sent with a transport
+// wrapping the HTTP transport of the client are batched as theirs would be.
+func (c *Client) headerOptions(ctx context.Context, header http.Header) []func(*http.Request) {
+	if len(header) == 0 {
+		return nil
+	}
+
+	set := c.requestHeader(ctx, nil)
+
+	changed := make(http.Header)
+
+	for k, v := range header {
+		// The timeout header is set again anyway.
+		if k == http.CanonicalHeaderKey(c.timeoutHeader) || equalValues(set[k], v) {
+			continue
+		}
+		changed[k] = v
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	return []func(*http.Request){func(r *http.Request) {
+		for k, v := range changed {
+			r.Header[k] = v
+		}
+	}}
+}
+
+// equalValues reports whether a and b are the same header values.
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// queryTransport sends op with variables with the client's transport,
+// decoding the data payload of the response into data.
+func (c *Client) queryTransport(ctx context.Context, op operation, variables map[string]interface{}, data interface{}, reqOpts []func(*http.Request)) error {
+	if err := c.lifecycle.begin(); err != nil {
+		return err
+	}
+	defer c.lifecycle.end()
+
+	if c.omitNullVariables {
+		variables = withoutNullVariables(variables)
+	}
+
+	resp, err := c.wrappedTransport.RoundTrip(ctx, &Request{
+		Query:         op.query,
+		OperationName: operationName(ctx),
+		Variables:     variables,
+		Extensions:    c.requestExtensions(ctx),
+		Header:        c.requestHeader(ctx, reqOpts),
+	})
+	if err != nil {
+		return err
+	}
+
+	if meta := responseMetadata(ctx); meta != nil {
+		meta.StatusCode = resp.StatusCode
+		meta.Header = resp.Header
+		meta.Extensions = resp.Extensions
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	if statusCode/100 != 2 || len(resp.Errors) > 0 {
+		// Undecoded data payloads are kept even with errors.
+		if raw, ok := data.(*json.RawMessage); ok {
+			*raw = resp.Data
+		}
+
+		return &ErrorResponse{
+			StatusCode: statusCode,
+			Errors:     resp.Errors,
+			hasData:    resp.Data != nil,
+		}
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	if resp.Data == nil {
+		return fmt.Errorf("error decoding data payload: %v", errNoData)
+	}
+
+	if err := json.Unmarshal(resp.Data, data); err != nil {
+		return fmt.Errorf("error decoding data payload: %v", err)
+	}
+
+	return nil
+}
+
+// requestHeader returns the headers set by the client's request options, the
+// options derived from ctx and reqOpts, and the timeout header.
+func (c *Client) requestHeader(ctx context.Context, reqOpts []func(*http.Request)) http.Header {
+	req, err := http.NewRequest(http.MethodPost, c.url, nil)
+	if err != nil {
+		req, _ = http.NewRequest(http.MethodPost, "", nil)
+	}
+	req = req.WithContext(ctx)
+
+	c.setTimeoutHeader(req)
+
+	for _, o := range c.reqOpts {
+		o(req)
+	}
+
+	c.applyContextRequestOptions(req)
+
+	for _, o := range reqOpts {
+		o(req)
+	}
+
+	return req.Header
+}
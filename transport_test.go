@@ -0,0 +1,307 @@
+package graphqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithTransport(t *testing.T) {
+	var got *Request
+
+	transport := transportFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		got = req
+
+		switch req.Query {
+		case "{ fail }":
+			return &Response{Errors: []Error{{Message: "failed"}}}, nil
+		case "{ down }":
+			return nil, errors.New("down")
+		}
+
+		return &Response{Data: json.RawMessage(`{"foo":"bar"}`)}, nil
+	})
+
+	c := NewClient("",
+		WithTransport(transport),
+		WithExtensions(map[string]interface{}{"foo": "bar"}),
+		WithRequestOptions(func(r *http.Request) {
+			r.Header.Set("X-Client", "1")
+		}),
+	)
+
+	t.Run("Data", func(t *testing.T) {
+		var data struct {
+			Foo string `json:"foo"`
+		}
+
+		err := c.Query(context.Background(), "{ foo }", map[string]interface{}{"id": "1"}, &data, func(r *http.Request) {
+			r.Header.Set("X-Call", "2")
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := data.Foo, "bar"; got != want {
+			t.Errorf("data.Foo = %q, want %q", got, want)
+		}
+
+		if got, want := got.Query, "{ foo }"; got != want {
+			t.Errorf("Query = %q, want %q", got, want)
+		}
+
+		if got, want := got.Variables["id"], "1"; got != want {
+			t.Errorf("Variables[id] = %v, want %v", got, want)
+		}
+
+		if got, want := got.Extensions["foo"], "bar"; got != want {
+			t.Errorf("Extensions[foo] = %v, want %v", got, want)
+		}
+
+		if got, want := got.Header.Get("X-Client")+got.Header.Get("X-Call"), "12"; got != want {
+			t.Errorf("headers = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		err := c.Query(context.Background(), "{ fail }", nil, nil)
+
+		errResp, ok := err.(*ErrorResponse)
+		if !ok {
+			t.Fatalf("err = %v, want *ErrorResponse", err)
+		}
+
+		if got, want := errResp.StatusCode, http.StatusOK; got != want {
+			t.Errorf("StatusCode = %d, want %d", got, want)
+		}
+
+		if got, want := errResp.Error(), "200 OK: failed"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		if got, want := c.Query(context.Background(), "{ down }", nil, nil).Error(), "down"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestHTTPTransport(t *testing.T) {
+	var gotHeader string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotHeader = r.Header.Get("X-Foo")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Rate-Limit", "10")
+			w.Write([]byte(`{"errors":[{"message":"partial"}],"data":{"foo":"bar"},"extensions":{"cost":1}}`))
+		},
+	))
+	defer ts.Close()
+
+	resp, err := NewClient(ts.URL).HTTPTransport().RoundTrip(context.Background(), &Request{
+		Query:  "{ foo }",
+		Header: http.Header{"X-Foo": {"bar"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := gotHeader, "bar"; got != want {
+		t.Errorf("X-Foo = %q, want %q", got, want)
+	}
+
+	if got, want := string(resp.Data), `{"foo":"bar"}`; got != want {
+		t.Errorf("Data = %s, want %s", got, want)
+	}
+
+	if got, want := len(resp.Errors), 1; got != want {
+		t.Fatalf("len(Errors) = %d, want %d", got, want)
+	}
+
+	if got, want := resp.Errors[0].Message, "partial"; got != want {
+		t.Errorf("Errors[0].Message = %q, want %q", got, want)
+	}
+
+	if got, want := string(resp.Extensions), `{"cost":1}`; got != want {
+		t.Errorf("Extensions = %s, want %s", got, want)
+	}
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("StatusCode = %d, want %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("X-Rate-Limit"), "10"; got != want {
+		t.Errorf("X-Rate-Limit = %q, want %q", got, want)
+	}
+}
+
+func TestWithTransportWrapper(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+
+			w.Write([]byte(`[{"data":{"foo":"bar"}},{"data":{"foo":"bar"}}]`))
+		},
+	))
+	defer ts.Close()
+
+	var calls []string
+
+	wrapper := func(name string) func(Transport) Transport {
+		return func(next Transport) Transport {
+			return transportFunc(func(ctx context.Context, req *Request) (*Response, error) {
+				mu.Lock()
+				calls = append(calls, name)
+				mu.Unlock()
+
+				return next.RoundTrip(ctx, req)
+			})
+		}
+	}
+
+	c := NewClient(ts.URL,
+		WithQueryBatching(50*time.Millisecond, 0),
+		WithRequestOptions(func(r *http.Request) {
+			r.Header.Set("X-Client", "1")
+		}),
+		WithTransportWrapper(wrapper("outer")),
+		WithTransportWrapper(wrapper("inner")),
+	)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var data struct {
+				Foo string `json:"foo"`
+			}
+
+			if err := c.Query(context.Background(), "{ foo }", nil, &data); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if got, want := data.Foo, "bar"; got != want {
+				t.Errorf("data.Foo = %q, want %q", got, want)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got, want := requests, 1; got != want {
+		t.Errorf("requests = %d, want %d", got, want)
+	}
+
+	// The outer wrapper is called first, and both for each query.
+	if got, want := calls[0], "outer"; got != want {
+		t.Errorf("calls[0] = %q, want %q", got, want)
+	}
+
+	sort.Strings(calls)
+
+	if got, want := strings.Join(calls, " "), "inner inner outer outer"; got != want {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/graphql-response+json")
+
+			switch r.Header.Get("X-Case") {
+			case "invalid":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":[{"message":"invalid query"}]}`))
+			case "down":
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(`bad gateway`))
+			default:
+				w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			}
+		},
+	))
+	defer ts.Close()
+
+	transport := transportFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{
+			Data:   json.RawMessage(`{"foo":null}`),
+			Errors: []Error{{Message: "partial"}},
+		}, nil
+	})
+
+	for _, tc := range []struct {
+		name           string
+		client         *Client
+		header         string
+		wantData       string
+		wantErrors     int
+		wantStatusCode int
+		wantErr        string
+	}{
+		{"Data", NewClient(ts.URL), "", `{"foo":"bar"}`, 0, http.StatusOK, ""},
+		{"RequestError", NewClient(ts.URL), "invalid", "", 1, http.StatusBadRequest, ""},
+		{"Failure", NewClient(ts.URL), "down", "", 0, 0, "502 Bad Gateway: bad gateway"},
+		{"Transport", NewClient("", WithTransport(transport)), "", `{"foo":null}`, 1, 0, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.client.Do(context.Background(), &Request{
+				Query:  "{ foo }",
+				Header: http.Header{"X-Case": {tc.header}},
+			})
+
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("err = nil, want %q", tc.wantErr)
+				}
+
+				if got, want := err.Error(), tc.wantErr; got != want {
+					t.Errorf("err = %q, want %q", got, want)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := string(resp.Data), tc.wantData; got != want {
+				t.Errorf("Data = %s, want %s", got, want)
+			}
+
+			if got, want := len(resp.Errors), tc.wantErrors; got != want {
+				t.Errorf("len(Errors) = %d, want %d", got, want)
+			}
+
+			if got, want := resp.StatusCode, tc.wantStatusCode; got != want {
+				t.Errorf("StatusCode = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+type transportFunc func(context.Context, *Request) (*Response, error)
+
+func (f transportFunc) RoundTrip(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,186 @@
+package graphqlclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// TransportStats is a snapshot of the connections of a client created with
+// WithTransportStats, and of the data transferred on them.
+type TransportStats struct {
+	// OpenConns is the number of open connections, and IdleConns the number
+	// of them not currently used by a request.
+	OpenConns int
+	IdleConns int
+
+	// Requests is the number of requests that got a connection, and
+	// ReusedConns the number of them that reused a previously used
+	// connection.
+	Requests    int64
+	ReusedConns int64
+
+	// BytesRead and BytesWritten are the numbers of bytes read from and
+	// written to connections, including headers and TLS overhead.
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// ReuseRatio returns the share of requests that reused a connection, or 0 if
+// no requests have been sent.
+func (s TransportStats) ReuseRatio() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(s.Requests)
+}
+
+// TransportStats returns a snapshot of the connections of the client and of
+// the data transferred on them. The stats are zero unless the client was
+// created with WithTransportStats.
+func (c *Client) TransportStats() TransportStats {
+	if c.transportStats == nil {
+		return TransportStats{}
+	}
+	return c.transportStats.snapshot()
+}
+
+// transportStats tracks the connections dialed by a transport instrumented by
+// WithTransportStats.
+type transportStats struct {
+	requests     int64
+	reusedConns  int64
+	bytesRead    int64
+	bytesWritten int64
+
+	mu        sync.Mutex
+	openConns int
+
+	// active holds the number of requests using each connection in use.
+	active map[net.Conn]int
+}
+
+func (s *transportStats) snapshot() TransportStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idle := s.openConns - len(s.active)
+	if idle < 0 {
+		idle = 0
+	}
+
+	return TransportStats{
+		OpenConns:    s.openConns,
+		IdleConns:    idle,
+		Requests:     atomic.LoadInt64(&s.requests),
+		ReusedConns:  atomic.LoadInt64(&s.reusedConns),
+		BytesRead:    atomic.LoadInt64(&s.bytesRead),
+		BytesWritten: atomic.LoadInt64(&s.bytesWritten),
+	}
+}
+
+// instrument makes t dial connections counted by s.
+func (s *transportStats) instrument(t *http.Transport) {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.DialContext = s.wrapDial(dial)
+
+	if t.DialTLSContext != nil {
+		t.DialTLSContext = s.wrapDial(t.DialTLSContext)
+	}
+}
+
+func (s *transportStats) wrapDial(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.openConns++
+		s.mu.Unlock()
+
+		return &statsConn{Conn: conn, stats: s}, nil
+	}
+}
+
+// trace returns ctx with a trace recording the connection used by the
+// request it is used for. The returned func must be called once the response
+// has been handled.
+func (s *transportStats) trace(ctx context.Context) (context.Context, func()) {
+	var (
+		mu   sync.Mutex
+		conn net.Conn
+	)
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			atomic.AddInt64(&s.requests, 1)
+			if info.Reused {
+				atomic.AddInt64(&s.reusedConns, 1)
+			}
+
+			s.mu.Lock()
+			if s.active == nil {
+				s.active = make(map[net.Conn]int)
+			}
+			s.active[info.Conn]++
+			s.mu.Unlock()
+
+			mu.Lock()
+			conn = info.Conn
+			mu.Unlock()
+		},
+	}
+
+	done := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if conn == nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.active[conn]--; s.active[conn] <= 0 {
+			delete(s.active, conn)
+		}
+		s.mu.Unlock()
+	}
+
+	return httptrace.WithClientTrace(ctx, trace), done
+}
+
+// statsConn is a connection counted by transportStats.
+type statsConn struct {
+	net.Conn
+	stats *transportStats
+	close sync.Once
+}
+
+func (c *statsConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(&c.stats.bytesRead, int64(n))
+	return n, err
+}
+
+func (c *statsConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	atomic.AddInt64(&c.stats.bytesWritten, int64(n))
+	return n, err
+}
+
+func (c *statsConn) Close() error {
+	c.close.Do(func() {
+		c.stats.mu.Lock()
+		c.stats.openConns--
+		c.stats.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
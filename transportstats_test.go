@@ -0,0 +1,77 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_TransportStats(t *testing.T) {
+	var (
+		c           *Client
+		statsDuring TransportStats
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			statsDuring = c.TransportStats()
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	c = NewClient(ts.URL, WithTransportStats())
+
+	for n := 0; n < 3; n++ {
+		if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := statsDuring.OpenConns, 1; got != want {
+		t.Errorf("OpenConns during request = %d, want %d", got, want)
+	}
+
+	if got, want := statsDuring.IdleConns, 0; got != want {
+		t.Errorf("IdleConns during request = %d, want %d", got, want)
+	}
+
+	stats := c.TransportStats()
+
+	if got, want := stats.OpenConns, 1; got != want {
+		t.Errorf("OpenConns = %d, want %d", got, want)
+	}
+
+	if got, want := stats.IdleConns, 1; got != want {
+		t.Errorf("IdleConns = %d, want %d", got, want)
+	}
+
+	if got, want := stats.Requests, int64(3); got != want {
+		t.Errorf("Requests = %d, want %d", got, want)
+	}
+
+	if got, want := stats.ReusedConns, int64(2); got != want {
+		t.Errorf("ReusedConns = %d, want %d", got, want)
+	}
+
+	if got, want := stats.ReuseRatio(), 2.0/3; got != want {
+		t.Errorf("ReuseRatio() = %v, want %v", got, want)
+	}
+
+	if stats.BytesRead == 0 || stats.BytesWritten == 0 {
+		t.Errorf("BytesRead, BytesWritten = %d, %d, want non-zero", stats.BytesRead, stats.BytesWritten)
+	}
+
+	c.httpClient.CloseIdleConnections()
+
+	if got, want := c.TransportStats().OpenConns, 0; got != want {
+		t.Errorf("OpenConns after closing idle connections = %d, want %d", got, want)
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		if got, want := NewClient(ts.URL).TransportStats(), (TransportStats{}); got != want {
+			t.Errorf("TransportStats() = %+v, want %+v", got, want)
+		}
+	})
+}
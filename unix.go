@@ -0,0 +1,37 @@
+package graphqlclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// NewUnixSocketClient returns a new client configured by opts sending
+// requests to the server listening on the Unix socket at socketPath, e.g. a
+// sidecar, at the URL path path, such as "/graphql". See WithUnixSocket.
+func NewUnixSocketClient(socketPath, path string, opts ...Option) *Client {
+	return NewClient("http://unix"+path, append([]Option{WithUnixSocket(socketPath)}, opts...)...)
+}
+
+// WithUnixSocket makes the client connect to the Unix socket at path instead
+// of the host of its URL, which is still sent in the Host header; any host,
+// such as "localhost", will do. Proxies are not used. The client's transport
+// is tuned as by WithMaxIdleConnsPerHost, so the option has no effect if it
+// is not an *http.Transport. Subscriptions are sent as usual, by their
+// SubscriptionTransport.
+func WithUnixSocket(path string) Option {
+	dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+
+	return func(c *Client) {
+		// Dialers set by other options, e.g. WithTransportStats,
+		// wrap this one.
+		c.transportOpts = append([]func(*http.Transport){func(t *http.Transport) {
+			t.DialContext = dial
+			t.DialTLSContext = nil
+			t.Proxy = nil
+		}}, c.transportOpts...)
+	}
+}
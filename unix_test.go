@@ -0,0 +1,53 @@
+package graphqlclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewUnixSocketClient(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "graphql.sock")
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+
+	var gotPath string
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		},
+	))
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	c := NewUnixSocketClient(socketPath, "/graphql", WithTransportStats())
+
+	var data struct {
+		Foo string `json:"foo"`
+	}
+
+	if err := c.Query(context.Background(), "{ foo }", nil, &data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := data.Foo, "bar"; got != want {
+		t.Errorf("data.Foo = %q, want %q", got, want)
+	}
+
+	if got, want := gotPath, "/graphql"; got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+
+	if got, want := c.TransportStats().OpenConns, 1; got != want {
+		t.Errorf("OpenConns = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package graphqlclient
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath is the path of this module, as listed in build information.
+const modulePath = "github.com/TV4/graphqlclient-go"
+
+var (
+	versionOnce sync.Once
+	moduleVer   string
+)
+
+// Version returns the version of this module the program was built with,
+// as recorded in its build information, or "devel" if unknown, e.g. in
+// tests or builds of a checked-out module.
+func Version() string {
+	versionOnce.Do(func() {
+		moduleVer = "devel"
+
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		mod := &bi.Main
+		for _, dep := range bi.Deps {
+			if dep.Path == modulePath {
+				mod = dep
+				if dep.Replace != nil {
+					mod = dep.Replace
+				}
+				break
+			}
+		}
+
+		if mod.Path == modulePath && mod.Version != "" && mod.Version != "(devel)" {
+			moduleVer = mod.Version
+		}
+	})
+
+	return moduleVer
+}
+
+// DefaultUserAgent returns the User-Agent header sent by clients created
+// without WithUserAgent, e.g. graphqlclient-go/v1.2.3.
+func DefaultUserAgent() string {
+	return "graphqlclient-go/" + Version()
+}
+
+// WithUserAgent makes the client send userAgent in the User-Agent header of
+// all requests instead of DefaultUserAgent. An empty userAgent makes the
+// client send the default of its transport, e.g. Go-http-client/1.1.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
@@ -0,0 +1,55 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	// Tests are built without the version of the module under test.
+	if got, want := Version(), "devel"; got != want {
+		t.Errorf("Version() = %q, want %q", got, want)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var userAgent string
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.UserAgent()
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"Default", nil, "graphqlclient-go/devel"},
+		{"UserAgent", []Option{WithUserAgent("foo-service/1.0")}, "foo-service/1.0"},
+		{"Empty", []Option{WithUserAgent("")}, "Go-http-client/"},
+		{
+			"RequestOptions",
+			[]Option{WithRequestOptions(func(req *http.Request) {
+				req.Header.Set("User-Agent", "bar-service/2.0")
+			})},
+			"bar-service/2.0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := NewClient(ts.URL, tc.opts...).Query(context.Background(), "foo-query", nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(userAgent, tc.want) {
+				t.Errorf("User-Agent = %q, want %q", userAgent, tc.want)
+			}
+		})
+	}
+}
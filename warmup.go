@@ -0,0 +1,89 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+)
+
+// warmupQuery is the query sent by Warmup. Every GraphQL server can answer
+// it, without resolving any fields of the schema.
+const warmupQuery = "{__typename}"
+
+// Warmup establishes n connections to the server, including their TLS
+// sessions, by sending n concurrent queries for __typename and holding each
+// connection until all n are established. The connections are then kept idle
+// by the transport, so that the first requests sent after a deploy don't pay
+// the latency of connecting. The transport closes idle connections after its
+// IdleConnTimeout, and keeps at most MaxIdleConnsPerHost of them, so n should
+// not exceed that; see WithMaxIdleConnsPerHost.
+//
+// The queries are sent over HTTP as by Query, but never in batches, see
+// WithQueryBatching, as trusted documents or as automatic persisted queries.
+// Warmup returns the first error of the queries. HTTP/2 servers typically
+// serve all queries on a single connection.
+func (c *Client) Warmup(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var connected sync.WaitGroup
+	connected.Add(n)
+
+	ready := make(chan struct{})
+	go func() {
+		connected.Wait()
+		close(ready)
+	}()
+
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var once sync.Once
+			done := func() { once.Do(connected.Done) }
+
+			// Queries that fail before getting a connection must not
+			// keep the others waiting.
+			defer done()
+
+			trace := &httptrace.ClientTrace{
+				GotConn: func(httptrace.GotConnInfo) {
+					done()
+
+					select {
+					case <-ready:
+					case <-ctx.Done():
+					}
+				},
+			}
+
+			// Sent directly, as batching would merge the queries
+			// into a single request.
+			errs[i] = c.send(httptrace.WithClientTrace(ctx, trace), operation{query: warmupQuery}, nil, nil, nil)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("error warming up connections: %v", err)
+		}
+	}
+
+	return nil
+}
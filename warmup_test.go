@@ -0,0 +1,107 @@
+package graphqlclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Warmup(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+		},
+	))
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	c := NewClient(ts.URL, WithMaxIdleConnsPerHost(4))
+
+	if err := c.Warmup(context.Background(), 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	if got, want := conns, 4; got != want {
+		t.Errorf("conns after warmup = %d, want %d", got, want)
+	}
+	mu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := c.Query(context.Background(), "foo-query", nil, nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if got, want := conns, 4; got != want {
+		t.Errorf("conns after queries = %d, want %d", got, want)
+	}
+
+	t.Run("QueryBatching", func(t *testing.T) {
+		var requests int32
+
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+			},
+		))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, WithMaxIdleConnsPerHost(2), WithQueryBatching(time.Hour, 0))
+
+		if err := c.Warmup(context.Background(), 2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := atomic.LoadInt32(&requests), int32(2); got != want {
+			t.Errorf("requests = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"message":"unauthorized"}]}`))
+			},
+		))
+		defer ts.Close()
+
+		err := NewClient(ts.URL).Warmup(context.Background(), 2)
+
+		if got, want := err.Error(), "error warming up connections: 401 Unauthorized: unauthorized"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package graphqlclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Warning is a warning sent by a server in a Warning header, as specified by
+// RFC 7234, or in an X-Warning header, e.g. to flag deprecated operations or
+// degraded services.
+type Warning struct {
+	// Code is the warn-code, e.g. 299 for a miscellaneous persistent
+	// warning, or 0 for X-Warning headers.
+	Code int
+
+	// Agent is the warn-agent, the host or pseudonym of the server adding
+	// the warning, or "-" if unknown. It is empty for X-Warning headers.
+	Agent string
+
+	// Text is the warn-text, or the value of an X-Warning header.
+	Text string
+
+	// Date is the warn-date, if any.
+	Date time.Time
+}
+
+// ParseWarnings returns the warnings in the Warning and X-Warning headers of
+// h. Malformed Warning values are ignored from the first error on.
+func ParseWarnings(h http.Header) []Warning {
+	var warnings []Warning
+
+	for _, v := range h.Values("Warning") {
+		warnings = parseWarning(warnings, v)
+	}
+
+	for _, v := range h.Values("X-Warning") {
+		if v = strings.TrimSpace(v); v != "" {
+			warnings = append(warnings, Warning{Text: v})
+		}
+	}
+
+	return warnings
+}
+
+// parseWarning appends the comma-separated warning values in s to warnings,
+// each of the form: warn-code SP warn-agent SP warn-text [ SP warn-date ].
+func parseWarning(warnings []Warning, s string) []Warning {
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return warnings
+		}
+
+		var (
+			w  Warning
+			ok bool
+		)
+
+		if len(s) < 4 || s[3] != ' ' {
+			return warnings
+		}
+
+		code, err := strconv.Atoi(s[:3])
+		if err != nil {
+			return warnings
+		}
+		w.Code = code
+		s = s[4:]
+
+		i := strings.IndexByte(s, ' ')
+		if i <= 0 {
+			return warnings
+		}
+		w.Agent, s = s[:i], s[i+1:]
+
+		if w.Text, s, ok = cutQuotedString(s); !ok {
+			return warnings
+		}
+
+		if strings.HasPrefix(s, ` "`) {
+			var date string
+			if date, s, ok = cutQuotedString(s[1:]); !ok {
+				return warnings
+			}
+			w.Date, _ = http.ParseTime(date)
+		}
+
+		warnings = append(warnings, w)
+	}
+}
+
+// cutQuotedString returns the value of the quoted string s begins with, and
+// the rest of s after it.
+func cutQuotedString(s string) (value, rest string, ok bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", s, false
+	}
+
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			if i++; i == len(s) {
+				return "", s, false
+			}
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", s, false
+}
+
+// WithWarningHandler makes the client call fn with the context and the
+// warnings of each response with Warning or X-Warning headers, before its
+// body is decoded.
+func WithWarningHandler(fn func(ctx context.Context, warnings []Warning)) Option {
+	return func(c *Client) {
+		c.warningHandler = fn
+	}
+}
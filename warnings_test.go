@@ -0,0 +1,89 @@
+package graphqlclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseWarnings(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "None",
+			header: http.Header{},
+			want:   "[]",
+		},
+		{
+			name:   "Warning",
+			header: http.Header{"Warning": {`299 gateway "Operation GetUser is deprecated"`}},
+			want:   `[{299 gateway Operation GetUser is deprecated 0001-01-01 00:00:00 +0000 UTC}]`,
+		},
+		{
+			name:   "Date",
+			header: http.Header{"Warning": {`199 - "Degraded: search" "Wed, 21 Oct 2015 07:28:00 GMT"`}},
+			want:   `[{199 - Degraded: search 2015-10-21 07:28:00 +0000 UTC}]`,
+		},
+		{
+			name:   "Multiple",
+			header: http.Header{"Warning": {`299 - "a, \"b\"", 199 - "c"`, `299 - "d"`}},
+			want:   `[{299 - a, "b" 0001-01-01 00:00:00 +0000 UTC} {199 - c 0001-01-01 00:00:00 +0000 UTC} {299 - d 0001-01-01 00:00:00 +0000 UTC}]`,
+		},
+		{
+			name:   "Malformed",
+			header: http.Header{"Warning": {`299 - "a", 2x9 - "b", 299 - "c"`, `299 - "unterminated`}},
+			want:   `[{299 - a 0001-01-01 00:00:00 +0000 UTC}]`,
+		},
+		{
+			name:   "XWarning",
+			header: http.Header{"X-Warning": {"search is degraded", " "}},
+			want:   `[{0  search is degraded 0001-01-01 00:00:00 +0000 UTC}]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := fmt.Sprint(ParseWarnings(tc.header)), tc.want; got != want {
+				t.Errorf("ParseWarnings(%q) = %s, want %s", tc.header, got, want)
+			}
+		})
+	}
+}
+
+func TestWithWarningHandler(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("warn") != "" {
+				w.Header().Set("Warning", `299 - "deprecated"`)
+			}
+			w.Write([]byte(`{"data":{}}`))
+		},
+	))
+	defer ts.Close()
+
+	type key struct{}
+
+	var calls []string
+
+	c := NewClient(ts.URL, WithWarningHandler(func(ctx context.Context, warnings []Warning) {
+		calls = append(calls, fmt.Sprintf("%v:%s", ctx.Value(key{}), warnings[0].Text))
+	}))
+
+	ctx := context.WithValue(context.Background(), key{}, "ctx")
+
+	for _, warn := range []string{"", "1"} {
+		err := c.Query(ctx, "foo-query", nil, nil, func(req *http.Request) {
+			req.URL.RawQuery = "warn=" + warn
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := fmt.Sprint(calls), "[ctx:deprecated]"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,626 @@
+package graphqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+// SubscriptionProtocol is a protocol for subscriptions over WebSocket.
+type SubscriptionProtocol int
+
+const (
+	// GraphQLTransportWS is the graphql-transport-ws protocol of the
+	// graphql-ws library, served by Apollo Server and most current GraphQL
+	// servers, see
+	// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+	GraphQLTransportWS SubscriptionProtocol = iota
+
+	// LegacyWS is the protocol of the deprecated subscriptions-transport-ws
+	// library, served by older Apollo servers, see
+	// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md.
+	LegacyWS
+)
+
+// wsProtocol holds the WebSocket subprotocol of a SubscriptionProtocol and
+// the types of the messages that differ between them.
+type wsProtocol struct {
+	subprotocol string
+	subscribe   string
+	next        string
+	stop        string
+}
+
+var wsProtocols = map[SubscriptionProtocol]wsProtocol{
+	GraphQLTransportWS: {
+		subprotocol: "graphql-transport-ws",
+		subscribe:   "subscribe",
+		next:        "next",
+		stop:        "complete",
+	},
+	LegacyWS: {
+		subprotocol: "graphql-ws",
+		subscribe:   "start",
+		next:        "data",
+		stop:        "stop",
+	},
+}
+
+// WebSocketTransport is a SubscriptionTransport speaking a subscription
+// protocol over WebSocket, by default graphql-transport-ws. Subscriptions to
+// the same URL with the same headers are multiplexed on a single connection,
+// which is closed when its last subscription ends and shares the connection
+// params it was established with. It is the default transport of clients.
+type WebSocketTransport struct {
+	// URL is the ws:// or wss:// URL of the server. Defaults to the
+	// client's URL, with the http and https schemes taken as ws and wss.
+	URL string
+
+	// Protocol is the protocol spoken.
+	Protocol SubscriptionProtocol
+
+	// MaxSubscriptionsPerConnection is the number of subscriptions
+	// multiplexed on a connection before another one is opened. Zero means
+	// no limit.
+	MaxSubscriptionsPerConnection int
+
+	// PingInterval, if set, is the interval at which connections are kept
+	// alive by sending ping frames. A connection is taken as lost when no
+	// pong is received within PongTimeout of a ping, which defaults to
+	// PingInterval.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+
+	// ReadTimeout, if set, is the time allowed for receiving each message.
+	// Pongs count as messages. A connection is taken as lost when it
+	// expires.
+	ReadTimeout time.Duration
+
+	// WriteTimeout, if set, is the time allowed for sending each message.
+	WriteTimeout time.Duration
+
+	mu    sync.Mutex
+	conns map[string][]*wsConn
+}
+
+// WithSubscriptionProtocol makes the client send subscriptions over
+// WebSocket using p, e.g. LegacyWS for servers only speaking the
+// subscriptions-transport-ws protocol.
+func WithSubscriptionProtocol(p SubscriptionProtocol) Option {
+	return WithSubscriptionTransport(&WebSocketTransport{Protocol: p})
+}
+
+// wsMessage is a message of the WebSocket subscription protocols.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsMaxPendingMessages is the number of messages buffered for each
+// subscription. Once a subscription's buffer is full, reading from its
+// connection waits for its handler.
+const wsMaxPendingMessages = 16
+
+// Subscribe implements SubscriptionTransport.
+func (t *WebSocketTransport) Subscribe(ctx context.Context, sub *Subscription, handler SubscriptionHandler) error {
+	proto, ok := wsProtocols[t.Protocol]
+	if !ok {
+		return fmt.Errorf("unknown subscription protocol %d", t.Protocol)
+	}
+
+	c, id, s, err := t.acquire(ctx, sub, proto)
+	if err != nil {
+		return err
+	}
+
+	// Subscriptions ended by the server need not be stopped.
+	var ended bool
+	defer func() {
+		c.release(id, !ended)
+	}()
+
+	err = c.send(id, proto.subscribe, map[string]interface{}{
+		"query":     sub.Query,
+		"variables": sub.Variables,
+	})
+	if err != nil {
+		return fmt.Errorf("error subscribing: %v", err)
+	}
+
+	for {
+		var msg *wsMessage
+
+		select {
+		case msg = <-s.msgs:
+		case <-c.done:
+			// Messages received before the connection was lost are
+			// still handled.
+			select {
+			case msg = <-s.msgs:
+			default:
+				return c.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		switch msg.Type {
+		case proto.next:
+			var result struct {
+				Data   json.RawMessage `json:"data"`
+				Errors []Error         `json:"errors"`
+			}
+
+			if err := json.Unmarshal(msg.Payload, &result); err != nil {
+				return fmt.Errorf("error decoding event: %v", err)
+			}
+
+			if err := handler(result.Data, result.Errors); err != nil {
+				return err
+			}
+		case "error":
+			ended = true
+
+			// Legacy servers may send a single error object.
+			errs, err := decodeLenientErrors(msg.Payload)
+			if err != nil {
+				return fmt.Errorf("error decoding errors: %v", err)
+			}
+
+			return &SubscriptionError{Errors: errs}
+		case "complete":
+			ended = true
+			return nil
+		}
+	}
+}
+
+// acquire registers a subscription on a connection for sub, opening one if
+// none with room for it is open, and returns the connection, the ID of the
+// subscription and the subscription.
+func (t *WebSocketTransport) acquire(ctx context.Context, sub *Subscription, proto wsProtocol) (*wsConn, string, *wsSub, error) {
+	url := t.URL
+	if url == "" {
+		url = sub.URL
+	}
+
+	var key bytes.Buffer
+	key.WriteString(url + "\n")
+	sub.Header.Write(&key)
+
+	t.mu.Lock()
+
+	var c *wsConn
+	for _, cc := range t.conns[key.String()] {
+		if t.MaxSubscriptionsPerConnection <= 0 || len(cc.subs) < t.MaxSubscriptionsPerConnection {
+			c = cc
+			break
+		}
+	}
+
+	open := c == nil
+	if open {
+		c = &wsConn{
+			t:     t,
+			key:   key.String(),
+			proto: proto,
+			subs:  map[string]*wsSub{},
+			ready: make(chan struct{}),
+			done:  make(chan struct{}),
+			pongs: make(chan struct{}, 1),
+		}
+
+		if t.conns == nil {
+			t.conns = map[string][]*wsConn{}
+		}
+		t.conns[c.key] = append(t.conns[c.key], c)
+	}
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+
+	s := &wsSub{
+		msgs: make(chan *wsMessage, wsMaxPendingMessages),
+		done: make(chan struct{}),
+	}
+	c.subs[id] = s
+
+	t.mu.Unlock()
+
+	if open {
+		c.connect(ctx, url, sub)
+	}
+
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		c.release(id, false)
+		return nil, "", nil, ctx.Err()
+	}
+
+	if c.initErr != nil {
+		c.release(id, false)
+		return nil, "", nil, c.initErr
+	}
+
+	return c, id, s, nil
+}
+
+// removeLocked removes c from the open connections. t.mu must be held.
+func (t *WebSocketTransport) removeLocked(c *wsConn) {
+	c.closing = true
+
+	conns := t.conns[c.key]
+	for i, cc := range conns {
+		if cc == c {
+			conns = append(conns[:i:i], conns[i+1:]...)
+			break
+		}
+	}
+
+	if len(conns) == 0 {
+		delete(t.conns, c.key)
+	} else {
+		t.conns[c.key] = conns
+	}
+}
+
+// wsConn is a WebSocket connection multiplexing subscriptions.
+type wsConn struct {
+	t     *WebSocketTransport
+	key   string
+	proto wsProtocol
+
+	// ready is closed once the connection is initialized, or failed to be
+	// with initErr.
+	ready   chan struct{}
+	initErr error
+
+	// done is closed once the connection is lost, with err.
+	done chan struct{}
+	err  error
+
+	// pongs receives the pongs received.
+	pongs chan struct{}
+
+	// conn, subs, nextID, closing and cause are guarded by t.mu. conn is
+	// set before ready is closed.
+	conn    *websocket.Conn
+	subs    map[string]*wsSub
+	nextID  int
+	closing bool
+
+	// cause, if set, is why the connection was closed as lost.
+	cause error
+}
+
+// wsSub is a subscription on a wsConn.
+type wsSub struct {
+	// msgs receives the messages for the subscription.
+	msgs chan *wsMessage
+
+	// done is closed once the subscription is released.
+	done chan struct{}
+}
+
+// connect opens and initializes the connection, then starts reading from it.
+func (c *wsConn) connect(ctx context.Context, url string, sub *Subscription) {
+	err := c.init(ctx, url, sub)
+
+	if err != nil {
+		c.t.mu.Lock()
+		c.t.removeLocked(c)
+		conn := c.conn
+		c.t.mu.Unlock()
+
+		if conn != nil {
+			conn.Close()
+		}
+
+		c.initErr = err
+		close(c.ready)
+		close(c.done)
+
+		return
+	}
+
+	close(c.ready)
+
+	go c.readLoop()
+
+	if c.t.PingInterval > 0 {
+		go c.keepalive()
+	}
+}
+
+// errPongTimeout is the error of connections lost because no pong was
+// received in time.
+var errPongTimeout = errors.New("no pong received within timeout")
+
+// keepalive sends pings at the transport's ping interval until the connection
+// is lost, and closes it if a pong is not received in time.
+func (c *wsConn) keepalive() {
+	timeout := c.t.PongTimeout
+	if timeout <= 0 {
+		timeout = c.t.PingInterval
+	}
+
+	ticker := time.NewTicker(c.t.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+
+		c.setWriteDeadline()
+
+		if err := c.conn.Ping(nil); err != nil {
+			c.lose(err)
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+
+		select {
+		case <-c.pongs:
+			timer.Stop()
+		case <-timer.C:
+			c.lose(errPongTimeout)
+			return
+		case <-c.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// lose closes the connection as lost because of err.
+func (c *wsConn) lose(err error) {
+	c.t.mu.Lock()
+	if c.cause == nil {
+		c.cause = err
+	}
+	c.t.mu.Unlock()
+
+	c.conn.Close()
+}
+
+func (c *wsConn) init(ctx context.Context, url string, sub *Subscription) error {
+	var params map[string]interface{}
+
+	if sub.ConnectionParams != nil {
+		var err error
+		if params, err = sub.ConnectionParams(ctx); err != nil {
+			return fmt.Errorf("error getting connection params: %v", err)
+		}
+	}
+
+	d := websocket.Dialer{
+		Subprotocols: []string{c.proto.subprotocol},
+		Header:       sub.Header,
+	}
+
+	conn, _, err := d.Dial(ctx, url)
+	if err != nil {
+		return fmt.Errorf("error connecting: %v", err)
+	}
+
+	c.t.mu.Lock()
+	c.conn = conn
+	closing := c.closing
+	c.t.mu.Unlock()
+
+	if closing {
+		// All subscriptions were released while connecting.
+		return errors.New("connection closed")
+	}
+
+	if conn.Subprotocol() != c.proto.subprotocol {
+		conn.WriteClose(websocket.CloseProtocolError, "")
+		return fmt.Errorf("server does not speak %s", c.proto.subprotocol)
+	}
+
+	conn.SetPongHandler(func([]byte) {
+		c.setReadDeadline()
+
+		select {
+		case c.pongs <- struct{}{}:
+		default:
+		}
+	})
+
+	// Reads fail once the connection is closed when ctx is done.
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	// A nil map is omitted rather than sent as null.
+	var payload interface{}
+	if params != nil {
+		payload = params
+	}
+
+	if err := c.send("", "connection_init", payload); err != nil {
+		return fmt.Errorf("error initializing connection: %v", err)
+	}
+
+	msg, err := c.read()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("error initializing connection: %v", err)
+	}
+
+	switch msg.Type {
+	case "connection_ack":
+	case "connection_error":
+		return fmt.Errorf("error initializing connection: %s", msg.Payload)
+	default:
+		return fmt.Errorf("error initializing connection: unexpected %q message", msg.Type)
+	}
+
+	return nil
+}
+
+// readLoop dispatches the messages read to their subscriptions until the
+// connection is lost.
+func (c *wsConn) readLoop() {
+	var err error
+
+	for {
+		var msg *wsMessage
+		if msg, err = c.read(); err != nil {
+			break
+		}
+
+		c.t.mu.Lock()
+		s := c.subs[msg.ID]
+		c.t.mu.Unlock()
+
+		if s == nil {
+			continue
+		}
+
+		select {
+		case s.msgs <- msg:
+		case <-s.done:
+		}
+	}
+
+	c.t.mu.Lock()
+	if !c.closing {
+		c.t.removeLocked(c)
+	}
+	if c.cause != nil {
+		err = c.cause
+	}
+	c.t.mu.Unlock()
+
+	c.conn.Close()
+
+	c.err = err
+	close(c.done)
+}
+
+// release unregisters the subscription with id, stopping it if stop is set,
+// and closes the connection if it was the last one.
+func (c *wsConn) release(id string, stop bool) {
+	c.t.mu.Lock()
+
+	if s, ok := c.subs[id]; ok {
+		close(s.done)
+		delete(c.subs, id)
+	}
+
+	last := len(c.subs) == 0 && !c.closing
+	if last {
+		c.t.removeLocked(c)
+	}
+
+	conn := c.conn
+
+	c.t.mu.Unlock()
+
+	if conn == nil {
+		return
+	}
+
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	if stop {
+		c.send(id, c.proto.stop, nil)
+	}
+
+	if last {
+		conn.WriteClose(websocket.CloseNormalClosure, "")
+		conn.Close()
+	}
+}
+
+// send sends a message with payload, which is omitted if nil.
+func (c *wsConn) send(id, typ string, payload interface{}) error {
+	msg := wsMessage{ID: id, Type: typ}
+
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		msg.Payload = b
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.setWriteDeadline()
+
+	return c.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// setReadDeadline sets the deadline for the next message to be received if
+// the transport has a read timeout.
+func (c *wsConn) setReadDeadline() {
+	if c.t.ReadTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.t.ReadTimeout))
+	}
+}
+
+// setWriteDeadline sets the deadline for the next message to be sent if the
+// transport has a write timeout.
+func (c *wsConn) setWriteDeadline() {
+	if c.t.WriteTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.t.WriteTimeout))
+	}
+}
+
+// read reads the next message, answering pings and skipping keepalives.
+func (c *wsConn) read() (*wsMessage, error) {
+	for {
+		c.setReadDeadline()
+
+		_, p, err := c.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(p, &msg); err != nil {
+			return nil, fmt.Errorf("error decoding message: %v", err)
+		}
+
+		switch msg.Type {
+		case "ping":
+			if err := c.send("", "pong", nil); err != nil {
+				return nil, err
+			}
+		case "pong", "ka":
+		default:
+			return &msg, nil
+		}
+	}
+}
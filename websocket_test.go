@@ -0,0 +1,420 @@
+package graphqlclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	graphqlclient "github.com/TV4/graphqlclient-go"
+	"github.com/TV4/graphqlclient-go/graphqltest"
+	"github.com/TV4/graphqlclient-go/internal/websocket"
+)
+
+// The transport is tested against graphqltest.SubscriptionServer, which
+// imports this package.
+
+func TestWebSocketTransport(t *testing.T) {
+	s := graphqltest.NewSubscriptionServer()
+	defer s.Close()
+
+	s.Script("OnFoo",
+		graphqltest.Next(map[string]string{"foo": "1"}),
+		graphqltest.NextWithErrors(nil, graphqlclient.Error{Message: "partial"}),
+		graphqltest.Next(map[string]string{"foo": "2"}),
+		graphqltest.Complete(),
+	)
+	s.Script("OnError", graphqltest.Error(graphqlclient.Error{Message: "invalid"}))
+	s.Script("OnEndless", graphqltest.Next(map[string]string{"foo": "1"}))
+
+	// The client's URL is taken as that of the WebSocket server.
+	c := graphqlclient.NewClient(s.URL)
+
+	t.Run("Events", func(t *testing.T) {
+		var events []string
+
+		err := c.Subscribe(context.Background(), "subscription OnFoo($id: ID!) { foo(id: $id) }", map[string]interface{}{"id": "123"},
+			func(data json.RawMessage, errs []graphqlclient.Error) error {
+				events = append(events, fmt.Sprintf("%s %v", data, errs))
+				return nil
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := `{"foo":"1"} [],null [{partial [] [] map[]}],{"foo":"2"} []`
+
+		if got := strings.Join(events, ","); got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+
+		graphqltest.AssertVariables(t, s.Subscriptions(), "OnFoo", map[string]interface{}{"id": "123"})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription OnError { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		var subErr *graphqlclient.SubscriptionError
+		if !errors.As(err, &subErr) {
+			t.Fatalf("err = %v, want %T", err, subErr)
+		}
+
+		if got, want := subErr.Error(), "subscription error: invalid"; got != want {
+			t.Errorf("subErr.Error() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("HandlerError", func(t *testing.T) {
+		errStop := errors.New("stop")
+
+		err := c.Subscribe(context.Background(), "subscription OnEndless { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return errStop },
+		)
+
+		if got, want := err, errStop; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := c.Subscribe(ctx, "subscription OnEndless { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		if got, want := err, context.DeadlineExceeded; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s.Script("OnDisconnect", graphqltest.Disconnect())
+
+		err := c.Subscribe(context.Background(), "subscription OnDisconnect { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		if err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		h := graphqlclient.NewClient("http://127.0.0.1:0/graphql",
+			graphqlclient.WithSubscriptionTransport(&graphqlclient.WebSocketTransport{URL: s.WSURL()}),
+		)
+
+		err := h.Subscribe(context.Background(), "subscription OnFoo { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestWebSocketTransport_LegacyWS(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Upgrade(w, r, []string{"graphql-ws"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			send := func(msg string) {
+				conn.WriteMessage(websocket.TextMessage, []byte(msg))
+			}
+
+			for {
+				_, p, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+
+				var msg struct {
+					ID      string `json:"id"`
+					Type    string `json:"type"`
+					Payload struct {
+						Query string `json:"query"`
+					} `json:"payload"`
+				}
+				json.Unmarshal(p, &msg)
+
+				mu.Lock()
+				received = append(received, msg.Type)
+				mu.Unlock()
+
+				switch msg.Type {
+				case "connection_init":
+					send(`{"type":"connection_ack"}`)
+					send(`{"type":"ka"}`)
+				case "start":
+					if strings.Contains(msg.Payload.Query, "OnError") {
+						send(`{"id":"` + msg.ID + `","type":"error","payload":{"message":"invalid"}}`)
+						continue
+					}
+
+					send(`{"id":"` + msg.ID + `","type":"data","payload":{"data":{"foo":"1"}}}`)
+					send(`{"type":"ka"}`)
+					send(`{"id":"` + msg.ID + `","type":"data","payload":{"data":null,"errors":[{"message":"partial"}]}}`)
+					send(`{"id":"` + msg.ID + `","type":"complete"}`)
+				}
+			}
+		},
+	))
+	defer ts.Close()
+
+	c := graphqlclient.NewClient(ts.URL, graphqlclient.WithSubscriptionProtocol(graphqlclient.LegacyWS))
+
+	t.Run("Events", func(t *testing.T) {
+		var events []string
+
+		err := c.Subscribe(context.Background(), "subscription OnFoo { foo }", nil,
+			func(data json.RawMessage, errs []graphqlclient.Error) error {
+				events = append(events, fmt.Sprintf("%s %v", data, errs))
+				return nil
+			},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := strings.Join(events, ","), `{"foo":"1"} [],null [{partial [] [] map[]}]`; got != want {
+			t.Errorf("events = %s, want %s", got, want)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if got, want := strings.Join(received[:2], ","), "connection_init,start"; got != want {
+			t.Errorf("received = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		err := c.Subscribe(context.Background(), "subscription OnError { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		var subErr *graphqlclient.SubscriptionError
+		if !errors.As(err, &subErr) {
+			t.Fatalf("err = %v, want %T", err, subErr)
+		}
+
+		if got, want := subErr.Error(), "subscription error: invalid"; got != want {
+			t.Errorf("subErr.Error() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Subprotocol", func(t *testing.T) {
+		s := graphqltest.NewSubscriptionServer()
+		defer s.Close()
+
+		err := graphqlclient.NewClient(s.URL, graphqlclient.WithSubscriptionProtocol(graphqlclient.LegacyWS)).Subscribe(
+			context.Background(), "subscription OnFoo { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		if err == nil {
+			t.Error("err = nil, want error")
+		}
+	})
+}
+
+func TestWebSocketTransport_Multiplexing(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		max       int
+		wantConns int
+	}{
+		{"Unlimited", 0, 1},
+		{"MaxSubscriptionsPerConnection", 2, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := graphqltest.NewSubscriptionServer()
+			defer s.Close()
+
+			s.Script("OnEndless", graphqltest.Next(map[string]string{"foo": "1"}))
+
+			c := graphqlclient.NewClient(s.URL, graphqlclient.WithSubscriptionTransport(
+				&graphqlclient.WebSocketTransport{MaxSubscriptionsPerConnection: tc.max},
+			))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var (
+				wg     sync.WaitGroup
+				events = make(chan struct{}, 3)
+			)
+
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					err := c.Subscribe(ctx, "subscription OnEndless { foo }", nil,
+						func(json.RawMessage, []graphqlclient.Error) error {
+							events <- struct{}{}
+							return nil
+						},
+					)
+
+					if got, want := err, context.Canceled; got != want {
+						t.Errorf("err = %v, want %v", got, want)
+					}
+				}()
+			}
+
+			// Every subscription receives its own event.
+			for i := 0; i < 3; i++ {
+				select {
+				case <-events:
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for events")
+				}
+			}
+
+			cancel()
+			wg.Wait()
+
+			if got, want := len(s.InitPayloads()), tc.wantConns; got != want {
+				t.Errorf("connections = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestWithConnectionParams(t *testing.T) {
+	s := graphqltest.NewSubscriptionServer()
+	defer s.Close()
+
+	s.Script("OnFoo", graphqltest.Complete())
+
+	var calls int
+
+	c := graphqlclient.NewClient(s.URL, graphqlclient.WithConnectionParams(
+		func(ctx context.Context) (map[string]interface{}, error) {
+			calls++
+			return map[string]interface{}{"authToken": fmt.Sprintf("token-%d", calls)}, nil
+		},
+	))
+
+	// Each connection gets fresh params.
+	for i := 0; i < 2; i++ {
+		err := c.Subscribe(context.Background(), "subscription OnFoo { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got, want := fmt.Sprint(s.InitPayloads()), "[map[authToken:token-1] map[authToken:token-2]]"; got != want {
+		t.Errorf("s.InitPayloads() = %s, want %s", got, want)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		c := graphqlclient.NewClient(s.URL, graphqlclient.WithConnectionParams(
+			func(ctx context.Context) (map[string]interface{}, error) {
+				return nil, errors.New("no token")
+			},
+		))
+
+		err := c.Subscribe(context.Background(), "subscription OnFoo { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+
+		if got, want := fmt.Sprint(err), "error getting connection params: no token"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestWebSocketTransport_Keepalive(t *testing.T) {
+	s := graphqltest.NewSubscriptionServer()
+	defer s.Close()
+
+	s.Script("OnQuiet", graphqltest.Pause(time.Hour))
+
+	subscribe := func(transport *graphqlclient.WebSocketTransport, url string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		c := graphqlclient.NewClient(url, graphqlclient.WithSubscriptionTransport(transport))
+
+		return c.Subscribe(ctx, "subscription OnQuiet { foo }", nil,
+			func(json.RawMessage, []graphqlclient.Error) error { return nil },
+		)
+	}
+
+	t.Run("Pings", func(t *testing.T) {
+		err := subscribe(&graphqlclient.WebSocketTransport{
+			PingInterval: 10 * time.Millisecond,
+			ReadTimeout:  50 * time.Millisecond,
+		}, s.URL)
+
+		// Pongs keep the connection from timing out.
+		if got, want := err, context.DeadlineExceeded; got != want {
+			t.Errorf("err = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ReadTimeout", func(t *testing.T) {
+		err := subscribe(&graphqlclient.WebSocketTransport{
+			ReadTimeout: 50 * time.Millisecond,
+		}, s.URL)
+
+		if err == nil || err == context.DeadlineExceeded {
+			t.Errorf("err = %v, want read timeout", err)
+		}
+	})
+
+	t.Run("PongTimeout", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				conn, err := websocket.Upgrade(w, r, []string{"graphql-transport-ws"})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				defer conn.Close()
+
+				conn.ReadMessage()
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"connection_ack"}`))
+				conn.ReadMessage()
+
+				// Pings are only answered while reading.
+				time.Sleep(200 * time.Millisecond)
+			},
+		))
+		defer ts.Close()
+
+		err := subscribe(&graphqlclient.WebSocketTransport{
+			PingInterval: 10 * time.Millisecond,
+			PongTimeout:  20 * time.Millisecond,
+		}, ts.URL)
+
+		if got, want := fmt.Sprint(err), "no pong received within timeout"; got != want {
+			t.Errorf("err = %q, want %q", got, want)
+		}
+	})
+}